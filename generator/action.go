@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// Generate comments for the structures that are generated for the action
+func addActionComment(w io.Writer, a *yang.Action) {
+	fmt.Fprintln(w, "//------------------------------------------------------------")
+	fmt.Fprint(w, "//  Name:\n")
+	s := indentString("action: " + a.NName())
+	s = commentString(s)
+	fmt.Fprint(w, s)
+	fmt.Fprint(w, "//  Description:\n")
+	if a.Description != nil {
+		s = indentString(a.Description.Name)
+		s = commentString(s)
+		fmt.Fprint(w, s)
+	}
+	fmt.Fprintln(w, "//-------------------------------------------------------------")
+}
+
+// An action is an rpc that is tied to a data node. The input and output
+// are generated the same way as for an rpc. In addition, the input gets
+// the functions that build the <action> envelope which carries the path
+// to the data node on which the action is invoked. If the path can be
+// determined statically, the keys of the lists along the path are the
+// parameters of the function Envelope(). Otherwise, only EnvelopeAt()
+// is generated and the caller supplies the path.
+func genTypeForAction(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.Node) {
+	a, ok := n.(*yang.Action)
+	if !ok {
+		errorlog("genTypeForAction(): %s.%s is not an action", n.NName(), n.Kind())
+		return
+	}
+	addActionComment(w, a)
+	name := fullName(a)
+	mod := getMyModule(a)
+	generateRpcDescriptor(w, mod, ymod, a.NName(), name)
+	genTypeForInput(w, ymod, mod, a.Input, name, a.NName())
+	genTypeForOutput(w, ymod, mod, a.Output, name)
+
+	tn := genTN(ymod, name+"_input") + "_cont"
+	fmt.Fprintf(w, "func (x %s) EnvelopeAt(path []nc.PathElem) nc.Action {\n", tn)
+	fmt.Fprintf(w, "\treturn nc.Action{Path: path, Input: x}\n")
+	fmt.Fprintf(w, "}\n")
+	path := getInstancePath(a.ParentNode())
+	if path == nil {
+		debuglog("genTypeForAction(): path of %s.%s is not known", a.NName(), a.Kind())
+		return
+	}
+	generateActionEnvelope(w, tn, path)
+}
+
+// Generate Envelope() for an action whose path is known. Each key of each
+// list along the path becomes a parameter of the function.
+func generateActionEnvelope(w io.Writer, tn string, path []yang.Node) {
+	var params []string
+	var elems []string
+	used := map[string]int{}
+	for _, node := range path {
+		mod := getMyModule(node)
+		elem := fmt.Sprintf("{Name: \"%s\", Namespace: %s_ns", node.NName(), genFN(mod.name))
+		if list, ok := node.(*yang.List); ok && list.Key != nil {
+			var keys []string
+			for _, key := range strings.Fields(list.Key.Name) {
+				key = getName(key)
+				leaf, ok := getNodeFromList(list, key, true).(*yang.Leaf)
+				if !ok {
+					errorlog("generateActionEnvelope(): key %s not found in %s", key, nodeString(list))
+					return
+				}
+				pn := genPN(list.NName() + "_" + key)
+				if id, ok := used[pn]; ok {
+					used[pn] = id + 1
+					pn = fmt.Sprintf("%s_%d", pn, id+1)
+				} else {
+					used[pn] = 0
+				}
+				params = append(params, pn+" "+getTypeName(getMyYangModule(leaf), leaf.Type))
+				keys = append(keys, fmt.Sprintf("{Name: \"%s\", Value: %s}", key, pn))
+			}
+			elem = elem + ", Keys: []nc.KeyValue{" + strings.Join(keys, ", ") + "}"
+		}
+		elems = append(elems, elem+"}")
+	}
+	fmt.Fprintf(w, "func (x %s) Envelope(%s) nc.Action {\n", tn, strings.Join(params, ", "))
+	fmt.Fprintf(w, "\tpath := []nc.PathElem{\n")
+	for _, elem := range elems {
+		fmt.Fprintf(w, "\t\t%s,\n", elem)
+	}
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn x.EnvelopeAt(path)\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+// Compute the data nodes from the top of the data tree to the node passed.
+// The choice and case statements do not appear in the data tree and are
+// skipped. Augments are resolved to the node they augment. If the node is
+// inside a grouping, the path depends on where the grouping is used and
+// nil is returned.
+func getInstancePath(n yang.Node) []yang.Node {
+	var path []yang.Node
+	for n != nil {
+		switch n.Kind() {
+		case "module", "submodule":
+			return path
+		case "container", "list":
+			path = append([]yang.Node{n}, path...)
+			n = n.ParentNode()
+		case "choice", "case":
+			n = n.ParentNode()
+		case "augment":
+			aug := n.(*yang.Augment)
+			n = traverse(aug.Name, aug, false)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// Generates a parameter name for generated functions. Unlike the field
+// names the parameters are in lower case and the result is never a go
+// keyword as the names passed are always a combination of two names
+func genPN(s string) string {
+	s = strings.ReplaceAll(s, ":", "_")
+	s = strings.ReplaceAll(s, ".", "_")
+	return strings.ReplaceAll(s, "-", "_")
+}
@@ -49,6 +49,14 @@ func addNotification(n *yang.Notification, ns []*yang.Notification) []*yang.Noti
 	}
 	return append(ns, n)
 }
+func addAction(a *yang.Action, as []*yang.Action) []*yang.Action {
+	for _, x := range as {
+		if x == a {
+			return as
+		}
+	}
+	return append(as, a)
+}
 func addCase(c *yang.Case, cs []*yang.Case) []*yang.Case {
 	for _, x := range cs {
 		if x == c {
@@ -82,6 +90,9 @@ func addAugmentToContainer(a *yang.Augment, n yang.Node) {
 	for _, n1 := range a.Notification {
 		cont.Notification = addNotification(n1, cont.Notification)
 	}
+	for _, a1 := range a.Action {
+		cont.Action = addAction(a1, cont.Action)
+	}
 	for _, u1 := range a.Uses {
 		g := getGroupingByName(u1)
 		if g == nil {
@@ -122,6 +133,9 @@ func addAugmentToList(a *yang.Augment, n yang.Node) {
 	for _, n1 := range a.Notification {
 		list.Notification = addNotification(n1, list.Notification)
 	}
+	for _, a1 := range a.Action {
+		list.Action = addAction(a1, list.Action)
+	}
 	for _, u1 := range a.Uses {
 		g := getGroupingByName(u1)
 		if g == nil {
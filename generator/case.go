@@ -41,25 +41,31 @@ func genTypeForCase(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.Node,
 
 	// Generate the structure for the case statement
 	name = fullName(case1)
+	v := newValidator(ymod, case1, addNs)
 	fmt.Fprintf(w, "type %s struct {\n", genTN(ymod, name))
 	if keepXmlID {
 		mod := getMyModule(ymod)
 		fmt.Fprintf(w, "\tXMLName nc.XmlId `xml:\"%s %s\"`\n", mod.namespace, case1.NName())
 	}
 	for _, cont := range case1.Container {
-		generateField(w, ymod, cont, case1, addNs)
+		v.generateField(w, cont)
 	}
 	for _, leaf := range case1.Leaf {
-		generateField(w, ymod, leaf, case1, addNs)
+		v.generateField(w, leaf)
+	}
+	for _, leaflist := range case1.LeafList {
+		v.generateField(w, leaflist)
 	}
 	for _, list := range case1.List {
-		generateField(w, ymod, list, case1, addNs)
+		v.generateField(w, list)
 	}
 	fmt.Fprintf(w, "}\n")
 
 	// Generate runtime namespace function
 	mod := getMyModule(case1)
 	generateChoiceRuntimeNs(w, mod, ymod, name)
+	v.generate(w, genTN(ymod, name), "")
+	generateListAccessors(w, ymod, genTN(ymod, name), case1, case1.List)
 
 	// The code below triggers the code generation for the
 	// constituents of the grouping
@@ -69,6 +75,9 @@ func genTypeForCase(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.Node,
 	for _, leaf := range case1.Leaf {
 		generateType(w, ymod, leaf, case1, false)
 	}
+	for _, leaflist := range case1.LeafList {
+		generateType(w, ymod, leaflist, case1, false)
+	}
 	for _, list := range case1.List {
 		generateType(w, ymod, list, case1, false)
 	}
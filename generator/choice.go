@@ -39,28 +39,34 @@ func genTypeForChoice(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.Nod
 
 	addChoiceComment(w, choice)
 	name := fullName(choice)
+	v := newValidator(ymod, choice, addNs)
 	fmt.Fprintf(w, "type %s struct {\n", genTN(ymod, name))
 	if keepXmlID {
 		mod := getMyModule(ymod)
 		fmt.Fprintf(w, "\tXMLName nc.XmlId `xml:\"%s %s\"`\n", mod.namespace, choice.NName())
 	}
 	for _, cont := range choice.Container {
-		generateField(w, ymod, cont, choice, addNs)
+		v.generateField(w, cont)
 	}
 	for _, leaf := range choice.Leaf {
-		generateField(w, ymod, leaf, choice, addNs)
+		v.generateField(w, leaf)
+	}
+	for _, leaflist := range choice.LeafList {
+		v.generateField(w, leaflist)
 	}
 	for _, list := range choice.List {
-		generateField(w, ymod, list, choice, addNs)
+		v.generateField(w, list)
 	}
 	for _, case1 := range choice.Case {
-		generateField(w, ymod, case1, choice, addNs)
+		v.generateField(w, case1)
 	}
 	fmt.Fprintf(w, "}\n")
 
 	// Generate runtime namespace function
 	mod := getMyModule(choice)
 	generateChoiceRuntimeNs(w, mod, ymod, name)
+	v.generate(w, genTN(ymod, name), "")
+	generateListAccessors(w, ymod, genTN(ymod, name), choice, choice.List)
 
 	// The code below triggers the code generation for the
 	// constituents of the grouping
@@ -70,6 +76,9 @@ func genTypeForChoice(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.Nod
 	for _, leaf := range choice.Leaf {
 		generateType(w, ymod, leaf, choice, false)
 	}
+	for _, leaflist := range choice.LeafList {
+		generateType(w, ymod, leaflist, choice, false)
+	}
 	for _, list := range choice.List {
 		generateType(w, ymod, list, choice, false)
 	}
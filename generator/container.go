@@ -41,37 +41,46 @@ func genTypeForContainer(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.
 	name = fullName(cont)
 
 	// Now we start generating code for the container
+	v := newValidator(ymod, cont, addNs)
 	fmt.Fprintf(w, "type %s_cont struct {\n", genTN(ymod, name))
 	if keepXmlID {
 		mod := getMyModule(ymod)
 		fmt.Fprintf(w, "\tXMLName nc.XmlId `xml:\"%s %s\"`\n", mod.namespace, cont.Name)
 	}
+	// The operation of edit-config on the container
+	fmt.Fprintf(w, "\tXMLOp nc.Operation `xml:\",operation\"`\n")
 	for _, c1 := range cont.Container {
-		generateField(w, ymod, c1, cont, addNs)
+		v.generateField(w, c1)
 	}
 	for _, l1 := range cont.Leaf {
-		generateField(w, ymod, l1, cont, addNs)
+		v.generateField(w, l1)
+	}
+	for _, l1 := range cont.LeafList {
+		v.generateField(w, l1)
 	}
 	for _, g1 := range cont.Grouping {
-		generateField(w, ymod, g1, cont, addNs)
+		v.generateField(w, g1)
 	}
 	for _, l1 := range cont.List {
-		generateField(w, ymod, l1, cont, addNs)
+		v.generateField(w, l1)
 	}
 	for _, n1 := range cont.Notification {
-		generateField(w, ymod, n1, cont, addNs)
+		v.generateField(w, n1)
 	}
 	for _, c1 := range cont.Choice {
-		generateField(w, ymod, c1, cont, addNs)
+		v.generateField(w, c1)
 	}
 	for _, u1 := range cont.Uses {
-		generateField(w, ymod, u1, cont, addNs)
+		v.generateField(w, u1)
 	}
 	fmt.Fprintf(w, "}\n")
 
 	// Generate runtime namespace function
 	mod := getMyModule(cont)
 	generateContainerRuntimeNs(w, mod, ymod, name)
+	v.generate(w, genTN(ymod, name)+"_cont", fmt.Sprintf("%q", rootPath(cont)))
+	generateListAccessors(w, ymod, genTN(ymod, name)+"_cont", cont, cont.List)
+	generatePaths(w, genTN(ymod, name)+"_cont", cont)
 
 	// The code below triggers the code generation for the
 	// constituents of the grouping
@@ -81,6 +90,9 @@ func genTypeForContainer(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.
 	for _, leaf1 := range cont.Leaf {
 		generateType(w, ymod, leaf1, cont, false)
 	}
+	for _, leaflist1 := range cont.LeafList {
+		generateType(w, ymod, leaflist1, cont, false)
+	}
 	for _, list1 := range cont.List {
 		generateType(w, ymod, list1, cont, false)
 	}
@@ -90,6 +102,9 @@ func genTypeForContainer(w io.Writer, ymod *yang.Module, n yang.Node, prev yang.
 	for _, choice1 := range cont.Choice {
 		generateType(w, ymod, choice1, cont, false)
 	}
+	for _, action1 := range cont.Action {
+		genTypeForAction(w, ymod, action1, cont)
+	}
 }
 
 func generateContainerRuntimeNs(w io.Writer, mod *Module, ymod *yang.Module, name string) {
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// The default values of the leaves and the leaf-lists are collected with
+// the checks as the fields of a structure are generated. They are emitted
+// as the method setDefaults() of the structure which sets the nodes that
+// are not present to their default and reports whether it set any. The
+// defaults of a non-presence container apply when its parent exists and
+// the container is made present when one of them is set. Within a choice
+// the defaults apply to the case present or, when there is none, to the
+// default case. The default values are also registered with the runtime
+// for the encoding modes of with-defaults.
+
+// Collect the defaults of a node whose field is named fn
+func (v *validator) addDefaults(node yang.Node, fn string) {
+	if v.noDefaults {
+		return
+	}
+	choice, inChoice := v.prev.(*yang.Choice)
+	isDefault := inChoice && choice.Default != nil && choice.Default.Name == node.NName()
+	v.present = append(v.present, presentExpr(node, fn))
+	var lines []string
+	switch n := node.(type) {
+	case *yang.Container:
+		// A container of a choice is a case of its own
+		if n.Presence != nil || inChoice {
+			v.addPresent(fn)
+		}
+		if n.Presence != nil || (inChoice && !isDefault) {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("if x.%s.setDefaults() {\n", fn))
+		lines = append(lines, fmt.Sprintf("\tx.%s_Prsnt = true\n", fn))
+		lines = append(lines, "\tset = true\n")
+		lines = append(lines, "}\n")
+	case *yang.Leaf:
+		// The default of a leaf that is a case of its own applies only
+		// to the default case
+		if isTrue(n.Mandatory) || (inChoice && !isDefault) {
+			break
+		}
+		def := leafDefaults(getMyYangModule(node), n.Type, n.Default)
+		if len(def) == 0 {
+			break
+		}
+		v.addDefaultValues(fn, def)
+		lines = append(lines, fmt.Sprintf("if !x.%s_Prsnt && nc.SetDefault(&x.%s, %s) == nil {\n", fn, fn, quoteAll(def)))
+		lines = append(lines, fmt.Sprintf("\tx.%s_Prsnt = true\n", fn))
+		lines = append(lines, "\tset = true\n")
+		lines = append(lines, "}\n")
+	case *yang.LeafList:
+		if inChoice && !isDefault {
+			break
+		}
+		def := leafDefaults(getMyYangModule(node), n.Type, n.Default...)
+		if len(def) == 0 {
+			break
+		}
+		v.addDefaultValues(fn, def)
+		lines = append(lines, fmt.Sprintf("if len(x.%s) == 0 && nc.SetDefault(&x.%s, %s) == nil {\n", fn, fn, quoteAll(def)))
+		lines = append(lines, "\tset = true\n")
+		lines = append(lines, "}\n")
+	case *yang.List:
+		lines = append(lines, fmt.Sprintf("for i := range x.%s {\n", fn))
+		lines = append(lines, fmt.Sprintf("\tset = x.%s[i].setDefaults() || set\n", fn))
+		lines = append(lines, "}\n")
+	case *yang.Choice:
+		lines = append(lines, fmt.Sprintf("if x.%s.setDefaults() {\n", fn))
+		lines = append(lines, fmt.Sprintf("\tx.%s_Prsnt = true\n", fn))
+		lines = append(lines, "\tset = true\n")
+		lines = append(lines, "}\n")
+	case *yang.Case:
+		v.addPresent(fn)
+		if isDefault {
+			lines = append(lines, fmt.Sprintf("if x.%s.setDefaults() {\n", fn))
+			lines = append(lines, fmt.Sprintf("\tx.%s_Prsnt = true\n", fn))
+			lines = append(lines, "\tset = true\n")
+			lines = append(lines, "}\n")
+		}
+	}
+	if isDefault {
+		v.defaultCase = append(v.defaultCase, lines...)
+		return
+	}
+	v.defaults = append(v.defaults, lines...)
+}
+
+// The defaults within a node that applies only when it is present
+func (v *validator) addPresent(fn string) {
+	v.defaults = append(v.defaults, fmt.Sprintf("if x.%s_Prsnt {\n", fn))
+	v.defaults = append(v.defaults, fmt.Sprintf("\tset = x.%s.setDefaults() || set\n", fn))
+	v.defaults = append(v.defaults, "}\n")
+}
+
+// The nodes of a grouping are set by its own setDefaults()
+func (v *validator) addDefaultsUses(tn string) {
+	v.defaults = append(v.defaults, fmt.Sprintf("set = x.%s.setDefaults() || set\n", tn))
+}
+
+func (v *validator) addDefaultValues(fn string, def []string) {
+	v.defaultValues = append(v.defaultValues, fmt.Sprintf("%q: {%s},\n", fn, quoteAll(def)))
+}
+
+// The expression that tells whether the node of a field is present
+func presentExpr(node yang.Node, fn string) string {
+	switch node.Kind() {
+	case "list", "leaf-list":
+		return "len(x." + fn + ") > 0"
+	case "uses":
+		return ""
+	}
+	return "x." + fn + "_Prsnt"
+}
+
+// The defaults of a leaf or a leaf-list. Those of the leaf take precedence
+// over the one of its type which may come from a typedef.
+func leafDefaults(ymod *yang.Module, typ *yang.Type, def ...*yang.Value) []string {
+	var res []string
+	for _, d := range def {
+		if d != nil {
+			res = append(res, d.Name)
+		}
+	}
+	if len(res) > 0 || typ == nil {
+		return res
+	}
+	if s, ok := typedefDefault(ymod, typ); ok {
+		res = append(res, s)
+	}
+	return res
+}
+
+// Follow the typedefs from a type to the first one with a default
+func typedefDefault(ymod *yang.Module, typ *yang.Type) (string, bool) {
+	for depth := 0; depth < 16 && typ != nil; depth++ {
+		mod, td := lookupTypedef(ymod, typ.Name)
+		if td == nil {
+			return "", false
+		}
+		if td.Default != nil {
+			return td.Default.Name, true
+		}
+		ymod, typ = mod, td.Type
+	}
+	return "", false
+}
+
+func quoteAll(values []string) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Quote(v)
+	}
+	return strings.Join(s, ", ")
+}
+
+// Emit setDefaults() and, when requested, SetDefaults() with the table
+// of the default values registered with the runtime
+func (v *validator) generateDefaults(w io.Writer, tn string, export bool) {
+	fmt.Fprintf(w, "func (x *%s) setDefaults() bool {\n", tn)
+	fmt.Fprintf(w, "\tset := false\n")
+	for _, l := range v.defaults {
+		fmt.Fprintf(w, "\t%s", l)
+	}
+	if len(v.defaultCase) > 0 {
+		var present []string
+		for _, p := range v.present {
+			if p != "" {
+				present = append(present, p)
+			}
+		}
+		fmt.Fprintf(w, "\tif !(%s) {\n", strings.Join(present, " || "))
+		for _, l := range v.defaultCase {
+			fmt.Fprintf(w, "\t\t%s", l)
+		}
+		fmt.Fprintf(w, "\t}\n")
+	}
+	fmt.Fprintf(w, "\treturn set\n")
+	fmt.Fprintf(w, "}\n")
+	if len(v.defaultValues) > 0 {
+		fmt.Fprintf(w, "var %s_defaults = map[string][]string{\n", tn)
+		for _, d := range v.defaultValues {
+			fmt.Fprintf(w, "\t%s", d)
+		}
+		fmt.Fprintf(w, "}\n")
+		v.register(w, tn, fmt.Sprintf("nc.RegisterDefaults(%s{}, %s_defaults)\n", tn, tn))
+	}
+	if !export {
+		return
+	}
+	// A field named SetDefaults would conflict with the method
+	if v.fields["SetDefaults"] {
+		errorlog("generateDefaults(): %s has a field SetDefaults and SetDefaults() is not generated", tn)
+		return
+	}
+	fmt.Fprintf(w, "// SetDefaults sets the nodes within that are not present to their default\n")
+	fmt.Fprintf(w, "func (x *%s) SetDefaults() {\n", tn)
+	fmt.Fprintf(w, "\tx.setDefaults()\n")
+	fmt.Fprintf(w, "}\n")
+}
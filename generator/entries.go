@@ -8,13 +8,19 @@ import (
 )
 
 // This function generates a single entry of field of a structure that may be generated
-// from a compound structure such as a grouping, container, list, etc.
-func generateField(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Node, addNs bool) {
+// from a compound structure such as a grouping, container, list, etc. It returns
+// whether the field was generated.
+func generateField(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Node, addNs bool) bool {
 	debuglog("generateField(): Generating for field %s.%s", node.NName(), node.Kind())
 	var nsstr string
+	// The namespace is needed explicitly when the node is from a module
+	// other than the one of its parent, which is the case for the nodes
+	// added through augment. Otherwise, the namespace is inherited.
 	if addNs {
 		mod := getMyModule(ymod)
 		nsstr = mod.namespace + " "
+	} else if mod := getMyModule(node); mod != nil && mod != getMyModule(prev) {
+		nsstr = mod.namespace + " "
 	}
 	ymod = getMyYangModule(prev)
 	nodeName := node.NName()
@@ -26,12 +32,11 @@ func generateField(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Nod
 	case "notification":
 		fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\",presfield\"`\n", genFN(nodeName))
 		fmt.Fprintf(w, "\t%s %s_cont `xml:\"%s%s\"`\n", genFN(nodeName), genTN(ymod, fullname), nsstr, nodeName)
-	case "choice":
+	case "choice", "case":
+		// The choice and the case do not appear in the encoding and their
+		// nodes are encoded as the nodes of the parent
 		fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\",presfield\"`\n", genFN(nodeName))
-		fmt.Fprintf(w, "\t%s %s `xml:\"%s%s\"`\n", genFN(nodeName), genTN(ymod, fullname), nsstr, nodeName)
-	case "case":
-		fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\",presfield\"`\n", genFN(nodeName))
-		fmt.Fprintf(w, "\t%s %s `xml:\"%s%s\"`\n", genFN(nodeName), genTN(ymod, fullname), nsstr, nodeName)
+		fmt.Fprintf(w, "\t%s %s `xml:\"%s,inline\"`\n", genFN(nodeName), genTN(ymod, fullname), nodeName)
 	case "leaf":
 		l, ok := node.(*yang.Leaf)
 		if !ok {
@@ -41,12 +46,16 @@ func generateField(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Nod
 		pre := getPrefix(tn)
 		if getImportedModuleByPrefix(ymod, pre) == nil {
 			errorlog("generateField(): Exiting from leaf field: pre=%s, leaf=%s.%s", pre, node.NName(), node.Kind())
+			return false
+		}
+		// A leaf of type empty has no value and the presence field
+		// carries the name of the leaf
+		if l.Type != nil && l.Type.Name == "empty" {
+			fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\"%s%s,presfield\"`\n", genFN(nodeName), nsstr, nodeName)
 			break
 		}
 		fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\",presfield\"`\n", genFN(nodeName))
-		if l.Type != nil && l.Type.Name != "empty" {
-			fmt.Fprintf(w, "\t%s %s `xml:\"%s%s\"`\n", genFN(nodeName), tn, nsstr, nodeName)
-		}
+		fmt.Fprintf(w, "\t%s %s `xml:\"%s%s\"`\n", genFN(nodeName), tn, nsstr, nodeName)
 	case "leaf-list":
 		l, ok := node.(*yang.LeafList)
 		if !ok {
@@ -55,9 +64,8 @@ func generateField(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Nod
 		tn := getTypeName(ymod, l.Type)
 		pre := getPrefix(getType(ymod, l.Type))
 		if getImportedModuleByPrefix(ymod, pre) == nil {
-			break
+			return false
 		}
-		fmt.Fprintf(w, "// Generated from here pre = %s, tn = %s \n", pre, l.Type.Name)
 		fmt.Fprintf(w, "\t%s []%s `xml:\"%s%s\"`\n", genFN(nodeName), tn, nsstr, nodeName)
 	case "list":
 		fmt.Fprintf(w, "\t%s []%s `xml:\"%s%s\"`\n", genFN(nodeName), genTN(ymod, fullname), nsstr, nodeName)
@@ -68,12 +76,14 @@ func generateField(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Nod
 		}
 		pre := getPrefix(u.Name)
 		if getImportedModuleByPrefix(ymod, pre) == nil {
-			break
+			return false
 		}
 		fmt.Fprintf(w, "\t%s\n", genTN(ymod, nodeName))
 	default:
 		errorlog("generateField(): unsupported field %s.%s", nodeName,  node.Kind())
+		return false
 	}
+	return true
 }
 
 // This function goes through the list of entries that are contained within elements
@@ -85,6 +95,8 @@ func generateType(w io.Writer, ymod *yang.Module, node yang.Node, prev yang.Node
 		genTypeForContainer(w, ymod, node, prev, keepXmlID)
 	case "list":
 		genTypeForList(w, ymod, node, prev)
+	case "notification":
+		genTypeForNotification(w, ymod, node, prev, keepXmlID)
 	case "leaf":
 		genTypeForLeaf(w, ymod, node, prev)
 	case "leaf-list":
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	genOnce sync.Once
+	genDir  string
+	genErr  error
+)
+
+// Generate the code of the modules of testdata once for all the tests as
+// the modules are kept in the global tables. The package generated is
+// built against the runtime of the repository along with the tests of
+// testdata/goyang_test.go.
+func generated(t *testing.T) string {
+	t.Helper()
+	genOnce.Do(func() {
+		genDir, genErr = os.MkdirTemp("", "ncgen")
+		if genErr != nil {
+			return
+		}
+		package_name = "goyang"
+		generate("testdata/yang", "", genDir)
+		rt, err := filepath.Abs("../nc")
+		if err != nil {
+			genErr = err
+			return
+		}
+		test, err := os.ReadFile("testdata/goyang_test.go")
+		if err != nil {
+			genErr = err
+			return
+		}
+		if genErr = os.WriteFile(filepath.Join(genDir, "goyang", "goyang_test.go"), test, 0o644); genErr != nil {
+			return
+		}
+		mod := "module gen\n\ngo 1.23.1\n\nrequire nc v0.0.0\n\nreplace nc => " + rt + "\n"
+		if genErr = os.WriteFile(filepath.Join(genDir, "go.mod"), []byte(mod), 0o644); genErr != nil {
+			return
+		}
+		for _, args := range [][]string{{"mod", "tidy"}, {"vet", "./..."}} {
+			cmd := exec.Command("go", args...)
+			cmd.Dir = genDir
+			cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				genErr = &buildError{args: args, out: string(out), err: err}
+				return
+			}
+		}
+	})
+	if genErr != nil {
+		t.Fatal(genErr)
+	}
+	return genDir
+}
+
+type buildError struct {
+	args []string
+	out  string
+	err  error
+}
+
+func (e *buildError) Error() string {
+	return "go " + strings.Join(e.args, " ") + ": " + e.err.Error() + "\n" + e.out
+}
+
+// The generated code of a module
+func generatedFile(t *testing.T, module string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(generated(t), "goyang", module+".go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMain(m *testing.M) {
+	// The generation that is expected to fail runs in a process of its own
+	// as the failure exits the process
+	if indir := os.Getenv("NCGEN_INDIR"); indir != "" {
+		package_name = "goyang"
+		generate(indir, "", os.Getenv("NCGEN_OUTDIR"))
+		os.Exit(0)
+	}
+	code := m.Run()
+	if genDir != "" {
+		os.RemoveAll(genDir)
+	}
+	os.Exit(code)
+}
+
+// Generate the code of the modules of indir in a process of its own and
+// return its output. The generation must fail.
+func generateFails(t *testing.T, indir string) string {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), "NCGEN_INDIR="+indir, "NCGEN_OUTDIR="+t.TempDir())
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("the generation of %s didn't fail:\n%s", indir, out)
+	}
+	return string(out)
+}
+
+// Run the tests of the generated code
+func TestGeneratedCode(t *testing.T) {
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = generated(t)
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+// The input of an action added by an augment is in the namespace of the
+// module of the augment, as its descriptor is
+func TestAugmentedAction(t *testing.T) {
+	src := generatedFile(t, "tbase")
+	for _, want := range []string{
+		"XMLName nc.XmlId `xml:\"urn:taug reboot\"`",
+		"Namespace: Taug_ns,",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("%q not generated", want)
+		}
+	}
+	if strings.Contains(src, "`xml:\"urn:tbase reboot\"`") {
+		t.Errorf("the input of reboot is in the namespace of tbase")
+	}
+}
+
+// A pattern that can't be translated fails the generation rather than
+// dropping the check of the values
+func TestUntranslatablePattern(t *testing.T) {
+	out := generateFails(t, "testdata/invalid/pattern")
+	if !strings.Contains(out, "pattern '[a-z-[aeiou]]+' of Tp_consonants can't be translated") {
+		t.Errorf("unexpected output of the generation:\n%s", out)
+	}
+}
+
+// The bits are held in a uint64 and a position beyond 63 fails the
+// generation
+func TestBitPosition(t *testing.T) {
+	out := generateFails(t, "testdata/invalid/bits")
+	if !strings.Contains(out, "position 64 of bit wide is beyond 63") {
+		t.Errorf("unexpected output of the generation:\n%s", out)
+	}
+}
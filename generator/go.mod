@@ -3,7 +3,8 @@ module ncgen
 go 1.23.1
 
 require (
-	github.com/google/go-cmp v0.7.0 // indirect
-	github.com/openconfig/goyang v1.6.3 // indirect
-	github.com/pborman/getopt v1.1.0 // indirect
+	github.com/openconfig/goyang v1.6.3
+	github.com/pborman/getopt v1.1.0
 )
+
+require github.com/google/go-cmp v0.7.0 // indirect
@@ -38,32 +38,41 @@ func processGrouping(w io.Writer, submod *SubModule, ymod *yang.Module, group *y
 
 	// The code below generates code for the grouping
 	debuglog("processGrouping(): Generating for group %s", group.NName())
+	v := newValidator(ymod, group, addNs)
 	fmt.Fprintf(w, "type %s struct {\n", genTN(ymod, group.NName()))
 	for _, l1 := range group.Leaf {
-		generateField(w, ymod, l1, group, addNs)
+		v.generateField(w, l1)
+	}
+	for _, l1 := range group.LeafList {
+		v.generateField(w, l1)
 	}
 	for _, c1 := range group.Container {
-		generateField(w, ymod, c1, group, addNs)
+		v.generateField(w, c1)
 	}
 	for _, g1 := range group.Grouping {
-		generateField(w, ymod, g1, group, addNs)
+		v.generateField(w, g1)
 	}
 	for _, l1 := range group.List {
-		generateField(w, ymod, l1, group, addNs)
+		v.generateField(w, l1)
 	}
 	for _, u1 := range group.Uses {
-		generateField(w, ymod, u1, group, addNs)
+		v.generateField(w, u1)
 	}
 	fmt.Fprintf(w, "}\n")
 
 	// Generate runtime namespace function
 	generateGroupingRuntimeNs(w, submod, ymod, group)
+	v.generate(w, genTN(ymod, group.NName()), "")
+	generateListAccessors(w, ymod, genTN(ymod, group.NName()), group, group.List)
 
 	// The code below triggers the code generation for the
 	// constituents of the grouping
 	for _, leaf := range group.Leaf {
 		generateType(w, ymod, leaf, group, addNs)
 	}
+	for _, leaflist := range group.LeafList {
+		generateType(w, ymod, leaflist, group, addNs)
+	}
 	for _, cont := range group.Container {
 		generateType(w, ymod, cont, group, addNs)
 	}
@@ -76,6 +85,9 @@ func processGrouping(w io.Writer, submod *SubModule, ymod *yang.Module, group *y
 	for _, choice := range group.Choice {
 		generateType(w, ymod, choice, group, false)
 	}
+	for _, action := range group.Action {
+		genTypeForAction(w, ymod, action, group)
+	}
 	fmt.Fprintf(w, "\n")
 
 	//storeInGroupingMap(submod.prefix, n)
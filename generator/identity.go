@@ -180,6 +180,28 @@ func processIdentity(w io.Writer, submod *SubModule, ymod *yang.Module, n yang.N
 	// Generate other code related to filling up the maps used in
 	// marshal/unmarshal functions
 	generateMapEntries(ymod, id)
+	registerBases(ymod, id)
+}
+
+// The bases of each identity are registered with the runtime which needs
+// them for derived-from() and derived-from-or-self() of XPath
+func registerBases(ymod *yang.Module, id *yang.Identity) {
+	submod := getSubModule(ymod.Name)
+	mod := getMyModule(ymod)
+	if submod == nil || mod == nil {
+		errorlog("registerBases(): Module %s not found for %s.%s", ymod.Name, id.NName(), id.Kind())
+		return
+	}
+	for _, b := range id.Base {
+		basemod := getImportedModuleByPrefix(ymod, getPrefix(b.Name))
+		if basemod == nil {
+			errorlog("registerBases(): Module not found for base %s of %s", b.Name, id.NName())
+			continue
+		}
+		s := fmt.Sprintf("nc.RegisterIdentity(%q, %q, %q, %q)\n", mod.namespace, id.Name,
+			basemod.namespace, getName(b.Name))
+		submod.initfunc = append(submod.initfunc, s)
+	}
 }
 
 // The map is used to translate the enumeration values generated to strings and
@@ -188,12 +210,13 @@ func processIdentity(w io.Writer, submod *SubModule, ymod *yang.Module, n yang.N
 // Recursively identifies all the base identities and adds code
 // for filling up the respective maps
 func generateMapEntries(ymod *yang.Module, id *yang.Identity) {
-	// Locate the first base and its prefix. This information is used
-	// in filling up all the maps as we traverse recursively
+	// Locate the first base. The maps of the bases are filled up as we
+	// traverse recursively. The value is qualified by the module in which
+	// the identity is defined which may differ from the module of its base
 	baseymod, id1 := locateBase(ymod, id)
-	basemod := getMyModule(baseymod)
-	namespace := basemod.namespace
-	prefix := basemod.prefix
+	mod := getMyModule(ymod)
+	namespace := mod.namespace
+	prefix := mod.prefix
 	for id1 != nil {
 		addMapEntry(ymod, id, baseymod, id1, namespace, prefix)
 		baseymod, id1 = locateBase(baseymod, id1)
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/openconfig/goyang/pkg/yang"
 )
@@ -27,29 +28,44 @@ func genTypeForList(w io.Writer, m *yang.Module, n yang.Node, prev yang.Node) {
 	}
 
 	// Now start generating the code for the list
+	v := newValidator(m, list, addNs)
+	v.addKeys(list)
 	fmt.Fprintf(w, "type %s struct {\n", genTN(m, ln))
+	// The operation of edit-config on the entry and, for the lists
+	// ordered by the user, where edit-config inserts it
+	fmt.Fprintf(w, "\tXMLOp nc.Operation `xml:\",operation\"`\n")
+	if list.OrderedBy != nil && list.OrderedBy.Name == "user" {
+		fmt.Fprintf(w, "\tXMLInsert nc.Insert `xml:\",insert\"`\n")
+	}
 	for _, l1 := range list.Leaf {
-		generateField(w, m, l1, list, addNs)
+		v.generateField(w, l1)
+	}
+	for _, l1 := range list.LeafList {
+		v.generateField(w, l1)
 	}
 	for _, c1 := range list.Container {
-		generateField(w, m, c1, list, addNs)
+		v.generateField(w, c1)
 	}
 	for _, g1 := range list.Grouping {
-		generateField(w, m, g1, list, addNs)
+		v.generateField(w, g1)
 	}
 	for _, l1 := range list.List {
-		generateField(w, m, l1, list, addNs)
+		v.generateField(w, l1)
 	}
 	for _, n1 := range list.Notification {
-		generateField(w, m, n1, list, addNs)
+		v.generateField(w, n1)
 	}
 	for _, c1 := range list.Choice {
-		generateField(w, m, c1, list, addNs)
+		v.generateField(w, c1)
 	}
 	for _, u1 := range list.Uses {
-		generateField(w, m, u1, list, addNs)
+		v.generateField(w, u1)
 	}
 	fmt.Fprintf(w, "}\n")
+	generateListKey(w, m, list, genTN(m, ln))
+	v.generate(w, genTN(m, ln), fmt.Sprintf("nc.EntryPath(%q, &x, -1)", rootPath(list)))
+	generateListAccessors(w, m, genTN(m, ln), list, list.List)
+	generatePaths(w, genTN(m, ln), list)
 
 	// The code below generates the type definitions needed
 	// for the constituents inside a list
@@ -59,6 +75,9 @@ func genTypeForList(w io.Writer, m *yang.Module, n yang.Node, prev yang.Node) {
 	for _, leaf := range list.Leaf {
 		generateType(w, m, leaf, list, false)
 	}
+	for _, leaflist := range list.LeafList {
+		generateType(w, m, leaflist, list, false)
+	}
 	for _, list1 := range list.List {
 		generateType(w, m, list1, list, false)
 	}
@@ -68,6 +87,9 @@ func genTypeForList(w io.Writer, m *yang.Module, n yang.Node, prev yang.Node) {
 	for _, choice := range list.Choice {
 		generateType(w, m, choice, list, false)
 	}
+	for _, action := range list.Action {
+		genTypeForAction(w, m, action, list)
+	}
 }
 
 // Look for a node that belongs to the list with a specific name. Iterate through
@@ -143,3 +165,174 @@ func getMatchingUsesNodeFromList(l *yang.List, name string) yang.Node {
 	}
 	return nil
 }
+
+// Get the key leaves of a list in the order they are declared in the key
+// statement. Lists without a key (possible for state data) return nil.
+func getListKeys(l *yang.List) []*yang.Leaf {
+	var keys []*yang.Leaf
+	if l.Key == nil {
+		return nil
+	}
+	for _, k := range strings.Fields(l.Key.Name) {
+		leaf, ok := getNodeFromList(l, getName(k), true).(*yang.Leaf)
+		if !ok {
+			errorlog("getListKeys(): key %s is not a leaf in %s.%s", k, l.NName(), l.Kind())
+			return nil
+		}
+		keys = append(keys, leaf)
+	}
+	return keys
+}
+
+// Generate the key related definitions for a list. The key names are
+// available for the runtime through ListKeyNames(). The key structure has
+// a field for each key leaf and Equal() compares two keys. The structure
+// is comparable, and may be a map key, unless a key is binary or a union
+// with a binary member. The methods and the types aren't named after key
+// alone as a list may well have a child named key, as in RFC 8177.
+func generateListKey(w io.Writer, m *yang.Module, list *yang.List, tn string) {
+	keys := getListKeys(list)
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "var %s_listkeys = []string{", tn)
+	for i, k := range keys {
+		if i > 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		fmt.Fprintf(w, "\"%s\"", k.NName())
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "type %s_listkey struct {\n", tn)
+	for _, k := range keys {
+		fmt.Fprintf(w, "\t%s %s\n", genFN(k.NName()), getTypeName(getMyYangModule(k), k.Type))
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "func (x %s) ListKeyNames() []string {\n", tn)
+	fmt.Fprintf(w, "\treturn %s_listkeys\n", tn)
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "func (x %s) ListKey() %s_listkey {\n", tn, tn)
+	fmt.Fprintf(w, "\treturn %s_listkey{", tn)
+	for i, k := range keys {
+		if i > 0 {
+			fmt.Fprintf(w, ", ")
+		}
+		fmt.Fprintf(w, "%s: x.%s", genFN(k.NName()), genFN(k.NName()))
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "}\n")
+	var conds []string
+	for _, k := range keys {
+		conds = append(conds, keyEqualExpr(k))
+	}
+	fmt.Fprintf(w, "func (k %s_listkey) Equal(o %s_listkey) bool {\n", tn, tn)
+	fmt.Fprintf(w, "\treturn %s\n", strings.Join(conds, " && "))
+	fmt.Fprintf(w, "}\n")
+}
+
+// The comparison of a key leaf of two keys. The binary types are slices
+// of bytes compared as strings and the unions with a binary member are
+// compared by their text.
+func keyEqualExpr(leaf *yang.Leaf) string {
+	fn := genFN(leaf.NName())
+	switch keyComparison(getMyYangModule(leaf), leaf.Type) {
+	case "binary":
+		return fmt.Sprintf("string(k.%s) == string(o.%s)", fn, fn)
+	case "text":
+		return fmt.Sprintf("nc.TextEqual(k.%s, o.%s)", fn, fn)
+	}
+	return fmt.Sprintf("k.%s == o.%s", fn, fn)
+}
+
+// How the values of a type are compared when the generated type isn't
+// comparable: "binary" for the slices of bytes and "text" for the unions
+// that contain one. The typedefs and the leafrefs are followed to the
+// type they are based on.
+func keyComparison(ymod *yang.Module, typ *yang.Type) string {
+	for depth := 0; depth < 16 && typ != nil; depth++ {
+		switch typ.Name {
+		case "binary":
+			return "binary"
+		case "union":
+			for _, t := range typ.Type {
+				if keyComparison(ymod, t) != "" {
+					return "text"
+				}
+			}
+			return ""
+		case "leafref":
+			if typ.Path == nil {
+				return ""
+			}
+			ref := getLeafref(typ.Path.Name, ymod, typ.ParentNode())
+			if ref == nil {
+				return ""
+			}
+			ymod, typ = getMyYangModule(ref), ref.Type
+			continue
+		}
+		mod, td := lookupTypedef(ymod, typ.Name)
+		if td == nil {
+			return ""
+		}
+		ymod, typ = mod, td.Type
+	}
+	return ""
+}
+
+// Generate the functions for keyed access to the lists contained in a
+// structure. The structure is identified by the type name passed and the
+// functions are generated only for lists that have keys.
+func generateListAccessors(w io.Writer, ymod *yang.Module, tn string, prev yang.Node, lists []*yang.List) {
+	for _, list := range lists {
+		if len(getListKeys(list)) == 0 {
+			continue
+		}
+		generateListAccessor(w, tn, genFN(list.NName()), genTN(getMyYangModule(prev), fullName(list)), list)
+	}
+}
+
+// Generate Get, Set and Delete for the entries of type etn of the list
+// in the field fn of the structure tn. The functions are named after the
+// field.
+func generateListAccessor(w io.Writer, tn string, fn string, etn string, list *yang.List) {
+	// Get returns a pointer to the entry so that it can be modified
+	fmt.Fprintf(w, "func (x *%s) Get%s(k %s_listkey) *%s {\n", tn, fn, etn, etn)
+	fmt.Fprintf(w, "\tfor i := range x.%s {\n", fn)
+	fmt.Fprintf(w, "\t\tif x.%s[i].ListKey().Equal(k) {\n", fn)
+	fmt.Fprintf(w, "\t\t\treturn &x.%s[i]\n", fn)
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn nil\n")
+	fmt.Fprintf(w, "}\n")
+
+	// Set replaces the entry with the same key or appends a new one. The
+	// keys are made present as they identify the entry. A key of type
+	// empty is left as is since its presence is its value.
+	fmt.Fprintf(w, "func (x *%s) Set%s(e %s) {\n", tn, fn, etn)
+	for _, k := range getListKeys(list) {
+		if k.Type == nil || k.Type.Name != "empty" {
+			fmt.Fprintf(w, "\te.%s_Prsnt = true\n", genFN(k.NName()))
+		}
+	}
+	fmt.Fprintf(w, "\tk := e.ListKey()\n")
+	fmt.Fprintf(w, "\tfor i := range x.%s {\n", fn)
+	fmt.Fprintf(w, "\t\tif x.%s[i].ListKey().Equal(k) {\n", fn)
+	fmt.Fprintf(w, "\t\t\tx.%s[i] = e\n", fn)
+	fmt.Fprintf(w, "\t\t\treturn\n")
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\tx.%s = append(x.%s, e)\n", fn, fn)
+	fmt.Fprintf(w, "}\n")
+
+	// Delete removes the entry and reports if it was present
+	fmt.Fprintf(w, "func (x *%s) Delete%s(k %s_listkey) bool {\n", tn, fn, etn)
+	fmt.Fprintf(w, "\tfor i := range x.%s {\n", fn)
+	fmt.Fprintf(w, "\t\tif x.%s[i].ListKey().Equal(k) {\n", fn)
+	fmt.Fprintf(w, "\t\t\tx.%s = append(x.%s[:i], x.%s[i+1:]...)\n", fn, fn, fn)
+	fmt.Fprintf(w, "\t\t\treturn true\n")
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn false\n")
+	fmt.Fprintf(w, "}\n")
+}
@@ -268,12 +268,18 @@ func readDir(path string, suffix string) []string {
 	return filelist
 }
 
+// The modules with a revision are in the tables of goyang under both their
+// name and their name@revision, so only those under their name are added.
 func addModules(modules *yang.Modules) {
-	for _, m := range modules.Modules {
-		addModule(m)
+	for name, m := range modules.Modules {
+		if name == m.Name {
+			addModule(m)
+		}
 	}
-	for _, m := range modules.SubModules {
-		addSubModule(m)
+	for name, m := range modules.SubModules {
+		if name == m.Name {
+			addSubModule(m)
+		}
 	}
 }
 
@@ -284,11 +290,12 @@ func printModules() {
 }
 
 func main() {
-	var indir, outdir, apiIndir string
+	var indir, outdir, apiIndir, sidIndir string
 	getopt.StringVarLong(&indir, "indir", 'i', "directory to look for yang files")
 	getopt.StringVarLong(&outdir, "outdir", 'o', "directory for output files")
 	getopt.StringVarLong(&package_name, "package_name", 'p', "golang package name")
 	getopt.StringVarLong(&apiIndir, "api-indir", 'I', "directory for input api files")
+	getopt.StringVarLong(&sidIndir, "sid-indir", 's', "directory to look for .sid files")
 	getopt.Parse()
 
 	if indir == "" {
@@ -301,6 +308,13 @@ func main() {
 		package_name = "goyang"
 	}
 
+	generate(indir, sidIndir, outdir)
+}
+
+// Generate the code of the yang files of the directory indir into the
+// package directory under outdir. The modules are kept in the global
+// tables, so the generation runs once in a process.
+func generate(indir, sidIndir, outdir string) {
 	// We recursively go through the directory for all the yang files which will
 	// be included in the generated. We look for files named ".yang". We parse
 	// those files and the output of parsing is stored in structure Modules defined
@@ -314,6 +328,11 @@ func main() {
 			errorlog("Cannot open file: %s", err.Error())
 		}
 	}
+	// The SIDs of the modules are optional and used only by the CBOR
+	// encoding
+	if sidIndir != "" {
+		readSidFiles(sidIndir)
+	}
 	// Add all the modules parsed
 	addModules(ms)
 	graph, inDegree, err := BuildGraph(modulesByName)
@@ -345,7 +364,7 @@ func main() {
 
 	// This generates the structure that describes the device based on yang
 	// files included in the generation.
-	//generateMain(outdir)
+	generateMain(outdir)
 
 
 	// Now generate code for each module. We generate a .go file for each
@@ -354,10 +373,4 @@ func main() {
 	for _, m := range modulesByName {
 		processModule(m, outdir)
 	}
-	//m := modulesByName["openconfig-policy-types"]
-	//processModule(m, outdir)
-	
-        //if apiIndir != "" {
-	//	processStructsAndApis(apiIndir, outdir)
-	//}
 }
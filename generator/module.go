@@ -218,6 +218,7 @@ func fileHeader(mod *Module, submod *SubModule, w io.Writer, keepXmlID bool) {
 	if submod.mtype == TypeModule {
 		fmt.Fprintf(w, "var %s_ns = \"%s\"\n", modname, mod.namespace)
 		fmt.Fprintf(w, "var %s_prefix = \"%s\"\n", modname, mod.prefix)
+		generateSids(w, mod, submod)
 	}
 	// Revision is needed independently for both submodules and main module
 	// and must be generated outside the earlier check
@@ -230,6 +231,7 @@ func fileHeader(mod *Module, submod *SubModule, w io.Writer, keepXmlID bool) {
 	} else {
 		//fmt.Fprintf(w, "var %s_capability = \"%s?\"\n", submodname, mod.namespace)
 	}
+	generateXPathPrefixes(w, submod.module)
 	// Generate the dummy usage for all the common import packages so that
 	// we don't have to carefully identify which of them to be included
 	fmt.Fprintf(w, "\n//-----------------------------------------------------\n")
@@ -348,11 +350,44 @@ func processSubModule(mod *Module, submod *SubModule, outdir string) {
 	for _, cont := range submod.module.Container {
 		genTypeForContainer(w, submod.module, cont, submod.module, keepXmlID)
 	}
+	// The other top level data nodes are part of the structure Device
+	// and need their types generated too
+	for _, list := range submod.module.List {
+		generateType(w, submod.module, list, submod.module, keepXmlID)
+	}
+	for _, leaf := range submod.module.Leaf {
+		generateType(w, submod.module, leaf, submod.module, keepXmlID)
+	}
+	for _, leaflist := range submod.module.LeafList {
+		generateType(w, submod.module, leaflist, submod.module, keepXmlID)
+	}
+	for _, choice := range submod.module.Choice {
+		generateType(w, submod.module, choice, submod.module, keepXmlID)
+	}
+	for _, rpc := range submod.module.RPC {
+		genTypeForRpc(w, submod.module, rpc)
+	}
+	for _, notif := range submod.module.Notification {
+		genTypeForNotification(w, submod.module, notif, submod.module, true)
+		registerNotification(submod, submod.module, notif)
+	}
 
 	// generate the init() function
 	fmt.Fprintf(w, "func init() {\n")
 	fmt.Fprintf(w, "\ts := \"dummy\"\n")
 	fmt.Fprintf(w, "\tnc.Marshal(s)\n")
+	// The runtime maps between the namespaces and the names of the
+	// modules for the encodings that qualify names by module
+	if submod.mtype == TypeModule {
+		modname := genFN(mod.name)
+		fmt.Fprintf(w, "\tnc.RegisterModule(\"%s\", %s_ns, %s_prefix)\n", mod.name, modname, modname)
+		// The servers advertise the capabilities of the modules compiled in
+		if len(submod.module.Revision) > 0 {
+			fmt.Fprintf(w, "\tnc.RegisterCapability(%s_capability)\n", modname)
+		} else {
+			fmt.Fprintf(w, "\tnc.RegisterCapability(%s_ns + \"?module=%s\")\n", modname, mod.name)
+		}
+	}
 	for _, s := range submod.initfunc {
 		fmt.Fprintf(w, "\t%s", s)
 	}
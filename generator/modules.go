@@ -1,14 +1,18 @@
 package main
 
 import (
-	"os"
-	"io"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/openconfig/goyang/pkg/yang"
 )
 
 // This file implements the global structure that describes the device
-// by putting together all the uses declared within each of the module
-// which essentially instantiate the device related paramters
+// by putting together all the data nodes declared at the top of each of
+// the modules. The structure is the <data> element returned by <get> and
+// <get-config> and a complete reply decodes into a single value.
 func generateMain(outdir string) {
 	w := openMainFile(outdir)
 	if w == nil {
@@ -24,6 +28,7 @@ func generateMain(outdir string) {
 // different modules as one structure that represents the device.
 func openMainFile(outdir string) *os.File {
 	outpath := outdir + "/" + package_name + "/main.go"
+	ensureDirectory(outpath)
 	w, err := os.OpenFile(outpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		errorlog("unable to open file %s", err.Error())
@@ -36,46 +41,141 @@ func openMainFile(outdir string) *os.File {
 // is a small one for now :)
 func mainFileHeader(w io.Writer) {
 	fmt.Fprintf(w, "package yang\n")
+	fmt.Fprintf(w, "import (\n")
+	fmt.Fprintf(w, "\tnc \"nc/nc\"\n")
+	fmt.Fprintf(w, ")\n")
 }
 
 // The structure includes all data that must be instantiated for
 // representing the device. The data is aggregated from all the modules
-// that have been compiled together.
+// that have been compiled together. The modules are sorted by name so
+// that the generated structure doesn't change between runs.
 func writeStructure(w io.Writer) {
+	fmt.Fprintln(w, "//------------------------------------------------------------")
+	fmt.Fprint(w, "//  Name:\n")
+	fmt.Fprint(w, "//    Device\n")
+	fmt.Fprint(w, "//  Description:\n")
+	fmt.Fprint(w, "//    The data of the device made of the top level data nodes\n")
+	fmt.Fprint(w, "//    of all the modules. It is encoded as <data> of netconf\n")
+	fmt.Fprintln(w, "//-------------------------------------------------------------")
 	fmt.Fprintf(w, "type Device struct {\n")
-	for _, m := range(modulesByName) {
-		for _, sm := range m.submodules {
-			addSubmodule(w, sm)
+	fmt.Fprintf(w, "\tXMLName nc.XmlId `xml:\"urn:ietf:params:xml:ns:netconf:base:1.0 data\"`\n")
+	v := newValidator(nil, nil, true)
+	var names []string
+	for name := range modulesByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var submods []*SubModule
+	var accessors []deviceList
+	for _, name := range names {
+		m := modulesByName[name]
+		var smnames []string
+		for smname := range m.submodules {
+			smnames = append(smnames, smname)
+		}
+		sort.Strings(smnames)
+		for _, smname := range smnames {
+			addSubmodule(w, v, &accessors, m.submodules[smname])
+			submods = append(submods, m.submodules[smname])
 		}
 	}
 	fmt.Fprintf(w, "}\n")
+	v.generate(w, "Device", "\"\"")
+	for _, l := range accessors {
+		generateListAccessor(w, "Device", l.fn, l.etn, l.list)
+	}
+	generateDevicePath(w)
+	for _, sm := range submods {
+		addDevicePaths(w, sm)
+	}
+}
+
+// A keyed list at the top of a module whose entries of type etn are in
+// the field fn of Device
+type deviceList struct {
+	fn   string
+	etn  string
+	list *yang.List
 }
 
 // We generate all data that is instantiated at the level of the
 // submodule. The groupings are instantiated using "uses" statement
 // while the others are instantiated by their presence at the level
 // of the module/submodule.
-func addSubmodule(w io.Writer, sm *SubModule) {
+func addSubmodule(w io.Writer, v *validator, accessors *[]deviceList, sm *SubModule) {
 	ymod := sm.module
-	for _, u := range ymod.Uses {
-		name := genTN(ymod, u.NName())
-		fmt.Fprintf(w, "\t%s\n", name)
+	mod := getMyModule(ymod)
+	if mod == nil {
+		errorlog("addSubmodule(): module not found for %s", ymod.NName())
+		return
+	}
+	addDataNodes(w, v, accessors, ymod, mod, ymod.Container, ymod.List, ymod.Leaf, ymod.LeafList, ymod.Choice, ymod.Uses)
+}
+
+// Add the fields for the top level data nodes. The names of the fields
+// carry the prefix of the module as the modules may define nodes with the
+// same name. The namespace is explicit in each field as the nodes of the
+// different modules are siblings within <data>. The data nodes of choices
+// and of groupings instantiated through uses are pulled up to the top.
+// The checks of the nodes are collected for the validate() of Device and
+// the keyed lists for the accessors of Device.
+func addDataNodes(w io.Writer, v *validator, accessors *[]deviceList, ymod *yang.Module, mod *Module, conts []*yang.Container, lists []*yang.List,
+	leaves []*yang.Leaf, leaflists []*yang.LeafList, choices []*yang.Choice, uses []*yang.Uses) {
+	for _, cont := range conts {
+		fn := genTN(ymod, cont.NName())
+		tn := genTN(getMyYangModule(cont), fullName(cont))
+		fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\",presfield\"`\n", fn)
+		fmt.Fprintf(w, "\t%s %s_cont `xml:\"%s %s\"`\n", fn, tn, mod.namespace, cont.NName())
+		v.addNode(cont, fn, mod.name+":"+cont.NName())
+		v.addDefaults(cont, fn)
+	}
+	for _, list := range lists {
+		fn := genTN(ymod, list.NName())
+		tn := genTN(getMyYangModule(list), fullName(list))
+		fmt.Fprintf(w, "\t%s []%s `xml:\"%s %s\"`\n", fn, tn, mod.namespace, list.NName())
+		v.addNode(list, fn, mod.name+":"+list.NName())
+		v.addDefaults(list, fn)
+		if len(getListKeys(list)) > 0 {
+			*accessors = append(*accessors, deviceList{fn: fn, etn: tn, list: list})
+		}
 	}
-	for _, cont := range ymod.Container {
-		name := genTN(ymod, cont.NName())
-		fmt.Fprintf(w, "\t%s %s\n", name, name)
+	for _, leaf := range leaves {
+		fn := genTN(ymod, leaf.NName())
+		tn := getTypeName(getMyYangModule(leaf), leaf.Type)
+		if leaf.Type != nil && leaf.Type.Name == "empty" {
+			fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\"%s %s,presfield\"`\n", fn, mod.namespace, leaf.NName())
+			v.addNode(leaf, fn, mod.name+":"+leaf.NName())
+			continue
+		}
+		fmt.Fprintf(w, "\t%s_Prsnt bool `xml:\",presfield\"`\n", fn)
+		fmt.Fprintf(w, "\t%s %s `xml:\"%s %s\"`\n", fn, tn, mod.namespace, leaf.NName())
+		v.addNode(leaf, fn, mod.name+":"+leaf.NName())
+		v.addDefaults(leaf, fn)
 	}
-	for _, list := range ymod.List {
-		name := genTN(ymod, list.NName())
-		fmt.Fprintf(w, "\t%s %s\n", name, name)
+	for _, leaflist := range leaflists {
+		fn := genTN(ymod, leaflist.NName())
+		tn := getTypeName(getMyYangModule(leaflist), leaflist.Type)
+		fmt.Fprintf(w, "\t%s []%s `xml:\"%s %s\"`\n", fn, tn, mod.namespace, leaflist.NName())
+		v.addNode(leaflist, fn, mod.name+":"+leaflist.NName())
+		v.addDefaults(leaflist, fn)
 	}
-	for _, leaf := range ymod.Leaf {
-		name := genTN(ymod, leaf.NName())
-		fmt.Fprintf(w, "\t%s %s\n", name, name)
+	// The case present is not known once the choices are flattened and
+	// the defaults of their nodes are left out
+	noDefaults := v.noDefaults
+	v.noDefaults = true
+	for _, choice := range choices {
+		for _, c := range choice.Case {
+			addDataNodes(w, v, accessors, ymod, mod, c.Container, c.List, c.Leaf, c.LeafList, c.Choice, c.Uses)
+		}
+		addDataNodes(w, v, accessors, ymod, mod, choice.Container, choice.List, choice.Leaf, choice.LeafList, nil, nil)
 	}
-	for _, leaflist := range ymod.LeafList {
-		name := genTN(ymod, leaflist.NName())
-		fmt.Fprintf(w, "\t%s %s\n", name, name)
+	v.noDefaults = noDefaults
+	for _, u := range uses {
+		g := getGroupingByName(u)
+		if g == nil {
+			continue
+		}
+		addDataNodes(w, v, accessors, ymod, mod, g.Container, g.List, g.Leaf, g.LeafList, g.Choice, g.Uses)
 	}
 }
-
@@ -14,9 +14,11 @@ func addNotificationComment(w io.Writer, n *yang.Notification) {
 	s = commentString(s)
 	fmt.Fprint(w, s)
 	fmt.Fprint(w, "//  Description:\n")
-	s = indentString(n.Description.Name)
-	s = commentString(s)
-	fmt.Fprint(w, s)
+	if n.Description != nil {
+		s = indentString(n.Description.Name)
+		s = commentString(s)
+		fmt.Fprint(w, s)
+	}
 	fmt.Fprintln(w, "//-------------------------------------------------------------")
 }
 
@@ -25,7 +27,8 @@ func genTypeForNotification(w io.Writer, ymod *yang.Module, n yang.Node, prev ya
 	var addNs bool = false
 	notif, ok := n.(*yang.Notification)
 	if !ok {
-		panic("Not a Container")
+		errorlog("genTypeForNotification(): %s.%s is not a Notification", n.NName(), n.Kind())
+		return
 	}
 
 	addNotificationComment(w, notif)
@@ -48,9 +51,15 @@ func genTypeForNotification(w io.Writer, ymod *yang.Module, n yang.Node, prev ya
 	for _, g1 := range notif.Grouping {
 		generateField(w, ymod, g1, notif, addNs)
 	}
+	for _, l1 := range notif.LeafList {
+		generateField(w, ymod, l1, notif, addNs)
+	}
 	for _, l1 := range notif.List {
 		generateField(w, ymod, l1, notif, addNs)
 	}
+	for _, c1 := range notif.Choice {
+		generateField(w, ymod, c1, notif, addNs)
+	}
 	for _, u1 := range notif.Uses {
 		generateField(w, ymod, u1, notif, addNs)
 	}
@@ -59,6 +68,7 @@ func genTypeForNotification(w io.Writer, ymod *yang.Module, n yang.Node, prev ya
 	// Generate runtime namespace function
 	mod := getMyModule(notif)
 	generateContainerRuntimeNs(w, mod, ymod, name)
+	generateListAccessors(w, ymod, genTN(ymod, name)+"_cont", notif, notif.List)
 
 	// The code below triggers the code generation for the
 	// constituents of the grouping
@@ -72,11 +82,33 @@ func genTypeForNotification(w io.Writer, ymod *yang.Module, n yang.Node, prev ya
 			generateType(w, ymod, l1, notif, false)
 		}
 	}
+	for _, l1 := range notif.LeafList {
+		if l1.ParentNode() == notif {
+			generateType(w, ymod, l1, notif, false)
+		}
+	}
 	for _, l1 := range notif.List {
 		if l1.ParentNode() == notif {
 			generateType(w, ymod, l1, notif, false)
 		}
 	}
+	for _, c1 := range notif.Choice {
+		if c1.ParentNode() == notif {
+			generateType(w, ymod, c1, notif, false)
+		}
+	}
+}
+
+// The notifications at the top level of the module are registered with
+// the runtime so that a received <notification> can be decoded into the
+// generated structure based on the namespace and the name of the element.
+// The registration is part of the init() function of the module.
+func registerNotification(submod *SubModule, ymod *yang.Module, notif *yang.Notification) {
+	mod := getMyModule(ymod)
+	tn := genTN(ymod, fullName(notif)) + "_cont"
+	s := fmt.Sprintf("nc.RegisterNotification(%s_ns, \"%s\", func() interface{} { return &%s{} })\n",
+		genFN(mod.name), notif.NName(), tn)
+	submod.initfunc = append(submod.initfunc, s)
 }
 
 func generateNotificationrRuntimeNs(w io.Writer, mod *Module, ymod *yang.Module, name string) {
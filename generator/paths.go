@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// The path builders follow the data tree from Root(). Each container and
+// each list gets the type <type>_path which holds the nc.Path of the node
+// and has a method for each data node within it. The nodes of choices,
+// cases and groupings are those of the node that contains them as they
+// don't appear in the data tree. The method of a list takes the keys and
+// returns the path of an entry while the one with the suffix _any returns
+// the path of all the entries. The leaves and the leaf-lists end the
+// path with a plain nc.Path.
+type pathGen struct {
+	w   io.Writer
+	ptn string
+	// The submodule whose top level nodes are added to the path of
+	// Device. The methods are then named as the fields of Device.
+	ymod *yang.Module
+}
+
+// Generate the path type of a container or a list and its methods
+func generatePaths(w io.Writer, tn string, node yang.Node) {
+	g := &pathGen{w: w, ptn: tn + "_path"}
+	fmt.Fprintf(w, "// %s is the path of a node of type %s\n", g.ptn, tn)
+	fmt.Fprintf(w, "type %s struct {\n", g.ptn)
+	fmt.Fprintf(w, "\tnc.Path\n")
+	fmt.Fprintf(w, "}\n")
+	switch n := node.(type) {
+	case *yang.Container:
+		g.addNodes(n, n.Container, n.List, n.Leaf, n.LeafList, n.Choice, n.Uses)
+	case *yang.List:
+		g.addNodes(n, n.Container, n.List, n.Leaf, n.LeafList, n.Choice, n.Uses)
+	}
+}
+
+// Generate the path type of Device and Root(). The nodes of a submodule
+// are added by addDevicePaths().
+func generateDevicePath(w io.Writer) {
+	fmt.Fprintf(w, "// Device_path is the path of the top of the data tree\n")
+	fmt.Fprintf(w, "type Device_path struct {\n")
+	fmt.Fprintf(w, "\tnc.Path\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "// Root returns the path of the top of the data tree\n")
+	fmt.Fprintf(w, "func Root() Device_path {\n")
+	fmt.Fprintf(w, "\treturn Device_path{}\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+func addDevicePaths(w io.Writer, sm *SubModule) {
+	g := &pathGen{w: w, ptn: "Device_path", ymod: sm.module}
+	m := sm.module
+	g.addNodes(m, m.Container, m.List, m.Leaf, m.LeafList, m.Choice, m.Uses)
+}
+
+// Add the methods for the nodes whose parent in the schema is prev
+func (g *pathGen) addNodes(prev yang.Node, conts []*yang.Container, lists []*yang.List, leaves []*yang.Leaf,
+	leaflists []*yang.LeafList, choices []*yang.Choice, uses []*yang.Uses) {
+	for _, c := range conts {
+		tn := genTN(getMyYangModule(prev), fullName(c)) + "_cont_path"
+		g.method(g.name(c), tn, "", fmt.Sprintf("%s{p.Child(%s, %q)}", tn, g.ns(c, prev), c.NName()))
+	}
+	for _, l := range lists {
+		g.addList(prev, l)
+	}
+	for _, l := range leaves {
+		g.method(g.name(l), "nc.Path", "", fmt.Sprintf("p.Child(%s, %q)", g.ns(l, prev), l.NName()))
+	}
+	for _, l := range leaflists {
+		g.method(g.name(l), "nc.Path", "", fmt.Sprintf("p.Child(%s, %q)", g.ns(l, prev), l.NName()))
+	}
+	for _, c := range choices {
+		for _, c1 := range c.Case {
+			g.addNodes(c1, c1.Container, c1.List, c1.Leaf, c1.LeafList, c1.Choice, c1.Uses)
+		}
+		g.addNodes(c, c.Container, c.List, c.Leaf, c.LeafList, nil, nil)
+	}
+	for _, u := range uses {
+		gr := getGroupingByName(u)
+		if gr == nil {
+			continue
+		}
+		g.addNodes(gr, gr.Container, gr.List, gr.Leaf, gr.LeafList, gr.Choice, gr.Uses)
+	}
+}
+
+// The keys of the list are the parameters of the method for an entry
+func (g *pathGen) addList(prev yang.Node, list *yang.List) {
+	tn := genTN(getMyYangModule(prev), fullName(list)) + "_path"
+	child := fmt.Sprintf("p.Child(%s, %q", g.ns(list, prev), list.NName())
+	if list.Key == nil {
+		g.method(g.name(list), tn, "", fmt.Sprintf("%s{%s)}", tn, child))
+		return
+	}
+	var params, keys []string
+	for _, key := range strings.Fields(list.Key.Name) {
+		key = getName(key)
+		leaf, ok := getNodeFromList(list, key, true).(*yang.Leaf)
+		if !ok {
+			errorlog("addList(): key %s not found in %s", key, nodeString(list))
+			return
+		}
+		pn := genPN(list.NName() + "_" + key)
+		params = append(params, pn+" "+getTypeName(getMyYangModule(leaf), leaf.Type))
+		keys = append(keys, fmt.Sprintf("nc.KeyValue{Name: %q, Value: %s}", key, pn))
+	}
+	g.method(g.name(list), tn, strings.Join(params, ", "), fmt.Sprintf("%s{%s, %s)}", tn, child, strings.Join(keys, ", ")))
+	g.method(g.name(list)+"_any", tn, "", fmt.Sprintf("%s{%s)}", tn, child))
+}
+
+func (g *pathGen) method(name string, tn string, params string, expr string) {
+	fmt.Fprintf(g.w, "func (p %s) %s(%s) %s {\n", g.ptn, name, params, tn)
+	fmt.Fprintf(g.w, "\treturn %s\n", expr)
+	fmt.Fprintf(g.w, "}\n")
+}
+
+// The methods are named as the fields of the structures
+func (g *pathGen) name(node yang.Node) string {
+	if g.ymod != nil {
+		return genTN(g.ymod, node.NName())
+	}
+	return genFN(node.NName())
+}
+
+// The namespace of a node is the one of its parent unless it comes from
+// another module as for augments or it is at the top
+func (g *pathGen) ns(node yang.Node, prev yang.Node) string {
+	mod := getMyModule(node)
+	if mod == nil {
+		return "\"\""
+	}
+	if g.ymod != nil || mod != getMyModule(prev) {
+		return genFN(mod.name) + "_ns"
+	}
+	return "\"\""
+}
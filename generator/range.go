@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A range or length expression of yang is a set of intervals separated
+// by "|". Each interval is either a single value or two values separated
+// by "..". The keywords "min" and "max" stand for the limits of the type
+// being restricted. Each interval is kept as the text of its bounds as
+// they are written as is into the generated code. An empty bound means
+// that there is no constraint on that side of the interval.
+type rangePart struct {
+	min string
+	max string
+}
+
+// Parse a range or length expression. The kind decides how the values
+// are validated: "int" for signed integers, "uint" for unsigned integers
+// and lengths and "decimal" for values of decimal64.
+func parseRange(expr string, kind string) ([]rangePart, error) {
+	var parts []rangePart
+	for _, s := range strings.Split(expr, "|") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, fmt.Errorf("empty interval in \"%s\"", expr)
+		}
+		var part rangePart
+		bounds := strings.Split(s, "..")
+		switch len(bounds) {
+		case 1:
+			part.min = strings.TrimSpace(bounds[0])
+			part.max = part.min
+		case 2:
+			part.min = strings.TrimSpace(bounds[0])
+			part.max = strings.TrimSpace(bounds[1])
+		default:
+			return nil, fmt.Errorf("invalid interval \"%s\" in \"%s\"", s, expr)
+		}
+		// The limits of the type are not known here. They are left out
+		// as the values can never cross the limits of the type anyway.
+		// This also covers a single value of min or max which is treated
+		// as no constraint.
+		for _, b := range []*string{&part.min, &part.max} {
+			if *b == "min" || *b == "max" {
+				*b = ""
+				continue
+			}
+			if err := checkBound(*b, kind); err != nil {
+				return nil, fmt.Errorf("invalid value \"%s\" in \"%s\": %s", *b, expr, err.Error())
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func checkBound(b string, kind string) error {
+	var err error
+	switch kind {
+	case "decimal":
+		_, err = strconv.ParseFloat(b, 64)
+	case "uint":
+		_, err = strconv.ParseUint(b, 10, 64)
+	default:
+		if strings.HasPrefix(b, "-") {
+			_, err = strconv.ParseInt(b, 10, 64)
+		} else {
+			_, err = strconv.ParseUint(b, 10, 64)
+		}
+	}
+	return err
+}
+
+// Generate the condition which is true when the value in the variable v
+// is outside all the intervals of the range. An empty string is returned
+// when the range doesn't constrain the values, for example "min..max".
+func rangeViolation(v string, parts []rangePart) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	var conds []string
+	for _, p := range parts {
+		switch {
+		case p.min == "" && p.max == "":
+			return ""
+		case p.min == "":
+			conds = append(conds, fmt.Sprintf("%s <= %s", v, p.max))
+		case p.max == "":
+			conds = append(conds, fmt.Sprintf("%s >= %s", v, p.min))
+		case p.min == p.max:
+			conds = append(conds, fmt.Sprintf("%s == %s", v, p.min))
+		default:
+			conds = append(conds, fmt.Sprintf("(%s >= %s && %s <= %s)", v, p.min, v, p.max))
+		}
+	}
+	return "!(" + strings.Join(conds, " || ") + ")"
+}
+
+// The bounds of a range of decimal64 are scaled by the fraction digits so
+// that they can be compared with the scaled integers that represent the
+// values in the generated code.
+func scaleRange(parts []rangePart, fd int) ([]rangePart, error) {
+	var scaled []rangePart
+	for _, p := range parts {
+		var err error
+		if p.min != "" {
+			if p.min, err = scaleDecimal(p.min, fd); err != nil {
+				return nil, err
+			}
+		}
+		if p.max != "" {
+			if p.max, err = scaleDecimal(p.max, fd); err != nil {
+				return nil, err
+			}
+		}
+		scaled = append(scaled, p)
+	}
+	return scaled, nil
+}
+
+// Convert a decimal value to the integer scaled by fraction digits. The
+// value must not have more digits after the decimal point than fd.
+func scaleDecimal(s string, fd int) (string, error) {
+	ip, fp, _ := strings.Cut(s, ".")
+	if len(fp) > fd {
+		return "", fmt.Errorf("%s has more than %d fraction digits", s, fd)
+	}
+	v, err := strconv.ParseInt(ip+fp+strings.Repeat("0", fd-len(fp)), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a valid decimal64: %s", s, err.Error())
+	}
+	return strconv.FormatInt(v, 10), nil
+}
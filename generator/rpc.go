@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// Generate comments for the structures that are generated for the rpc.
+// The comments carry the name and the description of the rpc so that
+// the source of the generated code can be identified
+func addRpcComment(w io.Writer, rpc *yang.RPC) {
+	fmt.Fprintln(w, "//------------------------------------------------------------")
+	fmt.Fprint(w, "//  Name:\n")
+	s := indentString("rpc: " + rpc.NName())
+	s = commentString(s)
+	fmt.Fprint(w, s)
+	fmt.Fprint(w, "//  Description:\n")
+	if rpc.Description != nil {
+		s = indentString(rpc.Description.Name)
+		s = commentString(s)
+		fmt.Fprint(w, s)
+	}
+	fmt.Fprintln(w, "//-------------------------------------------------------------")
+}
+
+// Each rpc is translated into three definitions. The input structure
+// is what is sent within <rpc> and therefore carries the XMLName of the
+// rpc. The output structure is what is received within <rpc-reply>. The
+// descriptor is a variable that captures name, namespace and module of
+// the rpc which is made available through the RpcInfo() method on both
+// the input and the output structures.
+func genTypeForRpc(w io.Writer, ymod *yang.Module, n yang.Node) {
+	rpc, ok := n.(*yang.RPC)
+	if !ok {
+		errorlog("genTypeForRpc(): %s.%s is not an rpc", n.NName(), n.Kind())
+		return
+	}
+	addRpcComment(w, rpc)
+	name := fullName(rpc)
+	mod := getMyModule(rpc)
+	generateRpcDescriptor(w, mod, ymod, rpc.NName(), name)
+	genTypeForInput(w, ymod, mod, rpc.Input, name, rpc.NName())
+	genTypeForOutput(w, ymod, mod, rpc.Output, name)
+}
+
+// The descriptor of the rpc. The namespace refers to the variable generated
+// in the file header of the module so that there is a single definition
+func generateRpcDescriptor(w io.Writer, mod *Module, ymod *yang.Module, rpcname string, name string) {
+	fmt.Fprintf(w, "var %s_rpc = nc.RpcInfo{\n", genTN(ymod, name))
+	fmt.Fprintf(w, "\tName: \"%s\",\n", rpcname)
+	fmt.Fprintf(w, "\tNamespace: %s_ns,\n", genFN(mod.name))
+	fmt.Fprintf(w, "\tModule: \"%s\",\n", mod.name)
+	fmt.Fprintf(w, "}\n")
+}
+
+// Generate the structure for the input of an rpc. The input may be absent
+// in the yang specification in which case an empty structure is generated
+// as the operation must still be sent. The XMLName is always included as
+// the input is encoded as the element named after the rpc. Its namespace
+// is the one of the module mod that defines the rpc, which for an action
+// added by an augment isn't the module of the node it is generated in.
+func genTypeForInput(w io.Writer, ymod *yang.Module, mod *Module, in *yang.Input, name string, elemname string) {
+	var addNs bool = false
+	tn := genTN(ymod, name+"_input")
+	fmt.Fprintf(w, "type %s_cont struct {\n", tn)
+	fmt.Fprintf(w, "\tXMLName nc.XmlId `xml:\"%s %s\"`\n", mod.namespace, elemname)
+	if in != nil {
+		for _, c1 := range in.Container {
+			generateField(w, ymod, c1, in, addNs)
+		}
+		for _, l1 := range in.Leaf {
+			generateField(w, ymod, l1, in, addNs)
+		}
+		for _, l1 := range in.LeafList {
+			generateField(w, ymod, l1, in, addNs)
+		}
+		for _, l1 := range in.List {
+			generateField(w, ymod, l1, in, addNs)
+		}
+		for _, c1 := range in.Choice {
+			generateField(w, ymod, c1, in, addNs)
+		}
+		for _, u1 := range in.Uses {
+			generateField(w, ymod, u1, in, addNs)
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+	generateContainerRuntimeNs(w, mod, ymod, name+"_input")
+	generateRpcInfo(w, ymod, name, tn+"_cont")
+	if in != nil {
+		generateListAccessors(w, ymod, tn+"_cont", in, in.List)
+	}
+
+	// The code below triggers the code generation for the
+	// constituents of the input
+	if in != nil {
+		for _, c1 := range in.Container {
+			generateType(w, ymod, c1, in, false)
+		}
+		for _, l1 := range in.Leaf {
+			generateType(w, ymod, l1, in, false)
+		}
+		for _, l1 := range in.LeafList {
+			generateType(w, ymod, l1, in, false)
+		}
+		for _, l1 := range in.List {
+			generateType(w, ymod, l1, in, false)
+		}
+		for _, c1 := range in.Choice {
+			generateType(w, ymod, c1, in, false)
+		}
+	}
+}
+
+// Generate the structure for the output of an rpc. The output is decoded
+// from the children of <rpc-reply> and hence doesn't carry an XMLName.
+func genTypeForOutput(w io.Writer, ymod *yang.Module, mod *Module, out *yang.Output, name string) {
+	var addNs bool = false
+	tn := genTN(ymod, name+"_output")
+	fmt.Fprintf(w, "type %s_cont struct {\n", tn)
+	if out != nil {
+		for _, c1 := range out.Container {
+			generateField(w, ymod, c1, out, addNs)
+		}
+		for _, l1 := range out.Leaf {
+			generateField(w, ymod, l1, out, addNs)
+		}
+		for _, l1 := range out.LeafList {
+			generateField(w, ymod, l1, out, addNs)
+		}
+		for _, l1 := range out.List {
+			generateField(w, ymod, l1, out, addNs)
+		}
+		for _, c1 := range out.Choice {
+			generateField(w, ymod, c1, out, addNs)
+		}
+		for _, u1 := range out.Uses {
+			generateField(w, ymod, u1, out, addNs)
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+	generateContainerRuntimeNs(w, mod, ymod, name+"_output")
+	generateRpcInfo(w, ymod, name, tn+"_cont")
+	if out != nil {
+		generateListAccessors(w, ymod, tn+"_cont", out, out.List)
+	}
+
+	// The code below triggers the code generation for the
+	// constituents of the output
+	if out != nil {
+		for _, c1 := range out.Container {
+			generateType(w, ymod, c1, out, false)
+		}
+		for _, l1 := range out.Leaf {
+			generateType(w, ymod, l1, out, false)
+		}
+		for _, l1 := range out.LeafList {
+			generateType(w, ymod, l1, out, false)
+		}
+		for _, l1 := range out.List {
+			generateType(w, ymod, l1, out, false)
+		}
+		for _, c1 := range out.Choice {
+			generateType(w, ymod, c1, out, false)
+		}
+	}
+}
+
+// The input and output structures both point to the descriptor of the
+// rpc. The client uses this to identify the operation being invoked.
+func generateRpcInfo(w io.Writer, ymod *yang.Module, name string, tn string) {
+	fmt.Fprintf(w, "func (x %s) RpcInfo() nc.RpcInfo {\n", tn)
+	fmt.Fprintf(w, "\treturn %s_rpc\n", genTN(ymod, name))
+	fmt.Fprintf(w, "}\n")
+}
+
+// Look for a node within the input of an rpc. This is needed when the
+// traversal of a path such as leafref passes through the input
+func getNodeFromInput(in *yang.Input, fname string, leaf bool) yang.Node {
+	debuglog("getNodeFromInput(): looking for %s in %s", fname, nodeString(in.ParentNode()))
+	name := getName(fname)
+	for _, c1 := range in.Container {
+		if c1.NName() == name {
+			return c1
+		}
+	}
+	for _, l1 := range in.Leaf {
+		if l1.NName() == name {
+			return l1
+		}
+	}
+	for _, l1 := range in.LeafList {
+		if l1.NName() == name {
+			return l1
+		}
+	}
+	for _, l1 := range in.List {
+		if l1.NName() == name {
+			return l1
+		}
+	}
+	for _, c1 := range in.Choice {
+		if c1.NName() == name {
+			return c1
+		}
+	}
+	for _, u1 := range in.Uses {
+		if node := getNodeFromUses(u1, name); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// Look for a node within the output of an rpc.
+func getNodeFromOutput(out *yang.Output, fname string, leaf bool) yang.Node {
+	debuglog("getNodeFromOutput(): looking for %s in %s", fname, nodeString(out.ParentNode()))
+	name := getName(fname)
+	for _, c1 := range out.Container {
+		if c1.NName() == name {
+			return c1
+		}
+	}
+	for _, l1 := range out.Leaf {
+		if l1.NName() == name {
+			return l1
+		}
+	}
+	for _, l1 := range out.LeafList {
+		if l1.NName() == name {
+			return l1
+		}
+	}
+	for _, l1 := range out.List {
+		if l1.NName() == name {
+			return l1
+		}
+	}
+	for _, c1 := range out.Choice {
+		if c1.NName() == name {
+			return c1
+		}
+	}
+	for _, u1 := range out.Uses {
+		if node := getNodeFromUses(u1, name); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// This function attempts to locate a uses node within the input and output
+// of an rpc recursively till it finds a uses node which uses the same string
+// as passed above.
+func getMatchingUsesNodeFromRpc(rpc *yang.RPC, name string) yang.Node {
+	iname := getName(name)
+	if in := rpc.Input; in != nil {
+		for _, u1 := range in.Uses {
+			if getName(u1.NName()) == iname {
+				return in
+			}
+		}
+		for _, c1 := range in.Container {
+			if n := getMatchingUsesNodeFromContainer(c1, name); n != nil {
+				return n
+			}
+		}
+		for _, l1 := range in.List {
+			if n := getMatchingUsesNodeFromList(l1, name); n != nil {
+				return n
+			}
+		}
+	}
+	if out := rpc.Output; out != nil {
+		for _, u1 := range out.Uses {
+			if getName(u1.NName()) == iname {
+				return out
+			}
+		}
+		for _, c1 := range out.Container {
+			if n := getMatchingUsesNodeFromContainer(c1, name); n != nil {
+				return n
+			}
+		}
+		for _, l1 := range out.List {
+			if n := getMatchingUsesNodeFromList(l1, name); n != nil {
+				return n
+			}
+		}
+	}
+	return nil
+}
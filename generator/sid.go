@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The SIDs of RFC 9595 are read from the .sid files of the modules. The
+// generated code carries a constant for the SID of each item next to the
+// namespace of the module and registers the SIDs with the runtime for
+// the CBOR encoding.
+type sidItem struct {
+	Namespace  string   `json:"namespace"`
+	Identifier string   `json:"identifier"`
+	Sid        sidValue `json:"sid"`
+}
+
+type sidFile struct {
+	ModuleName string    `json:"module-name"`
+	Item       []sidItem `json:"item"`
+	Items      []sidItem `json:"items"`
+}
+
+// The SID is a uint64 which RFC 7951 encodes as a string. Numbers are
+// accepted too.
+type sidValue uint64
+
+func (s *sidValue) UnmarshalJSON(b []byte) error {
+	x, err := strconv.ParseUint(strings.Trim(string(b), "\""), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sid %s", string(b))
+	}
+	*s = sidValue(x)
+	return nil
+}
+
+// The .sid files read indexed by the name of the module
+var sidFiles = map[string]*sidFile{}
+
+// Read the .sid files of a directory. The content is either the container
+// sid-file of module ietf-sid-file or its content as in earlier drafts.
+func readSidFiles(dir string) {
+	for _, name := range readDir(dir, ".sid") {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			errorlog("readSidFiles(): %s", err.Error())
+			continue
+		}
+		var top map[string]json.RawMessage
+		if err := json.Unmarshal(b, &top); err != nil {
+			errorlog("readSidFiles(): %s: %s", name, err.Error())
+			continue
+		}
+		if c, ok := top["ietf-sid-file:sid-file"]; ok {
+			b = c
+		}
+		sf := &sidFile{}
+		if err := json.Unmarshal(b, sf); err != nil {
+			errorlog("readSidFiles(): %s: %s", name, err.Error())
+			continue
+		}
+		if sf.ModuleName == "" {
+			errorlog("readSidFiles(): %s: module-name missing", name)
+			continue
+		}
+		sf.Item = append(sf.Item, sf.Items...)
+		sidFiles[sf.ModuleName] = sf
+	}
+}
+
+// The name of the constant for the SID of an item. The name of a data
+// node is made of the names of the nodes in its path.
+func sidConstName(mod *Module, item sidItem) string {
+	ymod := mod.module
+	switch item.Namespace {
+	case "module":
+		return genFN(item.Identifier) + "_sid"
+	case "identity":
+		return genTN(ymod, item.Identifier) + "_identity_sid"
+	case "feature":
+		return genTN(ymod, item.Identifier) + "_feature_sid"
+	case "data":
+		var names []string
+		for _, p := range strings.Split(strings.Trim(item.Identifier, "/"), "/") {
+			names = append(names, getName(p))
+		}
+		return genTN(ymod, strings.Join(names, "_")) + "_sid"
+	}
+	return ""
+}
+
+// The identifier with which the runtime knows an item. The identities
+// are qualified by the module and the features are not registered.
+func sidRuntimeId(mod *Module, item sidItem) string {
+	switch item.Namespace {
+	case "module", "data":
+		return item.Identifier
+	case "identity":
+		return mod.name + ":" + item.Identifier
+	}
+	return ""
+}
+
+// Generate the constants for the SIDs of the module and add their
+// registration to the init() function.
+func generateSids(w io.Writer, mod *Module, submod *SubModule) {
+	sf, ok := sidFiles[mod.name]
+	if !ok {
+		return
+	}
+	seen := map[string]bool{}
+	for _, item := range sf.Item {
+		name := sidConstName(mod, item)
+		if name == "" {
+			errorlog("generateSids(): unknown namespace %s in %s", item.Namespace, mod.name)
+			continue
+		}
+		if seen[name] {
+			errorlog("generateSids(): duplicate name %s for %s", name, item.Identifier)
+			continue
+		}
+		seen[name] = true
+		fmt.Fprintf(w, "const %s = %d\n", name, uint64(item.Sid))
+		if id := sidRuntimeId(mod, item); id != "" {
+			s := fmt.Sprintf("nc.RegisterSid(%q, %s)\n", id, name)
+			submod.initfunc = append(submod.initfunc, s)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package yang
+
+import (
+	"reflect"
+	"testing"
+
+	nc "nc/nc"
+)
+
+// The tests of the code generated for the modules of testdata/yang. They
+// run in the generated package.
+
+func TestBinaryKeys(t *testing.T) {
+	var s Tk_servers_cont
+	s.SetServer(Tk_servers_server{Bin: Tk_servers_server_bin("a"), Name: "x"})
+	s.SetServer(Tk_servers_server{Bin: Tk_servers_server_bin("b"), Name: "y"})
+	s.SetServer(Tk_servers_server{Bin: Tk_servers_server_bin("a"), Name: "z"})
+	if len(s.Server) != 2 {
+		t.Fatalf("%d servers, want 2", len(s.Server))
+	}
+	if e := s.GetServer(Tk_servers_server_listkey{Bin: Tk_servers_server_bin("a")}); e == nil || e.Name != "z" {
+		t.Errorf("GetServer(a) = %v, want z", e)
+	}
+	if s.GetServer(Tk_servers_server_listkey{Bin: Tk_servers_server_bin("c")}) != nil {
+		t.Errorf("GetServer(c) found an entry")
+	}
+	if !s.DeleteServer(Tk_servers_server_listkey{Bin: Tk_servers_server_bin("b")}) || len(s.Server) != 1 {
+		t.Errorf("DeleteServer(b) didn't delete the entry")
+	}
+}
+
+func TestUnionKeys(t *testing.T) {
+	blob := func(s string) Tk_servers_alias_id {
+		return Tk_servers_alias_id{Blob_1_Prsnt: true, Blob_1: Tk_blob(s)}
+	}
+	var s Tk_servers_cont
+	s.SetAlias(Tk_servers_alias{Ref: Tk_servers_server_bin("a"), Id: blob("x")})
+	s.SetAlias(Tk_servers_alias{Ref: Tk_servers_server_bin("a"), Id: Tk_servers_alias_id{Uint8_0_Prsnt: true, Uint8_0: 1}})
+	s.SetAlias(Tk_servers_alias{Ref: Tk_servers_server_bin("a"), Id: blob("x")})
+	if len(s.Alias) != 2 {
+		t.Fatalf("%d aliases, want 2", len(s.Alias))
+	}
+	if s.GetAlias(Tk_servers_alias_listkey{Ref: Tk_servers_server_bin("a"), Id: blob("x")}) == nil {
+		t.Errorf("GetAlias(a, x) found no entry")
+	}
+	if s.GetAlias(Tk_servers_alias_listkey{Ref: Tk_servers_server_bin("b"), Id: blob("x")}) != nil {
+		t.Errorf("GetAlias(b, x) found an entry")
+	}
+}
+
+func TestDeviceLists(t *testing.T) {
+	var d Device
+	d.SetTk_user(Tk_user{Name: "root", Uid: 0})
+	d.SetTk_user(Tk_user{Name: "bob", Uid: 1000})
+	if e := d.GetTk_user(Tk_user_listkey{Name: "bob"}); e == nil || e.Uid != 1000 {
+		t.Errorf("GetTk_user(bob) = %v", e)
+	}
+	if !d.DeleteTk_user(Tk_user_listkey{Name: "root"}) || d.DeleteTk_user(Tk_user_listkey{Name: "root"}) {
+		t.Errorf("DeleteTk_user(root) didn't delete the entry once")
+	}
+}
+
+// The lists and the leaves named key don't collide with the methods of
+// the keys
+func TestListNamedKey(t *testing.T) {
+	var c Tk_key_chains_key_chain
+	c.SetKey(Tk_key_chains_key_chain_key{Key_id: 1, Key: "secret"})
+	c.SetKey(Tk_key_chains_key_chain_key{Key_id: 2, Key: "other"})
+	c.SetKey(Tk_key_chains_key_chain_key{Key_id: 1, Key: "changed"})
+	if len(c.Key) != 2 {
+		t.Fatalf("%d keys, want 2", len(c.Key))
+	}
+	if e := c.GetKey(Tk_key_chains_key_chain_key_listkey{Key_id: 1}); e == nil || e.Key != "changed" {
+		t.Errorf("GetKey(1) = %v, want changed", e)
+	}
+	if got := c.Key[0].ListKeyNames(); !reflect.DeepEqual(got, []string{"key-id"}) {
+		t.Errorf("ListKeyNames() = %v", got)
+	}
+}
+
+// The entries set through the accessors have their keys present
+func TestSetKeyPresence(t *testing.T) {
+	var d Device
+	d.SetTk_user(Tk_user{Name: "bob"})
+	if !d.Tk_user[0].Name_Prsnt {
+		t.Errorf("the key of the entry set isn't present")
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	var c Tk_key_chains_key_chain
+	c.Name, c.Name_Prsnt = "k", true
+	c.SetKey(Tk_key_chains_key_chain_key{Key_id: 7})
+	if !c.Key[0].Key_id_Prsnt || c.Key[0].Key_Prsnt {
+		t.Errorf("the presence of the entry set is %v, %v, want the key only", c.Key[0].Key_id_Prsnt, c.Key[0].Key_Prsnt)
+	}
+}
+
+// The violations of Validate() by path with their error-app-tag
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	errs, ok := err.(nc.ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() = %v, want violations", err)
+	}
+	m := map[string]string{}
+	for _, e := range errs {
+		m[e.Path] = e.AppTag
+	}
+	return m
+}
+
+func TestMandatoryLeaf(t *testing.T) {
+	var s Tb_system_cont
+	got := violations(t, s.Validate())
+	if got["/tbase:system/hostname"] != "missing-leaf" {
+		t.Errorf("violations %v, want missing-leaf at /tbase:system/hostname", got)
+	}
+}
+
+func TestMissingKey(t *testing.T) {
+	var u Tk_user
+	u.Uid, u.Uid_Prsnt = 1, true
+	got := violations(t, u.Validate())
+	if got["/tkeys:user[name='']/name"] != "missing-leaf" {
+		t.Errorf("violations %v, want missing-leaf at the key name", got)
+	}
+	u.Name, u.Name_Prsnt = "bob", true
+	if err := u.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+// The position of an entry that is validated alone isn't known
+func TestKeylessEntry(t *testing.T) {
+	var e Tb_system_log
+	got := violations(t, e.Validate())
+	if got["/tbase:system/log/text"] != "missing-leaf" {
+		t.Errorf("violations %v, want missing-leaf at /tbase:system/log/text", got)
+	}
+}
+
+func TestInputPath(t *testing.T) {
+	e := Tb_check_input_targets{Name: "a", Name_Prsnt: true}
+	got := violations(t, e.Validate())
+	if got["/tbase:check/input/targets[name='a']/addr"] != "missing-leaf" {
+		t.Errorf("violations %v, want missing-leaf at /tbase:check/input/targets[name='a']/addr", got)
+	}
+}
+
+func TestRpcInput(t *testing.T) {
+	in := Tr_restart_input_cont{Delay: 5, Delay_Prsnt: true}
+	in.Host, in.Host_Prsnt = "a", true
+	b, err := nc.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<restart xmlns="urn:trpc"><delay>5</delay><host>a</host></restart>`; string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+	var got Tr_restart_input_cont
+	if err := nc.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Delay != 5 || got.Host != "a" {
+		t.Errorf("Unmarshal() = %+v", got)
+	}
+	want := nc.RpcInfo{Name: "restart", Namespace: "urn:trpc", Module: "trpc"}
+	if in.RpcInfo() != want || (Tr_restart_output_cont{}).RpcInfo() != want {
+		t.Errorf("RpcInfo() = %+v, want %+v", in.RpcInfo(), want)
+	}
+}
+
+func TestRpcOutput(t *testing.T) {
+	b, err := nc.MarshalOutput(&Tr_restart_output_cont{Status: "ok", Status_Prsnt: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<status xmlns="urn:trpc">ok</status>`; string(b) != want {
+		t.Errorf("MarshalOutput() = %s, want %s", b, want)
+	}
+	// An rpc without input or output has empty ones
+	if b, err := nc.Marshal(&Tr_ping_input_cont{}); err != nil || string(b) != `<ping xmlns="urn:trpc"></ping>` {
+		t.Errorf("Marshal(ping) = %s, %v", b, err)
+	}
+	if Tr_ping_rpc.Name != "ping" {
+		t.Errorf("Tr_ping_rpc = %+v", Tr_ping_rpc)
+	}
+}
+
+// The notifications at the top level are registered for decoding
+func TestNotification(t *testing.T) {
+	v, ok := nc.NewNotification("urn:tnotif", "alarm")
+	if !ok {
+		t.Fatal("alarm isn't registered")
+	}
+	a, ok := v.(*Tn_alarm_cont)
+	if !ok {
+		t.Fatalf("NewNotification() = %T", v)
+	}
+	if err := nc.Unmarshal([]byte(`<alarm xmlns="urn:tnotif"><severity>2</severity><text>fan</text></alarm>`), a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Severity != 2 || a.Text != "fan" {
+		t.Errorf("Unmarshal() = %+v", a)
+	}
+	if _, ok := nc.NewNotification("urn:tnotif", "other"); ok {
+		t.Errorf("other is registered")
+	}
+}
+
+// The values of the enums are declared or follow the previous one
+func TestEnumValues(t *testing.T) {
+	tests := []struct {
+		v    int
+		want int
+		s    string
+	}{
+		{int(Te_color_Red), 1, "red"},
+		{int(Te_color_Green), 2, "green"},
+		{int(Te_color_Blue), 10, "blue"},
+		{int(Te_color_Black), 11, "black"},
+		{int(Te_level_Low), -5, "low"},
+		{int(Te_level_Mid), -4, "mid"},
+		{int(Te_level_High), 0, "high"},
+	}
+	for _, tt := range tests {
+		if tt.v != tt.want {
+			t.Errorf("%s = %d, want %d", tt.s, tt.v, tt.want)
+		}
+	}
+	for _, c := range []Te_color{Te_color_Red, Te_color_Green, Te_color_Blue, Te_color_Black} {
+		b, err := c.MarshalText("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Te_color
+		if err := got.UnmarshalText("", b); err != nil || got != c {
+			t.Errorf("UnmarshalText(%s) = %d, %v, want %d", b, got, err, c)
+		}
+	}
+	if _, err := Te_color(3).MarshalText(""); err == nil {
+		t.Errorf("MarshalText(3) succeeded")
+	}
+	var c Te_color
+	if err := c.UnmarshalText("", []byte("pink")); err == nil {
+		t.Errorf("UnmarshalText(pink) succeeded")
+	}
+}
+
+// The text of a value is accepted when it is in one of the intervals
+type textType interface {
+	MarshalText(ns string) ([]byte, error)
+	UnmarshalText(ns string, b []byte) error
+}
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		v    textType
+		in   []string
+		out  []string
+	}{
+		{"small", new(Trg_small), []string{"-10", "-1", "1", "10", "42"}, []string{"-11", "0", "11", "41", "43", "x"}},
+		{"high", new(Trg_high), []string{"100", "65535"}, []string{"99", "65536", "-1"}},
+		{"low", new(Trg_low), []string{"-128", "0"}, []string{"1", "-129"}},
+		{"odd", new(Trg_limits_odd), []string{"1", "3", "5", "7"}, []string{"0", "2", "4", "8"}},
+		{"code", new(Trg_code), []string{"a", "abcd", "abcdefgh", "ééé"}, []string{"", "abcde", "abcdefg", "abcdefghi"}},
+		{"word", new(Trg_word), []string{"", "abc"}, []string{"abcd"}},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.in {
+			if err := tt.v.UnmarshalText("", []byte(s)); err != nil {
+				t.Errorf("%s: UnmarshalText(%q) = %v", tt.name, s, err)
+				continue
+			}
+			if b, err := tt.v.MarshalText(""); err != nil || string(b) != s {
+				t.Errorf("%s: MarshalText() = %q, %v, want %q", tt.name, b, err, s)
+			}
+		}
+		for _, s := range tt.out {
+			if err := tt.v.UnmarshalText("", []byte(s)); err == nil {
+				t.Errorf("%s: UnmarshalText(%q) succeeded", tt.name, s)
+			}
+		}
+	}
+	if _, err := Trg_small(0).MarshalText(""); err == nil {
+		t.Errorf("MarshalText(0) of small succeeded")
+	}
+	if _, err := Trg_code("abcde").MarshalText(""); err == nil {
+		t.Errorf("MarshalText(abcde) of code succeeded")
+	}
+}
+
+func TestDecimal64(t *testing.T) {
+	tests := []struct {
+		v    textType
+		in   string
+		out  string
+		want int64
+	}{
+		{new(Td_power), "0.01", "0.01", 1},
+		{new(Td_power), "-3.5", "-3.50", -350},
+		{new(Td_power), "-40", "-40.00", -4000},
+		{new(Td_power), "+10.00", "10.00", 1000},
+		{new(Td_optics_gain), "0", "0.000", 0},
+		{new(Td_optics_gain), "1.5", "1.500", 1500},
+		{new(Td_optics_gain), "9223372036854775.807", "9223372036854775.807", 9223372036854775807},
+		{new(Td_tiny), "-0.000000000000000001", "-0.000000000000000001", -1},
+		{new(Td_tiny), "9.223372036854775807", "9.223372036854775807", 9223372036854775807},
+	}
+	for _, tt := range tests {
+		if err := tt.v.UnmarshalText("", []byte(tt.in)); err != nil {
+			t.Errorf("UnmarshalText(%s) = %v", tt.in, err)
+			continue
+		}
+		if got := reflect.ValueOf(tt.v).Elem().Int(); got != tt.want {
+			t.Errorf("UnmarshalText(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+		if b, err := tt.v.MarshalText(""); err != nil || string(b) != tt.out {
+			t.Errorf("MarshalText() of %s = %s, %v, want %s", tt.in, b, err, tt.out)
+		}
+	}
+	for _, tt := range []struct {
+		v  textType
+		in string
+	}{
+		{new(Td_power), "10.01"},
+		{new(Td_power), "-40.01"},
+		{new(Td_power), "0.001"},
+		{new(Td_power), "1e2"},
+		{new(Td_power), "."},
+		{new(Td_power), "1."},
+		{new(Td_power), "--1"},
+		{new(Td_power), "1.-1"},
+		{new(Td_optics_gain), "1"},
+		{new(Td_tiny), "9.223372036854775808"},
+	} {
+		if err := tt.v.UnmarshalText("", []byte(tt.in)); err == nil {
+			t.Errorf("UnmarshalText(%s) of %T succeeded", tt.in, tt.v)
+		}
+	}
+	if _, err := Td_power(1001).MarshalText(""); err == nil {
+		t.Errorf("MarshalText(10.01) succeeded")
+	}
+	var d nc.Decimal64 = Td_optics_gain(0)
+	if d.FractionDigits() != 3 || Td_tiny(0).FractionDigits() != 18 {
+		t.Errorf("FractionDigits() = %d", d.FractionDigits())
+	}
+}
+
+// A reply of get-config decodes into the Device made of the nodes at the
+// top level of all the modules
+func TestDevice(t *testing.T) {
+	reply := `<data xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">` +
+		`<system xmlns="urn:tbase"><hostname>r1</hostname></system>` +
+		`<banner xmlns="urn:tdev">hi</banner>` +
+		`<clock xmlns="urn:tdev"><tz>UTC</tz></clock>` +
+		`<host xmlns="urn:tdev"><name>a</name><addr>10.0.0.1</addr></host>` +
+		`<host xmlns="urn:tdev"><name>b</name></host>` +
+		`<user xmlns="urn:tkeys"><name>bob</name></user>` +
+		`</data>`
+	var d Device
+	if err := nc.Unmarshal([]byte(reply), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Tb_system.Hostname != "r1" || d.Tv_banner != "hi" || d.Tv_clock.Tz != "UTC" {
+		t.Errorf("Unmarshal() = %+v", d)
+	}
+	if len(d.Tv_host) != 2 || d.Tv_host[0].Addr != "10.0.0.1" || d.Tv_host[1].Name != "b" {
+		t.Errorf("hosts %+v", d.Tv_host)
+	}
+	if len(d.Tk_user) != 1 || d.Tk_user[0].Name != "bob" {
+		t.Errorf("users %+v", d.Tk_user)
+	}
+	b, err := nc.Marshal(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Device
+	if err := nc.Unmarshal(b, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d, again) {
+		t.Errorf("Marshal() = %s doesn't decode as %s", b, reply)
+	}
+}
+
+func TestPathBuilders(t *testing.T) {
+	p := Root().Tv_host("a").Addr()
+	if got := p.String(); got != "/tdev:host[name='a']/addr" {
+		t.Errorf("String() = %s", got)
+	}
+	if got := p.Restconf(); got != "/tdev:host=a/addr" {
+		t.Errorf("Restconf() = %s", got)
+	}
+	xp, prefixes := p.XPath()
+	if xp != "/tv:host[tv:name='a']/tv:addr" || prefixes["tv"] != "urn:tdev" {
+		t.Errorf("XPath() = %s, %v", xp, prefixes)
+	}
+	g := p.Gnmi()
+	if len(g) != 2 || g[0].Name != "tdev:host" || g[0].Key["name"] != "a" || g[1].Name != "addr" {
+		t.Errorf("Gnmi() = %+v", g)
+	}
+	f, err := Root().Tv_host("a").Filter()
+	if err != nil || string(f) != `<host xmlns="urn:tdev"><name>a</name></host>` {
+		t.Errorf("Filter() = %s, %v", f, err)
+	}
+	if got := Root().Tv_host_any().String(); got != "/tdev:host" {
+		t.Errorf("String() of all the hosts = %s", got)
+	}
+	if got := Root().Tb_system().Hostname().String(); got != "/tbase:system/hostname" {
+		t.Errorf("String() = %s", got)
+	}
+	if got := Root().Tk_servers().Server(Tk_servers_server_bin("ab")).Name().String(); got != "/tkeys:servers/server[bin='YWI=']/name" {
+		t.Errorf("String() of a binary key = %s", got)
+	}
+	if got := Root().Tv_clock().Tz().Restconf(); got != "/tdev:clock/tz" {
+		t.Errorf("Restconf() = %s", got)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"io"
+	"log"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -38,7 +40,7 @@ func getTypeName(m *yang.Module, t *yang.Type) string {
 	p := t.ParentNode()
 	switch t.Name {
 	case "uint8", "uint16", "uint32", "uint64", "int8", "int16", "int32", "int64", "string":
-		if t.Range == nil && t.ParentNode().NName() != "union" {
+		if !isConstrained(t) && t.ParentNode().NName() != "union" {
 			return t.Name
 		} else {
 			if p.NName() == "union" {
@@ -48,17 +50,12 @@ func getTypeName(m *yang.Module, t *yang.Type) string {
 				return genTN(m, fullName(p))
 			}
 		}
-	case "decimal8", "decimal16", "decimal32", "decimal64":
-		if t.ParentNode().NName() != "union" {
-			return "float64"
-		} else {
-			if p.NName() == "union" {
-				id := getIndex(t)
-				return genTN(m, fullName(p)) + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
-			} else {
-				return genTN(m, fullName(p))
-			}
+	case "decimal64":
+		if p.NName() == "union" {
+			id := getIndex(t)
+			return genTN(m, fullName(p)) + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
 		}
+		return genTN(m, fullName(p))
 	case "leafref":
 		ref := getLeafref(t.Path.Name, m, p)
 		if ref == nil {
@@ -74,6 +71,12 @@ func getTypeName(m *yang.Module, t *yang.Type) string {
 		return "bool"
 	case "enumeration", "union":
 		return genTN(m, fullName(p))
+	case "bits":
+		if p.NName() == "union" {
+			id := getIndex(t)
+			return genTN(m, fullName(p)) + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
+		}
+		return genTN(m, fullName(p))
 	case "binary":
 		if p.NName() != "union" {
 			return genTN(m, fullName(p))
@@ -94,6 +97,16 @@ func getTypeName(m *yang.Module, t *yang.Type) string {
 	}
 }
 
+// The integer types are constrained by range and the strings by length
+// and patterns. A constrained type used directly in a leaf gets a type
+// of its own.
+func isConstrained(t *yang.Type) bool {
+	if t.Name == "string" {
+		return t.Length != nil || len(t.Pattern) > 0
+	}
+	return t.Range != nil
+}
+
 // This function is responsible for generation of golang type defintions
 // for all inclusions of "type". The generation of golang type name
 // is handled above.
@@ -116,7 +129,7 @@ func processType(w io.Writer, m *yang.Module, n yang.Node) {
 		processIntType(w, m, t)
 	case "uint8", "uint16", "uint32", "uint64":
 		processUintType(w, m, t)
-	case "decimal8", "decimal16", "decimal32", "decimal64":
+	case "decimal64":
 		processDecimalType(w, m, t)
 	case "boolean":
 		processBoolType(w, m, t)
@@ -199,18 +212,140 @@ func processBoolType(w io.Writer, m *yang.Module, t *yang.Type) {
 	fmt.Fprintf(w, "}\n")
 }
 
+// This function generates golang code for yang bits. The bits translate
+// to a uint64 where each bit is a constant with the bit at its position
+// set. The text form is the names of the bits that are set, separated by
+// spaces and in the order of their positions.
 func processBitsType(w io.Writer, m *yang.Module, t *yang.Type) {
 	p := t.ParentNode()
-	if p.Kind() != "typedef" && p.Kind() != "type" {
+	if p.Kind() != "typedef" && p.Kind() != "type" && p.Kind() != "leaf" && p.Kind() != "leaf-list" {
 		return
 	}
 
 	// Generate the type name as this is part of a typedef and requires
 	// a type definition
 	tn := genTN(m, fullName(t.ParentNode()))
+	if p.Kind() == "type" && p.NName() == "union" {
+		id := getIndex(t)
+		tn = tn + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
+	}
 
 	// We now have everything to be able to generate the code
 	fmt.Fprintf(w, "type %s uint64\n", tn)
+
+	// Generate the constants for the bits. The positions are the ones
+	// declared in the yang specification or assigned as per the rules
+	// of the specification
+	positions := getBitPositions(t)
+	fmt.Fprintf(w, "const (\n")
+	for i, bit := range t.Bit {
+		fmt.Fprintf(w, "\t%s_%s %s = 1 << %d\n", tn, genFN(bit.Name), tn, positions[i])
+	}
+	fmt.Fprintf(w, ")\n")
+
+	// Generate the mappings between the bits and their names
+	fmt.Fprintf(w, "var %s_to_string = map[%s]string {\n", tn, tn)
+	for _, bit := range t.Bit {
+		fmt.Fprintf(w, "\t%s_%s: \"%s\",\n", tn, genFN(bit.Name), bit.Name)
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "var string_to_%s = map[string]%s {\n", tn, tn)
+	for _, bit := range t.Bit {
+		fmt.Fprintf(w, "\t\"%s\": %s_%s,\n", bit.Name, tn, genFN(bit.Name))
+	}
+	fmt.Fprintf(w, "}\n")
+
+	// The bits in the order of their positions which is the order in which
+	// they are encoded
+	order := make([]int, len(t.Bit))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return positions[order[i]] < positions[order[j]] })
+	fmt.Fprintf(w, "var %s_order = []%s {\n", tn, tn)
+	for _, i := range order {
+		fmt.Fprintf(w, "\t%s_%s,\n", tn, genFN(t.Bit[i].Name))
+	}
+	fmt.Fprintf(w, "}\n")
+
+	// Generate Marshal code
+	fmt.Fprintf(w, "func (x %s)MarshalText(ns string) ([]byte, error) {\n", tn)
+	fmt.Fprintf(w, "\tvar names []string\n")
+	fmt.Fprintf(w, "\tvar all %s\n", tn)
+	fmt.Fprintf(w, "\tfor _, bit := range %s_order {\n", tn)
+	fmt.Fprintf(w, "\t\tif x&bit != 0 {\n")
+	fmt.Fprintf(w, "\t\t\tnames = append(names, %s_to_string[bit])\n", tn)
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\tall |= bit\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\tif x&^all != 0 {\n")
+	fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid value %%#x for %s\", uint64(x))\n", tn)
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn []byte(strings.Join(names, \" \")), nil\n")
+	fmt.Fprintf(w, "}\n")
+
+	// Generate Unmarshal code
+	fmt.Fprintf(w, "func (x *%s)UnmarshalText(ns string, b []byte) error {\n", tn)
+	fmt.Fprintf(w, "\tvar v %s\n", tn)
+	fmt.Fprintf(w, "\tfor _, name := range strings.Fields(string(b)) {\n")
+	fmt.Fprintf(w, "\t\tbit, ok := string_to_%s[name]\n", tn)
+	fmt.Fprintf(w, "\t\tif !ok {\n")
+	fmt.Fprintf(w, "\t\t\treturn fmt.Errorf(\"Invalid bit %%s for %s\", name)\n", tn)
+	fmt.Fprintf(w, "\t\t}\n")
+	fmt.Fprintf(w, "\t\tv |= bit\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\t*x = v\n")
+	fmt.Fprintf(w, "\treturn nil\n")
+	fmt.Fprintf(w, "}\n")
+
+	// Generate the operations on the bits
+	fmt.Fprintf(w, "func (x *%s) Set(bits %s) {\n", tn, tn)
+	fmt.Fprintf(w, "\t*x |= bits\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "func (x *%s) Clear(bits %s) {\n", tn, tn)
+	fmt.Fprintf(w, "\t*x &^= bits\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "func (x %s) Has(bits %s) bool {\n", tn, tn)
+	fmt.Fprintf(w, "\treturn x&bits == bits\n")
+	fmt.Fprintf(w, "}\n")
+	// The binary encodings carry the bits as a bitmap
+	fmt.Fprintf(w, "func (x %s) Bits() uint64 {\n", tn)
+	fmt.Fprintf(w, "\treturn uint64(x)\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+// Compute the positions of the bits. A bit may carry a position statement.
+// If it doesn't, RFC 7950 section 9.7.4.2 assigns a position one greater
+// than the highest position assigned so far and zero to the first bit.
+// The generated type is 64 bits wide and higher positions are reported.
+func getBitPositions(t *yang.Type) []int64 {
+	var positions []int64
+	var next int64 = 0
+	used := map[int64]string{}
+	for _, bit := range t.Bit {
+		pos := next
+		if bit.Position != nil {
+			x, err := strconv.ParseInt(bit.Position.Name, 10, 64)
+			if err != nil || x < 0 {
+				errorlog("getBitPositions(): invalid position %s for bit %s", bit.Position.Name, bit.Name)
+			} else {
+				pos = x
+			}
+		}
+		// The bits are held in a uint64 and a bit beyond can't be encoded
+		if pos > 63 {
+			log.Fatalf("getBitPositions(): position %d of bit %s is beyond 63", pos, bit.Name)
+		}
+		if prev, ok := used[pos]; ok {
+			errorlog("getBitPositions(): bit %s has the same position %d as %s", bit.Name, pos, prev)
+		}
+		used[pos] = bit.Name
+		if pos >= next {
+			next = pos + 1
+		}
+		positions = append(positions, pos)
+	}
+	return positions
 }
 
 func processUintType(w io.Writer, m *yang.Module, t *yang.Type) {
@@ -223,13 +358,12 @@ func processUintType(w io.Writer, m *yang.Module, t *yang.Type) {
 		return
 	}
 
-	// var min, max string
-	var parts []string
 	typestr := t.Name
 	sizestr := strings.ReplaceAll(typestr, "uint", "")
 	size, err := strconv.ParseUint(sizestr, 10, 8)
 	if err != nil {
-		panic("Invalid size for uint: " + sizestr)
+		errorlog("processUintType(): Invalid size for uint: %s", sizestr)
+		return
 	}
 	tn := genTN(m, fullName(p))
 	// If this is part of union, to make it unique, we need
@@ -239,29 +373,23 @@ func processUintType(w io.Writer, m *yang.Module, t *yang.Type) {
 		tn = tn + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
 	}
 
-	// We now have everything to be able to generate the code
-	fmt.Fprintf(w, "type %s uint%s\n", tn, sizestr)
-
 	// Work out the constraints first
+	var parts []rangePart
 	if t.Range != nil {
-		rangestr := t.Range.Name
-		parts = strings.Split(rangestr, "..")
-		if len(parts) != 2 {
-			errorlog("processUintType(): Range without two values: %s in %s.%s", t.Range.Name, t.NName(), t.Kind())
-			return
+		parts, err = parseRange(t.Range.Name, "uint")
+		if err != nil {
+			errorlog("processUintType(): Invalid range in %s.%s: %s", t.NName(), t.Kind(), err.Error())
 		}
 	}
 
+	// We now have everything to be able to generate the code
+	fmt.Fprintf(w, "type %s uint%s\n", tn, sizestr)
+
 	// Generate the marshal code. For this, we need to work with
 	// any constraints in the form of range
 	fmt.Fprintf(w, "func (x %s)MarshalText(ns string) ([]byte, error) {\n", tn)
-	if t.Range != nil && parts[0] != "min" {
-		fmt.Fprintf(w, "\tif x < %s {\n", parts[0])
-		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid value %%d for %s\", x)\n", tn)
-		fmt.Fprintf(w, "\t}\n")
-	}
-	if t.Range != nil && parts[1] != "max" {
-		fmt.Fprintf(w, "\tif x > %s {\n", parts[1])
+	if cond := rangeViolation("x", parts); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
 		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid value %%d for %s\", x)\n", tn)
 		fmt.Fprintf(w, "\t}\n")
 	}
@@ -274,13 +402,8 @@ func processUintType(w io.Writer, m *yang.Module, t *yang.Type) {
 	fmt.Fprintf(w, "\tif err != nil {\n")
 	fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", string(b))\n", tn)
 	fmt.Fprintf(w, "\t}\n")
-	if t.Range != nil && parts[0] != "min" {
-		fmt.Fprintf(w, "\tif v < %s {\n", parts[0])
-		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", string(b))\n", tn)
-		fmt.Fprintf(w, "\t}\n")
-	}
-	if t.Range != nil && parts[1] != "max" {
-		fmt.Fprintf(w, "\tif v > %s {\n", parts[1])
+	if cond := rangeViolation("v", parts); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
 		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", string(b))\n", tn)
 		fmt.Fprintf(w, "\t}\n")
 	}
@@ -299,13 +422,12 @@ func processIntType(w io.Writer, m *yang.Module, t *yang.Type) {
 		return
 	}
 
-	// var min, max string
-	var parts []string
 	typestr := t.Name
 	sizestr := strings.ReplaceAll(typestr, "int", "")
 	size, err := strconv.ParseUint(sizestr, 10, 8)
 	if err != nil {
-		panic("Invalid size for int: " + sizestr)
+		errorlog("processIntType(): Invalid size for int: %s", sizestr)
+		return
 	}
 	tn := genTN(m, fullName(p))
 	// If this is part of union, to make it unique, we need
@@ -315,28 +437,23 @@ func processIntType(w io.Writer, m *yang.Module, t *yang.Type) {
 		tn = tn + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
 	}
 
-	// We now have everything to be able to generate the code
-	fmt.Fprintf(w, "type %s int%s\n", tn, sizestr)
-
 	// Work out the constraints first
+	var parts []rangePart
 	if t.Range != nil {
-		rangestr := t.Range.Name
-		parts = strings.Split(rangestr, "..")
-		if len(parts) != 2 {
-			panic("Range without two values: " + rangestr)
+		parts, err = parseRange(t.Range.Name, "int")
+		if err != nil {
+			errorlog("processIntType(): Invalid range in %s.%s: %s", t.NName(), t.Kind(), err.Error())
 		}
 	}
 
+	// We now have everything to be able to generate the code
+	fmt.Fprintf(w, "type %s int%s\n", tn, sizestr)
+
 	// Generate the marshal code. For this, we need to work with
 	// any constraints in the form of range
 	fmt.Fprintf(w, "func (x %s)MarshalText(ns string) ([]byte, error) {\n", tn)
-	if t.Range != nil && parts[0] != "min" {
-		fmt.Fprintf(w, "\tif x < %s {\n", parts[0])
-		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid value %%d for %s\", x)\n", tn)
-		fmt.Fprintf(w, "\t}\n")
-	}
-	if t.Range != nil && parts[1] != "max" {
-		fmt.Fprintf(w, "\tif x > %s {\n", parts[1])
+	if cond := rangeViolation("x", parts); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
 		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid value %%d for %s\", x)\n", tn)
 		fmt.Fprintf(w, "\t}\n")
 	}
@@ -349,13 +466,8 @@ func processIntType(w io.Writer, m *yang.Module, t *yang.Type) {
 	fmt.Fprintf(w, "\tif err != nil {\n")
 	fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", string(b))\n", tn)
 	fmt.Fprintf(w, "\t}\n")
-	if t.Range != nil && parts[0] != "min" {
-		fmt.Fprintf(w, "\tif v < %s {\n", parts[0])
-		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", string(b))\n", tn)
-		fmt.Fprintf(w, "\t}\n")
-	}
-	if t.Range != nil && parts[1] != "max" {
-		fmt.Fprintf(w, "\tif v > %s {\n", parts[1])
+	if cond := rangeViolation("v", parts); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
 		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", string(b))\n", tn)
 		fmt.Fprintf(w, "\t}\n")
 	}
@@ -365,20 +477,18 @@ func processIntType(w io.Writer, m *yang.Module, t *yang.Type) {
 }
 
 // Process decimal type and generate the needed type definitions and marshal
-// related code.
+// related code. A decimal64 is a fixed point number and is represented by
+// the value scaled by 10 to the power of fraction-digits. The value 12.34
+// with two fraction digits is stored as 1234. This keeps the values exact
+// as they are formatted and parsed without the use of floating point.
 func processDecimalType(w io.Writer, m *yang.Module, t *yang.Type) {
-	// Check if we need to generate any type definition. We use the
-	// golang built-in types where possible. For this type, we need
-	// a special type only if the type has additional constraints
-	// If not, we can just leave from here
+	// Every decimal64 needs a type definition as the fraction digits
+	// are specific to the type.
 	p := t.ParentNode()
-	if p.Kind() != "typedef" && p.Kind() != "type" {
+	if p.Kind() != "typedef" && p.Kind() != "type" && p.Kind() != "leaf" && p.Kind() != "leaf-list" {
 		return
 	}
 
-	// var min, max string
-	typestr := t.Name
-	sizestr := strings.ReplaceAll(typestr, "decimal", "")
 	tn := genTN(m, fullName(t.ParentNode()))
 	// If this is part of union, to make it unique, we need
 	// to add the type name to the full name
@@ -387,28 +497,77 @@ func processDecimalType(w io.Writer, m *yang.Module, t *yang.Type) {
 		tn = tn + "_" + t.Name + "_" + strconv.FormatInt(int64(id), 10)
 	}
 
-	// We now have everything to be able to generate the code
-	fmt.Fprintf(w, "type %s float%s\n", tn, sizestr)
-
-	// Work out the constraints first
-	if t.FractionDigits != nil {
-		fmt.Fprintf(w, "/* TODO: support for fraction digits */\n")
+	// Work out the constraints first. The fraction digits are mandatory
+	// for decimal64 and are between 1 and 18
+	if t.FractionDigits == nil {
+		errorlog("processDecimalType(): fraction-digits missing for %s", tn)
+		return
+	}
+	fd, err := strconv.Atoi(t.FractionDigits.Name)
+	if err != nil || fd < 1 || fd > 18 {
+		errorlog("processDecimalType(): Invalid fraction-digits %s for %s", t.FractionDigits.Name, tn)
+		return
+	}
+	var parts []rangePart
+	if t.Range != nil {
+		parts, err = parseRange(t.Range.Name, "decimal")
+		if err == nil {
+			parts, err = scaleRange(parts, fd)
+		}
+		if err != nil {
+			errorlog("processDecimalType(): Invalid range in %s: %s", tn, err.Error())
+			parts = nil
+		}
 	}
+	scale := "1" + strings.Repeat("0", fd)
 
-	// Marshal code. Currently, the precision in printing is set to -1 (any).
-	// This should be improved by using the fraction digits learnt above
+	// We now have everything to be able to generate the code
+	fmt.Fprintf(w, "type %s int64\n", tn)
+	fmt.Fprintf(w, "const %s_fraction_digits = %d\n", tn, fd)
+	fmt.Fprintf(w, "func (x %s) FractionDigits() int {\n", tn)
+	fmt.Fprintf(w, "\treturn %s_fraction_digits\n", tn)
+	fmt.Fprintf(w, "}\n")
+
+	// Marshal code. The value is formatted with exactly as many digits
+	// after the decimal point as specified by fraction-digits
 	fmt.Fprintf(w, "func (x %s)MarshalText(ns string) ([]byte, error) {\n", tn)
-	fmt.Fprintf(w, "\ts := strconv.FormatFloat(float64(x), 'f', -1, %s)\n", sizestr)
+	if cond := rangeViolation("x", parts); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
+		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid value %%d for %s\", x)\n", tn)
+		fmt.Fprintf(w, "\t}\n")
+	}
+	fmt.Fprintf(w, "\tsign := \"\"\n")
+	fmt.Fprintf(w, "\tv := uint64(x)\n")
+	fmt.Fprintf(w, "\tif x < 0 {\n")
+	fmt.Fprintf(w, "\t\tsign = \"-\"\n")
+	fmt.Fprintf(w, "\t\tv = uint64(-x)\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\ts := fmt.Sprintf(\"%%s%%d.%%0%dd\", sign, v/%s, v%%%s)\n", fd, scale, scale)
 	fmt.Fprintf(w, "\treturn []byte(s), nil\n")
 	fmt.Fprintf(w, "}\n")
 
-	// Unmarshal code.
+	// Unmarshal code. The digits before and after the decimal point are
+	// combined into the scaled integer so that no rounding takes place
 	fmt.Fprintf(w, "func (x *%s)UnmarshalText(ns string, b []byte) error {\n", tn)
-	fmt.Fprintf(w, "\tf, err := strconv.ParseFloat(string(b), %s)\n", sizestr)
+	fmt.Fprintf(w, "\ts := string(b)\n")
+	fmt.Fprintf(w, "\tip, fp, dot := strings.Cut(s, \".\")\n")
+	fmt.Fprintf(w, "\tdigits := strings.TrimLeft(ip, \"+-\")\n")
+	fmt.Fprintf(w, "\tif digits == \"\" || len(ip)-len(digits) > 1 || (dot && fp == \"\") || len(fp) > %d {\n", fd)
+	fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", s)\n", tn)
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\tif strings.ContainsAny(fp, \"+-\") {\n")
+	fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", s)\n", tn)
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\tv, err := strconv.ParseInt(ip+fp+strings.Repeat(\"0\", %d-len(fp)), 10, 64)\n", fd)
 	fmt.Fprintf(w, "\tif err != nil {\n")
-	fmt.Fprintf(w, "\t\treturn err\n")
+	fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", s)\n", tn)
 	fmt.Fprintf(w, "\t}\n")
-	fmt.Fprintf(w, "\t*x = %s(f)\n", tn)
+	if cond := rangeViolation("v", parts); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
+		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid value %%s for %s\", s)\n", tn)
+		fmt.Fprintf(w, "\t}\n")
+	}
+	fmt.Fprintf(w, "\t*x = %s(v)\n", tn)
 	fmt.Fprintf(w, "\treturn nil\n")
 	fmt.Fprintf(w, "}\n")
 }
@@ -433,15 +592,12 @@ func processStringType(w io.Writer, m *yang.Module, t *yang.Type) {
 	// a special type only if the type has additional constraints
 	// If not, we can just leave from here
 	p := t.ParentNode()
-	if t.Length == nil && p.Kind() != "typedef" && p.Kind() != "type" {
+	if !isConstrained(t) && p.Kind() != "typedef" && p.Kind() != "type" {
 		return
 	}
 
 	// variables to manage constraints
-	var len_constraint bool
-	var min, max string
-	var pattern_constraint bool
-	var pattern string
+	var lengths []rangePart
 
 	// First generate the type definition
 	tn := genTN(m, fullName(t.ParentNode()))
@@ -455,45 +611,46 @@ func processStringType(w io.Writer, m *yang.Module, t *yang.Type) {
 	fmt.Fprintf(w, "type %s string\n", tn)
 
 	// Handle constraints. The strings may need to match some
-	// pattern or may be constrained to length
+	// pattern or may be constrained to length. The length of
+	// a string is counted in characters and not in bytes
 	if t.Length != nil {
-		len_constraint = true
-		parts := strings.Split(t.Length.Name, "..")
-		if len(parts) == 1 {
-			min = parts[0]
-			max = min
-		} else if len(parts) == 2 {
-			min = parts[0]
-			max = parts[1]
+		var err error
+		lengths, err = parseRange(t.Length.Name, "uint")
+		if err != nil {
+			errorlog("processStringType(): Invalid length in %s.%s: %s", t.NName(), t.Kind(), err.Error())
 		}
 	}
-	if len(t.Pattern) > 0 {
-		pattern_constraint = true
-		pattern = t.Pattern[0].Name
-		pattern = strings.ReplaceAll(pattern, "\\", "\\\\")
+
+	// Each pattern is translated into a regular expression of its own as
+	// the value must match all of them. A pattern with the modifier
+	// invert-match must not match.
+	var patterns []string
+	var inverts []bool
+	for _, pat := range t.Pattern {
+		// The check of a pattern can't be left out silently as the values
+		// it rejects would then be accepted
+		re, err := xsdToRE2(pat.Name)
+		if err != nil {
+			log.Fatalf("processStringType(): pattern '%s' of %s can't be translated: %s", pat.Name, tn, err.Error())
+		}
+		patterns = append(patterns, re)
+		inverts = append(inverts, pat.Modifier != nil && pat.Modifier.Name == "invert-match")
 	}
 
 	// Generate regular expression initialization
-	if pattern_constraint {
-		fmt.Fprintf(w, "var %s_re = regexp.MustCompile(\"%s\")\n", tn, pattern)
+	for i, re := range patterns {
+		fmt.Fprintf(w, "var %s_re_%d = regexp.MustCompile(%q)\n", tn, i, re)
 	}
 
 	// Generate MarshalText() that takes into consideration the constraints
 	fmt.Fprintf(w, "func (x %s)MarshalText(ns string) ([]byte, error) {\n", tn)
-	if len_constraint {
-		if min != "" && min != "min" {
-			fmt.Fprintf(w, "\tif len(x) < %s {\n", min)
-			fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid length %%d for %s\", len(x))\n", tn)
-			fmt.Fprintf(w, "\t}\n")
-		}
-		if max != "" && max != "max" {
-			fmt.Fprintf(w, "\tif len(x) > %s {\n", max)
-			fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid length %%d for %s\", len(x))\n", tn)
-			fmt.Fprintf(w, "\t}\n")
-		}
+	if cond := rangeViolation("len([]rune(x))", lengths); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
+		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid length %%d for %s\", len([]rune(x)))\n", tn)
+		fmt.Fprintf(w, "\t}\n")
 	}
-	if pattern_constraint {
-		fmt.Fprintf(w, "\tif !%s_re.MatchString(string(x)) {\n", tn)
+	for i := range patterns {
+		fmt.Fprintf(w, "\tif %s%s_re_%d.MatchString(string(x)) {\n", patternNot(inverts[i]), tn, i)
 		fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"Invalid %s: %%s\", x)\n", tn)
 		fmt.Fprintf(w, "\t}\n")
 	}
@@ -503,20 +660,13 @@ func processStringType(w io.Writer, m *yang.Module, t *yang.Type) {
 	// Generate UnmarshalText() that verifies the constraints
 	fmt.Fprintf(w, "func (x *%s)UnmarshalText(ns string, b []byte) error {\n", tn)
 	fmt.Fprintf(w, "\ts := string(b)\n")
-	if len_constraint {
-		if min != "" && min != "min" {
-			fmt.Fprintf(w, "\tif len(s) < %s {\n", min)
-			fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid length %%d for %s\", len(s))\n", tn)
-			fmt.Fprintf(w, "\t}\n")
-		}
-		if max != "" && max != "max" {
-			fmt.Fprintf(w, "\tif len(s) > %s {\n", max)
-			fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid length %%d for %s\", len(s))\n", tn)
-			fmt.Fprintf(w, "\t}\n")
-		}
+	if cond := rangeViolation("len([]rune(s))", lengths); cond != "" {
+		fmt.Fprintf(w, "\tif %s {\n", cond)
+		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid length %%d for %s\", len([]rune(s)))\n", tn)
+		fmt.Fprintf(w, "\t}\n")
 	}
-	if pattern_constraint {
-		fmt.Fprintf(w, "\tif !%s_re.MatchString(s) {\n", tn)
+	for i := range patterns {
+		fmt.Fprintf(w, "\tif %s%s_re_%d.MatchString(s) {\n", patternNot(inverts[i]), tn, i)
 		fmt.Fprintf(w, "\t\treturn fmt.Errorf(\"Invalid %s: %%s\", s)\n", tn)
 		fmt.Fprintf(w, "\t}\n")
 	}
@@ -525,6 +675,15 @@ func processStringType(w io.Writer, m *yang.Module, t *yang.Type) {
 	fmt.Fprintf(w, "}\n")
 }
 
+// The check of a pattern fails when the value doesn't match. For patterns
+// with invert-match, it fails when the value matches
+func patternNot(invert bool) string {
+	if invert {
+		return ""
+	}
+	return "!"
+}
+
 // This function generates code for yang union. Union can include
 // any one of the types that are part of the union. The decoding
 // happens for each type and the first successful type is assumed
@@ -585,15 +744,14 @@ func processEnumType(w io.Writer, m *yang.Module, t *yang.Type) {
 	tname := genTN(m, fullName(t.ParentNode()))
 	fmt.Fprintf(w, "type %s int\n", tname)
 
-	// Generate the constants for the enums
+	// Generate the constants for the enums. The values are the ones
+	// declared in the yang specification or assigned as per the rules
+	// of the specification
+	values := getEnumValues(t)
 	fmt.Fprintf(w, "const (\n")
 	for i, en := range t.Enum {
 		fname := genFN(en.Name)
-		if i == 0 {
-			fmt.Fprintf(w, "\t%s_%s %s = iota\n", tname, fname, tname)
-		} else {
-			fmt.Fprintf(w, "\t%s_%s\n", tname, fname)
-		}
+		fmt.Fprintf(w, "\t%s_%s %s = %d\n", tname, fname, tname, values[i])
 	}
 	fmt.Fprintf(w, ")\n")
 
@@ -631,6 +789,38 @@ func processEnumType(w io.Writer, m *yang.Module, t *yang.Type) {
 	fmt.Fprintf(w, "}\n")
 }
 
+// Compute the values of the enums of an enumeration. An enum may carry
+// a value statement. If it doesn't, RFC 7950 section 9.6.4.2 assigns a
+// value one greater than the highest value assigned so far and zero to
+// the first enum.
+func getEnumValues(t *yang.Type) []int64 {
+	var values []int64
+	// The first value is 0 unless declared and the others follow the
+	// highest value so far
+	var next int64 = 0
+	used := map[int64]string{}
+	for i, en := range t.Enum {
+		v := next
+		if en.Value != nil {
+			x, err := strconv.ParseInt(en.Value.Name, 10, 32)
+			if err != nil {
+				errorlog("getEnumValues(): invalid value %s for enum %s", en.Value.Name, en.Name)
+			} else {
+				v = x
+			}
+		}
+		if prev, ok := used[v]; ok {
+			errorlog("getEnumValues(): enum %s has the same value %d as %s", en.Name, v, prev)
+		}
+		used[v] = en.Name
+		if i == 0 || v >= next {
+			next = v + 1
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
 // Process leafref where a path is used to parse the tree to obtain the type
 // to be used
 func processLeafref(w io.Writer, m *yang.Module, t *yang.Type) {
@@ -178,9 +178,11 @@ func getModulePrefix(m *yang.Module) string {
 	return ""
 }
 
-// 
+// Generates the name of the node by prefixing the names of all the
+// ancestors up to the module. The input and output of an rpc have no
+// name and are represented by their kind instead.
 func fullName(n yang.Node) (fn string) {
-	fn = n.NName()
+	fn = nodeName(n)
 	for (n.ParentNode() != nil) && 
 	    ((n.ParentNode().Kind() != "module") &&
 	     (n.ParentNode().Kind() != "submodule")) {
@@ -195,11 +197,20 @@ func fullName(n yang.Node) (fn string) {
 		} else {
 			n = n.ParentNode()
 		}
-		fn = n.NName() + "_" + fn
+		fn = nodeName(n) + "_" + fn
 	}
 	return
 }
 
+// The name of a node as used in generated names. Statements such as input
+// and output carry no argument and thus their kind is used as the name
+func nodeName(n yang.Node) string {
+	if n.NName() == "" {
+		return n.Kind()
+	}
+	return n.NName()
+}
+
 // Generates a suitable type name to be used. If the type is
 // a built-in, it translates the type to a golang equivalent.
 // For other types, it ensures that right prefix is placed to
@@ -476,6 +487,10 @@ func getNextNodeByName(node yang.Node, name string, leaf bool) yang.Node {
 		return getNodeFromCase(node.(*yang.Case), name, false)
 	case "notification":
 		return getNodeFromNotification(node.(*yang.Notification), name, false)
+	case "input":
+		return getNodeFromInput(node.(*yang.Input), name, false)
+	case "output":
+		return getNodeFromOutput(node.(*yang.Output), name, false)
 	case "module", "submodule":
 		return getNodeFromMod(mod, name)
 	}
@@ -583,6 +598,11 @@ func getMatchingUsesNodeFromMod(mod *Module, name string) yang.Node {
 				return node
 			}
 		}
+		for _, r := range sm.module.RPC {
+			if node := getMatchingUsesNodeFromRpc(r, name); node != nil {
+				return node
+			}
+		}
 	}
 	return nil
 }
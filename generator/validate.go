@@ -0,0 +1,435 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// The checks of the constraints mandatory, min-elements, max-elements and
+// unique are collected as the fields of a structure are generated. They
+// are emitted as the method validate() of the structure which reports
+// every violation with the instance path of the node. The validate() of
+// a structure calls those of the structures of the nodes present within
+// it. The containers and the lists export the checks through Validate().
+//
+// The must and when statements and the paths of the leafrefs are XPath
+// expressions that are evaluated by the runtime. They are collected as a
+// table of constraints for the fields of the structure which is
+// registered with the runtime and checked by Validate().
+//
+// The default values are collected along, see defaults.go.
+type validator struct {
+	ymod          *yang.Module
+	prev          yang.Node
+	addNs         bool
+	fields        map[string]bool
+	lines         []string
+	constraints   []string
+	defaults      []string
+	defaultCase   []string // the defaults of the default case of a choice
+	present       []string // the presence of the nodes of a choice
+	noDefaults    bool
+	defaultValues []string
+}
+
+func newValidator(ymod *yang.Module, prev yang.Node, addNs bool) *validator {
+	return &validator{ymod: ymod, prev: prev, addNs: addNs, fields: map[string]bool{}}
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.lines = append(v.lines, fmt.Sprintf(format, args...))
+}
+
+// Generate the field for a node and collect its checks. The checks are
+// skipped when the field isn't generated.
+func (v *validator) generateField(w io.Writer, node yang.Node) {
+	if !generateField(w, v.ymod, node, v.prev, v.addNs) {
+		return
+	}
+	v.fields[genFN(node.NName())] = true
+	if node.Kind() == "uses" {
+		tn := genTN(getMyYangModule(v.prev), node.NName())
+		v.addUses(tn)
+		v.addConstraints(node, tn)
+		v.addDefaultsUses(tn)
+		return
+	}
+	v.addNode(node, genFN(node.NName()), v.pathName(node))
+	v.addDefaults(node, genFN(node.NName()))
+}
+
+// The name of a node in the instance path. The name is qualified by the
+// name of the module when the module changes as for augments.
+func (v *validator) pathName(node yang.Node) string {
+	mod := getMyModule(node)
+	if mod != nil && (v.addNs || mod != getMyModule(v.prev)) {
+		return mod.name + ":" + node.NName()
+	}
+	return node.NName()
+}
+
+func (v *validator) addUses(tn string) {
+	v.add("x.%s.validate(path, errs)\n", tn)
+}
+
+// Collect the checks of a node whose field is named fn. The checks of a
+// node that is not present are skipped except for mandatory and
+// min-elements.
+func (v *validator) addNode(node yang.Node, fn string, name string) {
+	v.addConstraints(node, fn)
+	switch n := node.(type) {
+	case *yang.Container:
+		v.add("if x.%s_Prsnt {\n", fn)
+		v.add("\tx.%s.validate(path+\"/%s\", errs)\n", fn, name)
+		v.add("}\n")
+	case *yang.Leaf:
+		if isTrue(n.Mandatory) {
+			v.add("if !x.%s_Prsnt {\n", fn)
+			v.add("\terrs.Add(path+\"/%s\", \"missing-leaf\", \"mandatory leaf is missing\")\n", name)
+			v.add("}\n")
+		}
+	case *yang.LeafList:
+		v.addElements(fn, name, n.MinElements, n.MaxElements)
+	case *yang.List:
+		v.addElements(fn, name, n.MinElements, n.MaxElements)
+		for _, u := range n.Unique {
+			v.addUnique(n, fn, name, u.Name)
+		}
+		v.add("for i := range x.%s {\n", fn)
+		v.add("\tx.%s[i].validate(nc.EntryPath(path+\"/%s\", &x.%s[i], i), errs)\n", fn, name, fn)
+		v.add("}\n")
+	case *yang.Choice:
+		if isTrue(n.Mandatory) {
+			v.add("if !x.%s_Prsnt {\n", fn)
+			v.add("\terrs.Add(path, \"missing-choice\", \"mandatory choice %s is missing\")\n", n.NName())
+			v.add("}\n")
+		}
+		v.add("if x.%s_Prsnt {\n", fn)
+		v.add("\tx.%s.validate(path, errs)\n", fn)
+		v.add("}\n")
+	case *yang.Case:
+		v.add("if x.%s_Prsnt {\n", fn)
+		v.add("\tx.%s.validate(path, errs)\n", fn)
+		v.add("}\n")
+	}
+}
+
+// The key leaves of an entry must be present as the mandatory leaves
+func (v *validator) addKeys(list *yang.List) {
+	for _, k := range getListKeys(list) {
+		v.add("if !x.%s_Prsnt {\n", genFN(k.NName()))
+		v.add("\terrs.Add(path+\"/%s\", \"missing-leaf\", \"key leaf is missing\")\n", v.pathName(k))
+		v.add("}\n")
+	}
+}
+
+func isTrue(v *yang.Value) bool {
+	return v != nil && v.Name == "true"
+}
+
+// The number of elements of a list or a leaf-list
+func (v *validator) addElements(fn string, name string, min *yang.Value, max *yang.Value) {
+	if min != nil {
+		n, err := strconv.ParseUint(min.Name, 10, 32)
+		if err != nil {
+			errorlog("addElements(): invalid min-elements %s for %s", min.Name, name)
+		} else if n > 0 {
+			v.add("if len(x.%s) < %d {\n", fn, n)
+			v.add("\terrs.Add(path+\"/%s\", \"too-few-elements\", \"fewer than %d elements\")\n", name, n)
+			v.add("}\n")
+		}
+	}
+	if max != nil && max.Name != "unbounded" {
+		n, err := strconv.ParseUint(max.Name, 10, 32)
+		if err != nil {
+			errorlog("addElements(): invalid max-elements %s for %s", max.Name, name)
+		} else {
+			v.add("if len(x.%s) > %d {\n", fn, n)
+			v.add("\terrs.Add(path+\"/%s\", \"too-many-elements\", \"more than %d elements\")\n", name, n)
+			v.add("}\n")
+		}
+	}
+}
+
+// The combined values of the leaves of a unique statement must differ
+// between the entries of the list in which all of them are present. The
+// leaves are descendants of the list through containers.
+func (v *validator) addUnique(list *yang.List, fn string, name string, unique string) {
+	var conds, values []string
+	for _, desc := range strings.Fields(unique) {
+		expr, cond, ok := uniqueLeafExpr(list, desc)
+		if !ok {
+			errorlog("addUnique(): unsupported unique %s in %s", unique, list.NName())
+			return
+		}
+		conds = append(conds, cond)
+		values = append(values, expr)
+	}
+	v.add("{\n")
+	v.add("\tseen := map[string]bool{}\n")
+	v.add("\tfor i := range x.%s {\n", fn)
+	v.add("\t\te := &x.%s[i]\n", fn)
+	v.add("\t\tif !(%s) {\n", strings.Join(conds, " && "))
+	v.add("\t\t\tcontinue\n")
+	v.add("\t\t}\n")
+	v.add("\t\tk := nc.UniqueKey(%s)\n", strings.Join(values, ", "))
+	v.add("\t\tif seen[k] {\n")
+	v.add("\t\t\terrs.Add(nc.EntryPath(path+\"/%s\", e, i), \"data-not-unique\", \"unique %s is violated\")\n", name, unique)
+	v.add("\t\t}\n")
+	v.add("\t\tseen[k] = true\n")
+	v.add("\t}\n")
+	v.add("}\n")
+}
+
+// The expression for the value of a descendant leaf of an entry and the
+// condition for its presence
+func uniqueLeafExpr(list *yang.List, desc string) (string, string, bool) {
+	var conds []string
+	expr := "e"
+	var node yang.Node = list
+	for _, part := range strings.Split(desc, "/") {
+		var child yang.Node
+		switch n := node.(type) {
+		case *yang.List:
+			child = getNodeFromList(n, getName(part), true)
+		case *yang.Container:
+			child = getNodeFromContainer(n, getName(part), true)
+		}
+		if child == nil || (child.Kind() != "container" && child.Kind() != "leaf") {
+			return "", "", false
+		}
+		expr = expr + "." + genFN(child.NName())
+		conds = append(conds, expr+"_Prsnt")
+		node = child
+	}
+	if node.Kind() != "leaf" {
+		return "", "", false
+	}
+	return expr, strings.Join(conds, " && "), true
+}
+
+// Collect the XPath expressions of a node whose field is named fn. The
+// expressions are evaluated with the prefixes of the module in which they
+// are written. The when of an augment applies to each node it adds.
+func (v *validator) addConstraints(node yang.Node, fn string) {
+	var musts []*yang.Must
+	var when *yang.Value
+	var typ *yang.Type
+	switch n := node.(type) {
+	case *yang.Container:
+		musts, when = n.Must, n.When
+	case *yang.Leaf:
+		musts, when, typ = n.Must, n.When, n.Type
+	case *yang.LeafList:
+		musts, when, typ = n.Must, n.When, n.Type
+	case *yang.List:
+		musts, when = n.Must, n.When
+	case *yang.Choice:
+		when = n.When
+	case *yang.Case:
+		when = n.When
+	case *yang.Uses:
+		when = n.When
+	}
+	prefixes := xpathPrefixesName(getMyYangModule(node))
+	for _, m := range musts {
+		s := fmt.Sprintf("Field: %q, Kind: nc.Must, Expr: %q, Prefixes: %s", fn, m.Name, prefixes)
+		if m.ErrorMessage != nil {
+			s += fmt.Sprintf(", Message: %q", m.ErrorMessage.Name)
+		}
+		if m.ErrorAppTag != nil {
+			s += fmt.Sprintf(", AppTag: %q", m.ErrorAppTag.Name)
+		}
+		v.addConstraint("%s", s)
+	}
+	if when != nil {
+		v.addConstraint("Field: %q, Kind: nc.When, Expr: %q, Prefixes: %s", fn, when.Name, prefixes)
+	}
+	if typ != nil {
+		v.addLeafref(getMyYangModule(node), typ, fn)
+	}
+	if aug, ok := node.ParentNode().(*yang.Augment); ok && aug.When != nil {
+		v.addConstraint("Field: %q, Kind: nc.When, Parent: true, Expr: %q, Prefixes: %s",
+			fn, aug.When.Name, xpathPrefixesName(getMyYangModule(aug)))
+	}
+}
+
+// The path of a leafref, which may come from a typedef, is used by deref()
+// and, unless require-instance is false, must select a node with the
+// value of the leaf
+func (v *validator) addLeafref(ymod *yang.Module, typ *yang.Type, fn string) {
+	ymod, typ = resolveLeafref(ymod, typ)
+	if typ == nil || typ.Path == nil {
+		return
+	}
+	s := fmt.Sprintf("Field: %q, Kind: nc.Leafref, Expr: %q, Prefixes: %s", fn, typ.Path.Name, xpathPrefixesName(ymod))
+	if typ.RequireInstance == nil || typ.RequireInstance.Name != "false" {
+		s += ", RequireInstance: true"
+	}
+	v.addConstraint("%s", s)
+}
+
+// Follow the typedefs from a type to a leafref. The module returned is
+// the one of the leafref whose prefixes its path uses.
+func resolveLeafref(ymod *yang.Module, typ *yang.Type) (*yang.Module, *yang.Type) {
+	for depth := 0; depth < 16; depth++ {
+		if typ.Name == "leafref" {
+			return ymod, typ
+		}
+		mod, td := lookupTypedef(ymod, typ.Name)
+		if td == nil || td.Type == nil {
+			return nil, nil
+		}
+		ymod = mod
+		typ = td.Type
+	}
+	return nil, nil
+}
+
+// The typedef of a type name and the module or the submodule that defines
+// it. The prefix of the name is the one of an import of ymod.
+func lookupTypedef(ymod *yang.Module, name string) (*yang.Module, *yang.Typedef) {
+	mod := getImportedModuleByPrefix(ymod, getPrefix(name))
+	if mod == nil {
+		return nil, nil
+	}
+	for _, sm := range mod.submodules {
+		for _, t := range sm.module.Typedef {
+			if t.Name == getName(name) {
+				return sm.module, t
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (v *validator) addConstraint(format string, args ...interface{}) {
+	v.constraints = append(v.constraints, fmt.Sprintf("{"+format+"},\n", args...))
+}
+
+// The name of the map of the prefixes of a module for XPath
+func xpathPrefixesName(ymod *yang.Module) string {
+	return genFN(ymod.Name) + "_xpath_prefixes"
+}
+
+// The prefixes that the XPath expressions of a module or a submodule may
+// use are its own and those of its imports.
+func generateXPathPrefixes(w io.Writer, ymod *yang.Module) {
+	fmt.Fprintf(w, "var %s = map[string]string{\n", xpathPrefixesName(ymod))
+	if mod := getMyModule(ymod); mod != nil {
+		fmt.Fprintf(w, "\t%q: %q,\n", getYangPrefix(ymod), mod.namespace)
+	}
+	for _, i := range ymod.Import {
+		mod, ok := modulesByName[i.Name]
+		if !ok {
+			errorlog("generateXPathPrefixes(): module %s imported by %s not found", i.Name, ymod.Name)
+			continue
+		}
+		fmt.Fprintf(w, "\t%q: %q,\n", i.Prefix.Name, mod.namespace)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// Emit validate() and, when requested, Validate(). The path of the root
+// node for Validate() is passed as Go expression.
+func (v *validator) generate(w io.Writer, tn string, root string) {
+	fmt.Fprintf(w, "func (x *%s) validate(path string, errs *nc.ValidationErrors) {\n", tn)
+	for _, l := range v.lines {
+		fmt.Fprintf(w, "\t%s", l)
+	}
+	fmt.Fprintf(w, "}\n")
+	v.generateConstraints(w, tn)
+	v.generateDefaults(w, tn, root != "")
+	if root == "" {
+		return
+	}
+	// A field named Validate would conflict with the method
+	if v.fields["Validate"] {
+		errorlog("generate(): %s has a field Validate and Validate() is not generated", tn)
+		return
+	}
+	fmt.Fprintf(w, "// Validate checks the constraints of the schema on the data within\n")
+	fmt.Fprintf(w, "func (x %s) Validate() error {\n", tn)
+	fmt.Fprintf(w, "\tvar errs nc.ValidationErrors\n")
+	fmt.Fprintf(w, "\tx.validate(%s, &errs)\n", root)
+	fmt.Fprintf(w, "\tnc.CheckConstraints(&x, %s, &errs)\n", root)
+	fmt.Fprintf(w, "\treturn errs.Err()\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+// Emit the table of the constraints and its registration. Device has its
+// own init() as it is not part of a module.
+func (v *validator) generateConstraints(w io.Writer, tn string) {
+	if len(v.constraints) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "var %s_constraints = []nc.Constraint{\n", tn)
+	for _, c := range v.constraints {
+		fmt.Fprintf(w, "\t%s", c)
+	}
+	fmt.Fprintf(w, "}\n")
+	v.register(w, tn, fmt.Sprintf("nc.RegisterConstraints(%s{}, %s_constraints)\n", tn, tn))
+}
+
+// Add a registration with the runtime to the init() of the submodule
+func (v *validator) register(w io.Writer, tn string, s string) {
+	if v.ymod == nil {
+		fmt.Fprintf(w, "func init() {\n\t%s}\n", s)
+		return
+	}
+	submod := getSubModule(v.ymod.Name)
+	if submod == nil {
+		errorlog("register(): submodule %s not found for %s", v.ymod.Name, tn)
+		return
+	}
+	submod.initfunc = append(submod.initfunc, s)
+}
+
+// The path of a node from the top for Validate(). The keys of the lists
+// above the node are not known and are left out. The nodes within a
+// grouping start from the grouping. The nodes of the input or the output
+// of an rpc or an action are below the rpc or the action.
+func rootPath(node yang.Node) string {
+	var path string
+	for n := node; n != nil; {
+		switch n.Kind() {
+		case "module", "submodule", "grouping":
+			return path
+		case "augment":
+			if n = augmentTarget(n); n == nil {
+				errorlog("rootPath(): Couldn't complete for %s.%s", node.NName(), node.Kind())
+				return path
+			}
+			continue
+		case "input", "output":
+			path = "/" + n.Kind() + path
+		case "container", "list", "rpc", "action", "notification":
+			name := n.NName()
+			p := n.ParentNode()
+			for p != nil && (p.Kind() == "choice" || p.Kind() == "case" || p.Kind() == "augment") {
+				if p.Kind() == "augment" {
+					p = augmentTarget(p)
+				} else {
+					p = p.ParentNode()
+				}
+			}
+			if mod := getMyModule(n); mod != nil && (p == nil || p.Kind() == "module" ||
+				p.Kind() == "submodule" || p.Kind() == "grouping" || getMyModule(p) != mod) {
+				name = mod.name + ":" + name
+			}
+			path = "/" + name + path
+		}
+		n = n.ParentNode()
+	}
+	return path
+}
+
+// The node an augment adds its nodes to
+func augmentTarget(n yang.Node) yang.Node {
+	aug := n.(*yang.Augment)
+	return traverse(aug.Name, aug, false)
+}
@@ -0,0 +1,257 @@
+// Package client is a netconf client. A session runs over any stream, an
+// SSH channel as set up by Dial or a pipe to a local stand-in server, and
+// exchanges the generated structures with the server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"nc/nc"
+	"nc/transport"
+)
+
+// ErrClosed is returned for the rpcs on a session that has ended
+var ErrClosed = errors.New("client: session closed")
+
+// Session is a netconf session with a server. The rpcs may be issued
+// concurrently and the replies are matched to them by their message-id.
+type Session struct {
+	rw    io.ReadWriter
+	f     *transport.Framer
+	hello *transport.Hello
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[string]chan []byte
+	err     error // why the session ended
+	closing bool
+	done    chan struct{}
+
+	notifications chan *Notification
+	stop          chan struct{} // closed by Close to end the delivery
+	queue         []*Notification
+	maxQueue      int
+	dropped       uint64
+	cond          *sync.Cond
+
+	withDefaults *nc.WithDefaults
+}
+
+// DefaultCapabilities are those the client advertises when none are given
+var DefaultCapabilities = []string{transport.Base10, transport.Base11}
+
+// NewSession starts a session over rw by exchanging the hellos. The
+// client advertises the capabilities passed, the base ones if none. The
+// session owns rw and closes it if it is an io.Closer.
+func NewSession(rw io.ReadWriter, caps ...string) (*Session, error) {
+	if len(caps) == 0 {
+		caps = DefaultCapabilities
+	}
+	f := transport.NewFramer(rw, rw)
+	hello, err := transport.Exchange(f, &transport.Hello{Capabilities: caps})
+	if err != nil {
+		closeRW(rw)
+		return nil, err
+	}
+	if hello.SessionID == 0 {
+		closeRW(rw)
+		return nil, fmt.Errorf("client: no session-id in the hello of the server")
+	}
+	s := &Session{
+		rw:      rw,
+		f:       f,
+		hello:   hello,
+		pending: map[string]chan []byte{},
+		done:    make(chan struct{}),
+
+		notifications: make(chan *Notification),
+		stop:          make(chan struct{}),
+		maxQueue:      DefaultNotificationQueue,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.receive()
+	go s.deliver()
+	return s, nil
+}
+
+func closeRW(rw io.ReadWriter) error {
+	if c, ok := rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SessionID returns the id the server assigned to the session
+func (s *Session) SessionID() uint64 {
+	return s.hello.SessionID
+}
+
+// Capabilities returns the capabilities of the server
+func (s *Session) Capabilities() []string {
+	return s.hello.Capabilities
+}
+
+// HasCapability tells whether the server has the capability. The
+// capabilities of modules are the variables <module>_capability of the
+// generated code.
+func (s *Session) HasCapability(capability string) bool {
+	return transport.HasCapability(s.hello.Capabilities, capability)
+}
+
+// Done is closed when the session ends
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the session ended
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the session with close-session and closes the stream. The
+// notifications not received yet are discarded.
+func (s *Session) Close() error {
+	defer s.stopDelivery()
+	select {
+	case <-s.done:
+		return closeRW(s.rw)
+	default:
+	}
+	_, err := s.rpc(context.Background(), []byte("<close-session/>"))
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+	if c, ok := s.rw.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+		<-s.done
+	}
+	return err
+}
+
+// Read the messages of the server and hand the replies over to the rpcs
+// waiting for them and the notifications over to Notifications()
+func (s *Session) receive() {
+	var err error
+	for {
+		var msg []byte
+		msg, err = s.f.ReadMsg()
+		if err != nil {
+			break
+		}
+		start, perr := rootElement(msg)
+		if perr != nil {
+			continue
+		}
+		switch {
+		case start.Name.Space == nc.NetconfNs && start.Name.Local == "rpc-reply":
+			id := attr(start, "message-id")
+			s.mu.Lock()
+			ch, ok := s.pending[id]
+			delete(s.pending, id)
+			s.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case start.Name.Space == nc.NotificationNs && start.Name.Local == "notification":
+			s.queueNotification(msg)
+		}
+	}
+	s.mu.Lock()
+	if err == io.EOF || s.closing {
+		err = ErrClosed
+	}
+	s.err = err
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+	s.mu.Unlock()
+	s.cond.Broadcast()
+	close(s.done)
+}
+
+// The start of the root element of a message
+func rootElement(msg []byte) (xml.StartElement, error) {
+	d := xml.NewDecoder(bytes.NewReader(msg))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name && (a.Name.Space == "" || a.Name.Space == nc.NetconfNs) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Send the operation within <rpc> and wait for the reply. The errors of
+// the reply are returned as nc.RPCErrors along with the reply.
+func (s *Session) rpc(ctx context.Context, op []byte) ([]byte, error) {
+	ch := make(chan []byte, 1)
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.nextID++
+	id := strconv.FormatUint(s.nextID, 10)
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	var b bytes.Buffer
+	b.WriteString(`<rpc xmlns="` + nc.NetconfNs + `" message-id="` + id + `">`)
+	b.Write(op)
+	b.WriteString(`</rpc>`)
+	if err := s.f.WriteMsg(b.Bytes()); err != nil {
+		s.forget(id)
+		return nil, err
+	}
+	select {
+	case reply, ok := <-ch:
+		if !ok {
+			return nil, s.Err()
+		}
+		return reply, replyErrors(reply)
+	case <-ctx.Done():
+		s.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Session) forget(id string) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
+
+// The rpc-errors of a reply
+func replyErrors(reply []byte) error {
+	var r struct {
+		Errors []*nc.RPCError `xml:"rpc-error"`
+	}
+	if err := xml.Unmarshal(reply, &r); err != nil {
+		return fmt.Errorf("client: invalid reply: %v", err)
+	}
+	return nc.RPCErrors(r.Errors).Err()
+}
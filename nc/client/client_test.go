@@ -0,0 +1,291 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+	"nc/server"
+	"nc/transport"
+)
+
+// The configuration the stand-in server starts with
+func testDevice() *yang.Device {
+	dev := &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Hostname_Prsnt: true, Hostname: "r1", Mtu_Prsnt: true, Mtu: 1500}}
+	dev.T_top_Prsnt = true
+	dev.T_top.Intf = []yang.T_top_intf{{Name_Prsnt: true, Name: "eth0", Mtu_Prsnt: true, Mtu: 1500}}
+	return dev
+}
+
+// A session with the server over an in-memory pipe
+func connect(t *testing.T, srv *server.Server, caps ...string) *Session {
+	t.Helper()
+	c, sc := net.Pipe()
+	go srv.ServeConn(sc)
+	s, err := NewSession(c, caps...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// The rpc-error of the tag passed
+func hasTag(err error, tag string) bool {
+	var errs nc.RPCErrors
+	return errors.As(err, &errs) && len(errs) == 1 && errs[0].Tag == tag
+}
+
+func TestNewSession(t *testing.T) {
+	srv := server.New(testDevice())
+	for _, caps := range [][]string{nil, {transport.Base10}} {
+		s := connect(t, srv, caps...)
+		if s.SessionID() == 0 {
+			t.Errorf("no session-id")
+		}
+		if !s.HasCapability(yang.Test_capability) || !s.HasCapability(transport.CandidateCapability) {
+			t.Errorf("Capabilities() = %v", s.Capabilities())
+		}
+		// The framing is chunked unless the client only supports 1.0
+		if got, want := s.f.Chunked(), caps == nil; got != want {
+			t.Errorf("Chunked() = %v with %v", got, caps)
+		}
+		if err := s.Lock(context.Background(), nc.Running); err != nil {
+			t.Error(err)
+		}
+		if err := s.Unlock(context.Background(), nc.Running); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// The session isn't set up with a server whose hello has no session-id
+func TestNewSessionWithoutID(t *testing.T) {
+	c, sc := net.Pipe()
+	defer sc.Close()
+	go transport.Exchange(transport.NewFramer(sc, sc), &transport.Hello{Capabilities: []string{transport.Base10}})
+	if _, err := NewSession(c); err == nil || !strings.Contains(err.Error(), "session-id") {
+		t.Errorf("NewSession() = %v", err)
+	}
+}
+
+// The replies are matched to the rpcs by their message-id whatever their
+// order
+func TestMessageID(t *testing.T) {
+	c, sc := net.Pipe()
+	go func() {
+		defer sc.Close()
+		f := transport.NewFramer(sc, sc)
+		if _, err := transport.Exchange(f, &transport.Hello{Capabilities: []string{transport.Base11}, SessionID: 1}); err != nil {
+			t.Error(err)
+			return
+		}
+		var replies []string
+		for i := 0; i < 2; i++ {
+			msg, err := f.ReadMsg()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			start, _ := rootElement(msg)
+			id := attr(start, "message-id")
+			reply := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="` + id + `">`
+			if strings.Contains(string(msg), "<lock>") {
+				reply += `<rpc-error><error-type>protocol</error-type><error-tag>lock-denied</error-tag><error-severity>error</error-severity></rpc-error>`
+			} else {
+				reply += `<ok/>`
+			}
+			replies = append(replies, reply+`</rpc-reply>`)
+		}
+		for i := len(replies) - 1; i >= 0; i-- {
+			if err := f.WriteMsg([]byte(replies[i])); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		f.ReadMsg()
+	}()
+	s, err := NewSession(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	lock := make(chan error, 1)
+	go func() { lock <- s.Lock(ctx, nc.Candidate) }()
+	if err := s.Commit(ctx); err != nil {
+		t.Errorf("Commit() = %v", err)
+	}
+	if err := <-lock; !hasTag(err, "lock-denied") {
+		t.Errorf("Lock() = %v", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	srv := server.New(testDevice())
+	srv.SetState(&yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Stats_Prsnt: true, Stats: yang.T_system_stats_cont{In_Prsnt: true, In: 7}}})
+	s := connect(t, srv)
+	ctx := context.Background()
+
+	var dev yang.Device
+	if err := s.Get(ctx, nil, &dev); err != nil {
+		t.Fatal(err)
+	}
+	if dev.T_system.Hostname != "r1" || dev.T_system.Stats.In != 7 || len(dev.T_top.Intf) != 1 {
+		t.Errorf("Get() = %+v", dev)
+	}
+	dev = yang.Device{}
+	if err := s.GetConfig(ctx, nc.Running, yang.Root().T_system().Hostname(), &dev); err != nil {
+		t.Fatal(err)
+	}
+	want := yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r1"}
+	if !dev.T_system_Prsnt || !reflect.DeepEqual(dev.T_system, want) || dev.T_top_Prsnt {
+		t.Errorf("GetConfig() of the hostname = %+v", dev)
+	}
+	dev = yang.Device{}
+	filter := []nc.Path{yang.Root().T_system().Mtu(), yang.Root().T_top().Intf("eth0").Mtu()}
+	if err := s.GetConfig(ctx, nc.Running, filter, &dev); err != nil {
+		t.Fatal(err)
+	}
+	if dev.T_system.Mtu != 1500 || dev.T_system.Hostname_Prsnt || len(dev.T_top.Intf) != 1 || dev.T_top.Intf[0].Mtu != 1500 {
+		t.Errorf("GetConfig() of the mtus = %+v", dev)
+	}
+	if err := s.GetConfig(ctx, "nowhere", nil, &dev); !hasTag(err, "invalid-value") {
+		t.Errorf("GetConfig() of an unknown datastore = %v", err)
+	}
+}
+
+func TestEditConfig(t *testing.T) {
+	srv := server.New(testDevice())
+	s := connect(t, srv)
+	ctx := context.Background()
+	running := func() *yang.Device { return srv.Datastore(nc.Running).(*yang.Device) }
+
+	config := &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r2"}}
+	if err := s.EditConfig(ctx, nc.Running, config, nil); err != nil {
+		t.Fatal(err)
+	}
+	if dev := running(); dev.T_system.Hostname != "r2" || dev.T_system.Mtu != 1500 {
+		t.Errorf("merge: %+v", dev.T_system)
+	}
+	config.T_system.XMLOp = nc.OpReplace
+	config.T_system.Hostname = "r3"
+	if err := s.EditConfig(ctx, nc.Running, config, &EditOptions{TestOption: TestOnly}); err != nil {
+		t.Fatal(err)
+	}
+	if dev := running(); dev.T_system.Hostname != "r2" {
+		t.Errorf("test-only applied: %+v", dev.T_system)
+	}
+	if err := s.EditConfig(ctx, nc.Running, config, nil); err != nil {
+		t.Fatal(err)
+	}
+	if dev := running(); dev.T_system.Hostname != "r3" || dev.T_system.Mtu_Prsnt {
+		t.Errorf("replace: %+v", dev.T_system)
+	}
+	create := &yang.Device{T_top_Prsnt: true, T_top: yang.T_top_cont{
+		Intf: []yang.T_top_intf{{XMLOp: nc.OpCreate, Name_Prsnt: true, Name: "eth0"}}}}
+	if err := s.EditConfig(ctx, nc.Running, create, nil); !hasTag(err, "data-exists") {
+		t.Errorf("EditConfig() creating eth0 = %v", err)
+	}
+	xml := `<top xmlns="urn:test"><intf><name>eth1</name><mtu>9000</mtu></intf></top>`
+	if err := s.EditConfig(ctx, nc.Running, xml, &EditOptions{DefaultOperation: DefaultMerge}); err != nil {
+		t.Fatal(err)
+	}
+	if dev := running(); len(dev.T_top.Intf) != 2 {
+		t.Errorf("EditConfig() of XML: %+v", dev.T_top)
+	}
+}
+
+func TestLockCommit(t *testing.T) {
+	srv := server.New(testDevice())
+	s1 := connect(t, srv)
+	s2 := connect(t, srv)
+	ctx := context.Background()
+	hostname := func(ds nc.Datastore) string { return srv.Datastore(ds).(*yang.Device).T_system.Hostname }
+
+	if err := s1.Lock(ctx, nc.Candidate); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.Lock(ctx, nc.Candidate); !hasTag(err, "lock-denied") {
+		t.Errorf("Lock() of a locked datastore = %v", err)
+	}
+	config := &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r2"}}
+	if err := s2.EditConfig(ctx, nc.Candidate, config, nil); !hasTag(err, "in-use") {
+		t.Errorf("EditConfig() of a locked datastore = %v", err)
+	}
+	if err := s1.EditConfig(ctx, nc.Candidate, config, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s1.Validate(ctx, nc.Candidate); err != nil {
+		t.Error(err)
+	}
+	if err := s1.DiscardChanges(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if hostname(nc.Candidate) != "r1" {
+		t.Errorf("DiscardChanges() left %s", hostname(nc.Candidate))
+	}
+	if err := s1.EditConfig(ctx, nc.Candidate, config, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s1.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if hostname(nc.Running) != "r2" {
+		t.Errorf("Commit() left %s", hostname(nc.Running))
+	}
+	if err := s1.Unlock(ctx, nc.Candidate); err != nil {
+		t.Error(err)
+	}
+	if err := s2.Lock(ctx, nc.Candidate); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	srv := server.New(testDevice())
+	s := connect(t, srv)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-s.Done()
+	if err := s.Err(); err != ErrClosed {
+		t.Errorf("Err() = %v", err)
+	}
+	if err := s.Commit(context.Background()); err != ErrClosed {
+		t.Errorf("Commit() after Close() = %v", err)
+	}
+}
+
+// The rpc waiting for its reply returns when the context is done
+func TestContext(t *testing.T) {
+	c, sc := net.Pipe()
+	go func() {
+		defer sc.Close()
+		f := transport.NewFramer(sc, sc)
+		transport.Exchange(f, &transport.Hello{Capabilities: []string{transport.Base10}, SessionID: 1})
+		for {
+			if _, err := f.ReadMsg(); err != nil {
+				return
+			}
+		}
+	}()
+	s, err := NewSession(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Commit(ctx); err != context.Canceled {
+		t.Errorf("Commit() = %v", err)
+	}
+	c.Close()
+	<-s.Done()
+}
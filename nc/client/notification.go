@@ -0,0 +1,290 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"nc/nc"
+)
+
+// Notification is a <notification> received. The event is the generated
+// structure of the notification when it is one at the top level of a
+// module compiled in and nil otherwise. The notifications of the data
+// nodes are decoded with Decode into the generated device structure.
+type Notification struct {
+	EventTime time.Time
+	Name      xml.Name // the element of the event
+	Event     interface{}
+	msg       []byte
+}
+
+// DecodeNotification decodes the message of a notification
+func DecodeNotification(msg []byte) (*Notification, error) {
+	n := &Notification{msg: msg}
+	d := nc.NewDecoder(bytes.NewReader(msg))
+	start, err := n.event(d)
+	if err != nil {
+		return nil, err
+	}
+	n.Name = start.Name
+	if v, ok := nc.NewNotification(start.Name.Space, start.Name.Local); ok {
+		if err := d.DecodeElement(v, start); err != nil {
+			return nil, err
+		}
+		n.Event = v
+	}
+	return n, nil
+}
+
+// Read up to the element of the event. The eventTime is read on the way.
+func (n *Notification) event(d *nc.Decoder) (*xml.StartElement, error) {
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("client: invalid notification: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				if t.Name.Space != nc.NotificationNs || t.Name.Local != "notification" {
+					return nil, fmt.Errorf("client: %s isn't a notification", t.Name.Local)
+				}
+				continue
+			}
+			if t.Name.Space == nc.NotificationNs && t.Name.Local == "eventTime" {
+				var s string
+				if err := d.DecodeElement(&s, &t); err != nil {
+					return nil, err
+				}
+				depth--
+				if n.EventTime, err = time.Parse(time.RFC3339Nano, strings.TrimSpace(s)); err != nil {
+					return nil, fmt.Errorf("client: invalid eventTime %s", s)
+				}
+				continue
+			}
+			return &t, nil
+		case xml.EndElement:
+			return nil, fmt.Errorf("client: notification without event")
+		}
+	}
+}
+
+// Decode decodes the event into v. It is either the generated structure
+// of the notification or, for the notifications of the data nodes, the
+// generated device structure.
+func (n *Notification) Decode(v interface{}) error {
+	d := nc.NewDecoder(bytes.NewReader(n.msg))
+	ns, name := nc.ElementName(v)
+	if ns == nc.NetconfNs && name == "data" {
+		// The event is decoded as the content of the device
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if root, ok := tok.(xml.StartElement); ok {
+				root.Name = xml.Name{Space: nc.NetconfNs, Local: "data"}
+				return d.DecodeElement(v, &root)
+			}
+		}
+	}
+	start, err := n.event(d)
+	if err != nil {
+		return err
+	}
+	return d.DecodeElement(v, start)
+}
+
+// DefaultNotificationQueue is the number of notifications a session holds
+// for Notifications() until they are received
+const DefaultNotificationQueue = 1000
+
+// Notifications returns the channel of the notifications received on
+// the session. Those still queued when the session ends are delivered
+// before the channel is closed unless the session is closed by Close.
+func (s *Session) Notifications() <-chan *Notification {
+	return s.notifications
+}
+
+// SetNotificationQueue sets the number of notifications the session holds
+// until they are received on Notifications(), at least one. When the queue
+// is full the notifications that arrive are dropped, rather than holding
+// up the replies of the rpcs, and counted by Dropped.
+func (s *Session) SetNotificationQueue(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.mu.Lock()
+	s.maxQueue = n
+	s.mu.Unlock()
+}
+
+// Dropped returns the number of notifications dropped as the queue was full
+func (s *Session) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Queue a notification received. They are delivered in the order
+// received without holding up the replies of the rpcs.
+func (s *Session) queueNotification(msg []byte) {
+	n, err := DecodeNotification(msg)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	if len(s.queue) >= s.maxQueue {
+		s.dropped++
+		s.mu.Unlock()
+		return
+	}
+	s.queue = append(s.queue, n)
+	s.mu.Unlock()
+	s.cond.Signal()
+}
+
+// Hand the queued notifications over to Notifications() until the session
+// has ended and the queue is empty or until Close, as no one may be left
+// to receive them
+func (s *Session) deliver() {
+	defer close(s.notifications)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && s.err == nil && !s.stopped() {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 || s.stopped() {
+			s.mu.Unlock()
+			return
+		}
+		n := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		select {
+		case s.notifications <- n:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// End the delivery of the notifications
+func (s *Session) stopDelivery() {
+	s.mu.Lock()
+	if !s.stopped() {
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+func (s *Session) stopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// SubscriptionOptions are the parameters of a subscription. The stream is
+// NETCONF if empty and the filter is one accepted by Filter. The start
+// time asks for the replay of the notifications since then.
+type SubscriptionOptions struct {
+	Stream    string
+	Filter    interface{}
+	StartTime time.Time
+	StopTime  time.Time
+}
+
+// CreateSubscription subscribes to the notifications of a stream as in
+// RFC 5277. They are received on Notifications().
+func (s *Session) CreateSubscription(ctx context.Context, opts *SubscriptionOptions) error {
+	if opts == nil {
+		opts = &SubscriptionOptions{}
+	}
+	var b bytes.Buffer
+	b.WriteString(`<create-subscription xmlns="` + nc.NotificationNs + `">`)
+	if err := writeSubscription(&b, opts, "filter", "startTime", "stopTime"); err != nil {
+		return err
+	}
+	b.WriteString("</create-subscription>")
+	_, err := s.rpc(ctx, b.Bytes())
+	return err
+}
+
+// EstablishSubscription establishes a dynamic subscription as in RFC 8639
+// and returns its id. The notifications are received on Notifications().
+func (s *Session) EstablishSubscription(ctx context.Context, opts *SubscriptionOptions) (uint32, error) {
+	if opts == nil {
+		opts = &SubscriptionOptions{}
+	}
+	var b bytes.Buffer
+	b.WriteString(`<establish-subscription xmlns="` + nc.SubscribedNotificationsNs + `">`)
+	if err := writeSubscription(&b, opts, "stream-subtree-filter", "replay-start-time", "stop-time"); err != nil {
+		return 0, err
+	}
+	b.WriteString("</establish-subscription>")
+	reply, err := s.rpc(ctx, b.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	var r struct {
+		ID string `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications id"`
+	}
+	if err := xml.Unmarshal(reply, &r); err != nil {
+		return 0, fmt.Errorf("client: invalid reply: %v", err)
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(r.ID), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("client: invalid subscription id %q", r.ID)
+	}
+	return uint32(id), nil
+}
+
+// DeleteSubscription ends a dynamic subscription of the session
+func (s *Session) DeleteSubscription(ctx context.Context, id uint32) error {
+	_, err := s.rpc(ctx, []byte(fmt.Sprintf(`<delete-subscription xmlns="%s"><id>%d</id></delete-subscription>`, nc.SubscribedNotificationsNs, id)))
+	return err
+}
+
+// The stream, the filter and the times of a subscription which differ
+// between the two RFCs only in their names
+func writeSubscription(b *bytes.Buffer, opts *SubscriptionOptions, filter, start, stop string) error {
+	stream := opts.Stream
+	if stream == "" {
+		stream = "NETCONF"
+	}
+	b.WriteString("<stream>")
+	xml.EscapeText(b, []byte(stream))
+	b.WriteString("</stream>")
+	f, err := Filter(opts.Filter)
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		if filter == "filter" {
+			b.WriteString(`<filter type="subtree">`)
+		} else {
+			b.WriteString("<" + filter + ">")
+		}
+		b.Write(f)
+		b.WriteString("</" + filter + ">")
+	}
+	if !opts.StartTime.IsZero() {
+		b.WriteString("<" + start + ">" + opts.StartTime.Format(time.RFC3339Nano) + "</" + start + ">")
+	}
+	if !opts.StopTime.IsZero() {
+		b.WriteString("<" + stop + ">" + opts.StopTime.Format(time.RFC3339Nano) + "</" + stop + ">")
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"nc/internal/yang"
+	"nc/nc"
+	"nc/server"
+	"nc/transport"
+)
+
+// A session with a server that sends the notifications passed and ends
+// the session once the start channel is closed
+func notifyingSession(t *testing.T, notifications int) (*Session, chan struct{}) {
+	c, srv := net.Pipe()
+	start := make(chan struct{})
+	go func() {
+		defer srv.Close()
+		f := transport.NewFramer(srv, srv)
+		if _, err := transport.Exchange(f, &transport.Hello{Capabilities: []string{transport.Base10}, SessionID: 1}); err != nil {
+			t.Error(err)
+			return
+		}
+		<-start
+		for i := 0; i < notifications; i++ {
+			msg := fmt.Sprintf(`<notification xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><eventTime>2024-01-01T00:00:00Z</eventTime><event xmlns="urn:test"><seq>%d</seq></event></notification>`, i)
+			if err := f.WriteMsg([]byte(msg)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	s, err := NewSession(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, start
+}
+
+// The notifications received are all delivered after the session ended
+func TestNotificationsDrained(t *testing.T) {
+	s, start := notifyingSession(t, 10)
+	close(start)
+	<-s.Done()
+	var seq []string
+	for n := range s.Notifications() {
+		var e struct {
+			Seq string `xml:"seq"`
+		}
+		if err := n.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		seq = append(seq, e.Seq)
+	}
+	if got := fmt.Sprint(seq); got != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Errorf("received %s", got)
+	}
+	if s.Dropped() != 0 {
+		t.Errorf("%d dropped", s.Dropped())
+	}
+}
+
+// The notifications beyond the queue are dropped and counted
+func TestNotificationsDropped(t *testing.T) {
+	s, start := notifyingSession(t, 10)
+	s.SetNotificationQueue(3)
+	close(start)
+	<-s.Done()
+	received := 0
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case _, ok := <-s.Notifications():
+			if ok {
+				received++
+			}
+			done = !ok
+		case <-timeout:
+			t.Fatal("the notifications channel isn't closed")
+		}
+	}
+	// One notification may have been taken off the queue while the others
+	// were queued
+	if received != 3 && received != 4 {
+		t.Errorf("received %d notifications", received)
+	}
+	if got := s.Dropped(); got != uint64(10-received) {
+		t.Errorf("Dropped() = %d, want %d", got, 10-received)
+	}
+}
+
+// The delivery ends on Close even though the notifications aren't
+// received
+func TestNotificationsClosed(t *testing.T) {
+	s, start := notifyingSession(t, 10)
+	close(start)
+	<-s.Done()
+	s.Close()
+	received := 0
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case _, ok := <-s.Notifications():
+			if ok {
+				received++
+			}
+			done = !ok
+		case <-timeout:
+			t.Fatal("the notifications channel isn't closed")
+		}
+	}
+	// The notification being handed over when Close is called may still
+	// be received
+	if received > 1 {
+		t.Errorf("received %d notifications after Close", received)
+	}
+}
+
+// The notifications are received once for each dynamic subscription whose
+// filter selects them
+func TestEstablishSubscription(t *testing.T) {
+	srv := server.New(testDevice())
+	s := connect(t, srv)
+	ctx := context.Background()
+	red, err := s.EstablishSubscription(ctx, &SubscriptionOptions{Filter: `<alarm xmlns="urn:test"><severity>red</severity></alarm>`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.EstablishSubscription(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if red == all {
+		t.Fatalf("the subscriptions have the same id %d", red)
+	}
+	notify := func(severity yang.T_color, text string) {
+		t.Helper()
+		err := srv.Notify(&yang.T_alarm_cont{Severity_Prsnt: true, Severity: severity, Text_Prsnt: true, Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	receive := func() string {
+		t.Helper()
+		select {
+		case n := <-s.Notifications():
+			alarm, ok := n.Event.(*yang.T_alarm_cont)
+			if !ok || n.EventTime.IsZero() {
+				t.Fatalf("received %+v", n)
+			}
+			return alarm.Text
+		case <-time.After(5 * time.Second):
+			t.Fatal("no notification received")
+		}
+		return ""
+	}
+	notify(yang.T_color_Green, "1")
+	notify(yang.T_color_Red, "2")
+	if err := s.DeleteSubscription(ctx, all); err != nil {
+		t.Fatal(err)
+	}
+	notify(yang.T_color_Blue, "3")
+	notify(yang.T_color_Red, "4")
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, receive())
+	}
+	if fmt.Sprint(got) != "[1 2 2 4]" {
+		t.Errorf("received %v", got)
+	}
+
+	var errs nc.RPCErrors
+	if err := s.DeleteSubscription(ctx, all); !errors.As(err, &errs) || errs[0].AppTag != "ietf-subscribed-notifications:no-such-subscription" {
+		t.Errorf("DeleteSubscription() of a deleted subscription = %v", err)
+	}
+	_, err = s.EstablishSubscription(ctx, &SubscriptionOptions{StartTime: time.Now()})
+	if !errors.As(err, &errs) || errs[0].AppTag != "ietf-subscribed-notifications:replay-unsupported" {
+		t.Errorf("EstablishSubscription() with a replay = %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+
+	"nc/nc"
+)
+
+// DefaultOperation is the default-operation of edit-config
+type DefaultOperation string
+
+const (
+	DefaultMerge   DefaultOperation = "merge"
+	DefaultReplace DefaultOperation = "replace"
+	DefaultNone    DefaultOperation = "none"
+)
+
+// TestOption is the test-option of edit-config
+type TestOption string
+
+const (
+	TestThenSet TestOption = "test-then-set"
+	Set         TestOption = "set"
+	TestOnly    TestOption = "test-only"
+)
+
+// ErrorOption is the error-option of edit-config
+type ErrorOption string
+
+const (
+	StopOnError     ErrorOption = "stop-on-error"
+	ContinueOnError ErrorOption = "continue-on-error"
+	RollbackOnError ErrorOption = "rollback-on-error"
+)
+
+// EditOptions are the parameters of edit-config. Those left empty are
+// not sent and the server applies its defaults.
+type EditOptions struct {
+	DefaultOperation DefaultOperation
+	TestOption       TestOption
+	ErrorOption      ErrorOption
+}
+
+// SetWithDefaults sets the with-defaults parameter of get and get-config
+// of RFC 6243. The parameter isn't sent unless set.
+func (s *Session) SetWithDefaults(mode nc.WithDefaults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.withDefaults = &mode
+}
+
+// Get retrieves the configuration and the state of the server. The
+// filter is either one accepted by Filter or nil to retrieve everything.
+// The content of <data> is decoded into v which is either the generated
+// device structure or a top level container.
+func (s *Session) Get(ctx context.Context, filter, v interface{}) error {
+	return s.get(ctx, "get", "", filter, v)
+}
+
+// GetConfig retrieves the configuration in the datastore. The filter and
+// v are those of Get.
+func (s *Session) GetConfig(ctx context.Context, source nc.Datastore, filter, v interface{}) error {
+	return s.get(ctx, "get-config", source, filter, v)
+}
+
+func (s *Session) get(ctx context.Context, op string, source nc.Datastore, filter, v interface{}) error {
+	f, err := Filter(filter)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString("<" + op + ">")
+	if source != "" {
+		b.WriteString("<source>" + datastore(source) + "</source>")
+	}
+	if f != nil {
+		b.WriteString(`<filter type="subtree">`)
+		b.Write(f)
+		b.WriteString("</filter>")
+	}
+	s.mu.Lock()
+	wd := s.withDefaults
+	s.mu.Unlock()
+	if wd != nil {
+		b.WriteString(`<with-defaults xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults">`)
+		b.WriteString(wd.String() + "</with-defaults>")
+	}
+	b.WriteString("</" + op + ">")
+	reply, err := s.rpc(ctx, b.Bytes())
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return decodeData(reply, v)
+}
+
+// Filter returns the subtree filter of RFC 6241 for a filter given as one
+// of:
+//   - the XML of the filter as []byte or string
+//   - a path such as those built from Root() or a slice of them
+//   - a generated structure with the nodes to select as with nc.Filter
+func Filter(filter interface{}) ([]byte, error) {
+	switch f := filter.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return f, nil
+	case string:
+		return []byte(f), nil
+	case []nc.Path:
+		var b []byte
+		for _, p := range f {
+			pf, err := p.Filter()
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, pf...)
+		}
+		return b, nil
+	case interface{ Filter() ([]byte, error) }:
+		return f.Filter()
+	}
+	return nc.Filter(filter)
+}
+
+// Decode the content of <data> of a reply into v
+func decodeData(reply []byte, v interface{}) error {
+	ns, name := nc.ElementName(v)
+	if name == "" {
+		return fmt.Errorf("client: can't decode into %T without XMLName", v)
+	}
+	d := nc.NewDecoder(bytes.NewReader(reply))
+	data, err := child(d, nc.NetconfNs, "data")
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("client: no data in the reply")
+	}
+	if ns == nc.NetconfNs && name == "data" {
+		return d.DecodeElement(v, data)
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == name && (ns == "" || t.Name.Space == ns) {
+				return d.DecodeElement(v, &t)
+			}
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// Locate the child of <rpc-reply> with the name passed. It returns nil
+// if there is none.
+func child(d *nc.Decoder, ns, name string) (*xml.StartElement, error) {
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 1 && t.Name.Space == ns && t.Name.Local == name {
+				return &t, nil
+			}
+			if depth == 1 {
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return nil, nil
+			}
+		}
+	}
+}
+
+// EditConfig loads the configuration into the datastore. The config is
+// the generated device structure, a top level container or the XML of the
+// content of <config>. The operation of each node is set through its
+// field XMLOp. The options may be nil.
+func (s *Session) EditConfig(ctx context.Context, target nc.Datastore, config interface{}, opts *EditOptions) error {
+	var b bytes.Buffer
+	b.WriteString("<edit-config><target>" + datastore(target) + "</target>")
+	if opts != nil {
+		if opts.DefaultOperation != "" {
+			b.WriteString("<default-operation>" + string(opts.DefaultOperation) + "</default-operation>")
+		}
+		if opts.TestOption != "" {
+			b.WriteString("<test-option>" + string(opts.TestOption) + "</test-option>")
+		}
+		if opts.ErrorOption != "" {
+			b.WriteString("<error-option>" + string(opts.ErrorOption) + "</error-option>")
+		}
+	}
+	c, err := configXML(config)
+	if err != nil {
+		return err
+	}
+	b.Write(c)
+	b.WriteString("</edit-config>")
+	_, err = s.rpc(ctx, b.Bytes())
+	return err
+}
+
+// The element <config> holding the configuration
+func configXML(config interface{}) ([]byte, error) {
+	switch c := config.(type) {
+	case []byte:
+		return append(append([]byte("<config>"), c...), "</config>"...), nil
+	case string:
+		return []byte("<config>" + c + "</config>"), nil
+	}
+	ns, name := nc.ElementName(config)
+	if ns == nc.NetconfNs && name == "data" {
+		return nc.MarshalElement(config, nc.NetconfNs, "config")
+	}
+	b, err := nc.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte("<config>"), b...), "</config>"...), nil
+}
+
+// Lock locks the datastore
+func (s *Session) Lock(ctx context.Context, target nc.Datastore) error {
+	_, err := s.rpc(ctx, []byte("<lock><target>"+datastore(target)+"</target></lock>"))
+	return err
+}
+
+// Unlock unlocks the datastore
+func (s *Session) Unlock(ctx context.Context, target nc.Datastore) error {
+	_, err := s.rpc(ctx, []byte("<unlock><target>"+datastore(target)+"</target></unlock>"))
+	return err
+}
+
+// Commit commits the candidate datastore to the running one
+func (s *Session) Commit(ctx context.Context) error {
+	_, err := s.rpc(ctx, []byte("<commit/>"))
+	return err
+}
+
+// DiscardChanges reverts the candidate datastore to the running one
+func (s *Session) DiscardChanges(ctx context.Context) error {
+	_, err := s.rpc(ctx, []byte("<discard-changes/>"))
+	return err
+}
+
+// Validate validates the configuration in the datastore
+func (s *Session) Validate(ctx context.Context, source nc.Datastore) error {
+	_, err := s.rpc(ctx, []byte("<validate><source>"+datastore(source)+"</source></validate>"))
+	return err
+}
+
+// KillSession ends another session of the server
+func (s *Session) KillSession(ctx context.Context, id uint64) error {
+	_, err := s.rpc(ctx, []byte(fmt.Sprintf("<kill-session><session-id>%d</session-id></kill-session>", id)))
+	return err
+}
+
+// Call invokes an rpc or an action. The input is the generated input
+// structure of the rpc or an nc.Action. The output, if not nil, is the
+// generated output structure into which the reply is decoded.
+func (s *Session) Call(ctx context.Context, input, output interface{}) error {
+	in, err := nc.Marshal(input)
+	if err != nil {
+		return err
+	}
+	reply, err := s.rpc(ctx, in)
+	if err != nil || output == nil {
+		return err
+	}
+	d := nc.NewDecoder(bytes.NewReader(reply))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return d.DecodeElement(output, &start)
+		}
+	}
+}
+
+func datastore(ds nc.Datastore) string {
+	return "<" + string(ds) + "/>"
+}
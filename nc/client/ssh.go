@@ -0,0 +1,59 @@
+package client
+
+import (
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// The stream of the netconf subsystem of an SSH connection
+type sshStream struct {
+	io.Reader
+	io.WriteCloser
+	session *ssh.Session
+	conn    *ssh.Client
+}
+
+func (s *sshStream) Close() error {
+	s.WriteCloser.Close()
+	s.session.Close()
+	return s.conn.Close()
+}
+
+// Dial connects to the server at addr over SSH as in RFC 6242 and starts
+// a session on the netconf subsystem. The capabilities are those of
+// NewSession.
+func Dial(addr string, config *ssh.ClientConfig, caps ...string) (*Session, error) {
+	conn, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	rw, err := subsystem(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return NewSession(rw, caps...)
+}
+
+func subsystem(conn *ssh.Client) (*sshStream, error) {
+	session, err := conn.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	if err := session.RequestSubsystem("netconf"); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return &sshStream{Reader: r, WriteCloser: w, session: session, conn: conn}, nil
+}
@@ -0,0 +1,315 @@
+// Package gnmi binds the generated structures to gNMI. The paths of gNMI
+// are those of the generated path builders whose names are qualified by
+// the modules registered by the generated code and whose keys are those
+// of the lists. The leaves are carried as typed values and the other
+// nodes in JSON of RFC 7951. The server serves the datastore of a netconf
+// server with Get, Set and Subscribe.
+package gnmi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+
+	"nc/nc"
+)
+
+// Path returns the path of gNMI of a path of the generated path builders
+func Path(p nc.Path) *gpb.Path {
+	gp := &gpb.Path{}
+	for _, e := range p.Gnmi() {
+		gp.Elem = append(gp.Elem, &gpb.PathElem{Name: e.Name, Key: e.Key})
+	}
+	return gp
+}
+
+// ParsePath returns the path of a path of gNMI which follows the prefix,
+// nil for none. The namespace of a name that isn't qualified by its module
+// is the one of its parent. The keys are named but are in no order until
+// the path is resolved by nc.ResolvePath.
+func ParsePath(prefix, p *gpb.Path) (nc.Path, error) {
+	var r nc.Path
+	ns := ""
+	for _, gp := range []*gpb.Path{prefix, p} {
+		if gp == nil {
+			continue
+		}
+		if len(gp.Element) > 0 && len(gp.Elem) == 0 {
+			return nil, fmt.Errorf("gnmi: the paths of elements aren't supported")
+		}
+		for _, e := range gp.Elem {
+			name := e.Name
+			if mod, local, ok := strings.Cut(name, ":"); ok {
+				m, ok := nc.ModuleByName(mod)
+				if !ok {
+					return nil, fmt.Errorf("gnmi: unknown module %s", mod)
+				}
+				ns, name = m.Namespace, local
+			}
+			if ns == "" {
+				return nil, fmt.Errorf("gnmi: %s isn't qualified by its module", name)
+			}
+			var keys []nc.KeyValue
+			for k, v := range e.Key {
+				keys = append(keys, nc.KeyValue{Name: k, Value: v})
+			}
+			r = append(r, nc.PathElem{Name: name, Namespace: ns, Keys: keys})
+		}
+	}
+	return r, nil
+}
+
+// TypedValue returns the typed value of the node v of the namespace ns.
+// The leaves are scalars as nc.LeafValue returns them, the decimal64 is
+// a decimal and the leaf-lists are arrays of scalars. The other nodes are
+// in JSON_IETF.
+func TypedValue(v interface{}, ns string) (*gpb.TypedValue, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		list := &gpb.ScalarArray{}
+		for i := 0; i < rv.Len(); i++ {
+			value, err := nc.LeafValue(rv.Index(i).Interface(), ns)
+			if err != nil {
+				// The lists without keys are passed whole
+				return jsonValue(v, ns)
+			}
+			list.Element = append(list.Element, scalar(value))
+		}
+		return &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{LeaflistVal: list}}, nil
+	}
+	value, err := nc.LeafValue(v, ns)
+	if err != nil {
+		if rv.Kind() == reflect.Struct {
+			return jsonValue(v, ns)
+		}
+		return nil, err
+	}
+	return scalar(value), nil
+}
+
+func jsonValue(v interface{}, ns string) (*gpb.TypedValue, error) {
+	return encodeJSON(v, ns, gpb.Encoding_JSON_IETF)
+}
+
+// The value of a node in JSON of RFC 7951 which is carried as JSON_IETF
+// or, with the encoding JSON, as JSON
+func encodeJSON(v interface{}, ns string, enc gpb.Encoding) (*gpb.TypedValue, error) {
+	b, err := nc.MarshalJSONValue(v, ns)
+	if err != nil {
+		return nil, err
+	}
+	if enc == gpb.Encoding_JSON {
+		return &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: b}}, nil
+	}
+	return &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: b}}, nil
+}
+
+// The typed value of a value that nc.LeafValue returns
+func scalar(v interface{}) *gpb.TypedValue {
+	switch v := v.(type) {
+	case bool:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{BoolVal: v}}
+	case int64:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{IntVal: v}}
+	case uint64:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: v}}
+	case float64:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_DoubleVal{DoubleVal: v}}
+	case []byte:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: v}}
+	case nc.Decimal64:
+		d := &gpb.Decimal64{Digits: reflect.ValueOf(v).Int(), Precision: uint32(v.FractionDigits())}
+		return &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{DecimalVal: d}}
+	}
+	return &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: fmt.Sprint(v)}}
+}
+
+// DecodeValue decodes the typed value of the node of the path into v, a
+// pointer of the type nc.NewNode returns for the path
+func DecodeValue(tv *gpb.TypedValue, p nc.Path, v interface{}) error {
+	ns := ""
+	if len(p) > 0 {
+		ns = p[len(p)-1].Namespace
+	}
+	switch tv.GetValue().(type) {
+	case *gpb.TypedValue_JsonIetfVal:
+		return nc.UnmarshalJSONValue(tv.GetJsonIetfVal(), v, ns)
+	case *gpb.TypedValue_JsonVal:
+		return nc.UnmarshalJSONValue(tv.GetJsonVal(), v, ns)
+	case *gpb.TypedValue_LeaflistVal:
+		var values []interface{}
+		for _, e := range tv.GetLeaflistVal().GetElement() {
+			value, err := scalarValue(e)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		return nc.SetLeafValue(v, values, ns)
+	}
+	value, err := scalarValue(tv)
+	if err != nil {
+		return err
+	}
+	return nc.SetLeafValue(v, value, ns)
+}
+
+// The value of a scalar typed value as nc.SetLeafValue takes it
+func scalarValue(tv *gpb.TypedValue) (interface{}, error) {
+	switch v := tv.GetValue().(type) {
+	case *gpb.TypedValue_StringVal:
+		return v.StringVal, nil
+	case *gpb.TypedValue_AsciiVal:
+		return v.AsciiVal, nil
+	case *gpb.TypedValue_BoolVal:
+		return v.BoolVal, nil
+	case *gpb.TypedValue_IntVal:
+		return v.IntVal, nil
+	case *gpb.TypedValue_UintVal:
+		return v.UintVal, nil
+	case *gpb.TypedValue_DoubleVal:
+		return v.DoubleVal, nil
+	case *gpb.TypedValue_FloatVal:
+		return float64(v.FloatVal), nil
+	case *gpb.TypedValue_BytesVal:
+		return v.BytesVal, nil
+	case *gpb.TypedValue_DecimalVal:
+		return decimalText(v.DecimalVal), nil
+	}
+	return nil, fmt.Errorf("gnmi: the value %T isn't supported", tv.GetValue())
+}
+
+// The text of a decimal as in the XML encoding
+func decimalText(d *gpb.Decimal64) string {
+	s := strconv.FormatInt(d.Digits, 10)
+	sign := ""
+	if d.Digits < 0 {
+		sign, s = "-", s[1:]
+	}
+	n := int(d.Precision)
+	if n == 0 {
+		return sign + s
+	}
+	if len(s) <= n {
+		s = strings.Repeat("0", n-len(s)+1) + s
+	}
+	return sign + s[:len(s)-n] + "." + s[len(s)-n:]
+}
+
+// Notification returns the notification of the node v of the path at the
+// time passed. With the encoding PROTO the updates are those of the leaves
+// within the node with typed values and with JSON_IETF or JSON the node,
+// in JSON of RFC 7951 in both, is the value of a single update. The paths
+// of the updates follow the path as the prefix.
+func Notification(p nc.Path, v interface{}, enc gpb.Encoding, t time.Time) (*gpb.Notification, error) {
+	n := &gpb.Notification{Timestamp: t.UnixNano(), Prefix: Path(p)}
+	switch enc {
+	case gpb.Encoding_PROTO:
+		err := nc.Leaves(v, p, func(lp nc.Path, value interface{}) error {
+			tv, err := TypedValue(value, lp[len(lp)-1].Namespace)
+			if err != nil {
+				return fmt.Errorf("gnmi: %s: %v", lp, err)
+			}
+			n.Update = append(n.Update, &gpb.Update{Path: relative(p, lp), Val: tv})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	case gpb.Encoding_JSON_IETF, gpb.Encoding_JSON:
+		ns := ""
+		if len(p) > 0 {
+			ns = p[len(p)-1].Namespace
+		}
+		tv, err := encodeJSON(v, ns, enc)
+		if err != nil {
+			return nil, err
+		}
+		n.Update = []*gpb.Update{{Path: &gpb.Path{}, Val: tv}}
+	default:
+		return nil, fmt.Errorf("gnmi: the encoding %s isn't supported", enc)
+	}
+	return n, nil
+}
+
+// The path of gNMI of a node relative to the path of one of its ancestors.
+// The first name is qualified unless its module is the one of the prefix.
+func relative(prefix, p nc.Path) *gpb.Path {
+	if len(prefix) == 0 {
+		return Path(p)
+	}
+	gp := Path(p[len(prefix)-1:])
+	gp.Elem = gp.Elem[1:]
+	return gp
+}
+
+// Unmarshal applies the updates and the deletes of a notification to the
+// generated device v points to. The updates are merged and the lists
+// without keys, whose entries can't be addressed, are appended to.
+func Unmarshal(n *gpb.Notification, v interface{}) error {
+	for _, d := range n.GetDelete() {
+		p, err := ParsePath(n.GetPrefix(), d)
+		if err != nil {
+			return err
+		}
+		if err := nc.EditNode(v, p, nil, nc.OpRemove); err != nil {
+			return err
+		}
+	}
+	for _, u := range n.GetUpdate() {
+		p, err := ParsePath(n.GetPrefix(), u.GetPath())
+		if err != nil {
+			return err
+		}
+		if err := Update(v, p, u.GetVal(), nc.OpMerge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update applies the typed value of the node of the path to the generated
+// device v points to with the operation of edit-config passed. The value
+// of a list without keys in the path is an array of entries.
+func Update(v interface{}, p nc.Path, tv *gpb.TypedValue, op nc.Operation) error {
+	node, err := nc.NewNode(v, p)
+	if err != nil {
+		return err
+	}
+	b := tv.GetJsonIetfVal()
+	if b == nil {
+		b = tv.GetJsonVal()
+	}
+	if len(b) > 0 && b[0] == '[' && reflect.TypeOf(node).Elem().Kind() == reflect.Struct {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return invalidValue(p, err)
+		}
+		for _, e := range entries {
+			entry, _ := nc.NewNode(v, p)
+			if err := nc.UnmarshalJSONValue(e, entry, p[len(p)-1].Namespace); err != nil {
+				return invalidValue(p, err)
+			}
+			if err := nc.EditNode(v, p, entry, op); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := DecodeValue(tv, p, node); err != nil {
+		return invalidValue(p, err)
+	}
+	return nc.EditNode(v, p, node, op)
+}
+
+func invalidValue(p nc.Path, err error) error {
+	return &nc.RPCError{Type: nc.ErrApplication, Tag: "invalid-value", Severity: "error", Path: p.String(), Message: err.Error()}
+}
@@ -0,0 +1,79 @@
+package gnmi
+
+import (
+	"testing"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/protobuf/proto"
+
+	"nc/nc"
+)
+
+func TestTypedValue(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want *gpb.TypedValue
+	}{
+		{"a", &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "a"}}},
+		{true, &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{BoolVal: true}}},
+		{int8(-3), &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{IntVal: -3}}},
+		{uint32(7), &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: 7}}},
+		{0.1, &gpb.TypedValue{Value: &gpb.TypedValue_DoubleVal{DoubleVal: 0.1}}},
+		{[]byte{1, 2}, &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: []byte{1, 2}}}},
+		{[]string{"x", "y"}, &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{LeaflistVal: &gpb.ScalarArray{Element: []*gpb.TypedValue{
+			{Value: &gpb.TypedValue_StringVal{StringVal: "x"}},
+			{Value: &gpb.TypedValue_StringVal{StringVal: "y"}},
+		}}}}},
+	}
+	for _, tt := range tests {
+		got, err := TypedValue(tt.v, "urn:test")
+		if err != nil {
+			t.Errorf("TypedValue(%v): %v", tt.v, err)
+			continue
+		}
+		if !proto.Equal(got, tt.want) {
+			t.Errorf("TypedValue(%v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+// The floats are decoded from both the double and the deprecated float
+func TestDecodeFloat(t *testing.T) {
+	p := nc.Path{{Name: "load", Namespace: "urn:test"}}
+	for _, tv := range []*gpb.TypedValue{
+		{Value: &gpb.TypedValue_DoubleVal{DoubleVal: 0.1}},
+		{Value: &gpb.TypedValue_FloatVal{FloatVal: 0.5}},
+	} {
+		var f float64
+		if err := DecodeValue(tv, p, &f); err != nil {
+			t.Errorf("DecodeValue(%v): %v", tv, err)
+			continue
+		}
+		want := tv.GetDoubleVal()
+		if want == 0 {
+			want = float64(tv.GetFloatVal())
+		}
+		if f != want {
+			t.Errorf("DecodeValue(%v) = %v", tv, f)
+		}
+	}
+}
+
+func TestDecimalText(t *testing.T) {
+	tests := []struct {
+		digits    int64
+		precision uint32
+		want      string
+	}{
+		{1234, 2, "12.34"},
+		{-1234, 2, "-12.34"},
+		{5, 3, "0.005"},
+		{-5, 1, "-0.5"},
+		{42, 0, "42"},
+	}
+	for _, tt := range tests {
+		if got := decimalText(&gpb.Decimal64{Digits: tt.digits, Precision: tt.precision}); got != tt.want {
+			t.Errorf("decimalText(%d, %d) = %s, want %s", tt.digits, tt.precision, got, tt.want)
+		}
+	}
+}
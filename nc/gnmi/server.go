@@ -0,0 +1,447 @@
+package gnmi
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"nc/nc"
+	"nc/server"
+)
+
+// Version is the version of gNMI the server implements
+const Version = "0.7.0"
+
+// The interval of the samples when the subscription sets none
+const defaultInterval = time.Second
+
+// Server serves the datastore of a netconf server over gNMI. Get and
+// Subscribe read running with the state data and Set changes running as
+// the netconf sessions see it. It is registered on a grpc.Server with
+// gpb.RegisterGNMIServer and may be served by a local listener.
+type Server struct {
+	srv *server.Server
+}
+
+// NewServer returns the gNMI server of the datastore of the server
+func NewServer(srv *server.Server) *Server {
+	return &Server{srv: srv}
+}
+
+// Capabilities replies the modules registered by the generated code and
+// the encodings JSON, JSON_IETF and PROTO
+func (s *Server) Capabilities(ctx context.Context, req *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
+	resp := &gpb.CapabilityResponse{
+		SupportedEncodings: []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_PROTO},
+		GNMIVersion:        Version,
+	}
+	for _, c := range nc.Capabilities() {
+		_, query, _ := strings.Cut(c, "?")
+		q, err := url.ParseQuery(query)
+		if err != nil || q.Get("module") == "" {
+			continue
+		}
+		resp.SupportedModels = append(resp.SupportedModels, &gpb.ModelData{Name: q.Get("module"), Version: q.Get("revision")})
+	}
+	return resp, nil
+}
+
+// Get replies a notification for each node of the paths. A list without
+// keys at the end of a path stands for all its entries.
+func (s *Server) Get(ctx context.Context, req *gpb.GetRequest) (*gpb.GetResponse, error) {
+	if err := checkEncoding(req.Encoding); err != nil {
+		return nil, err
+	}
+	var data interface{}
+	var err error
+	switch req.Type {
+	case gpb.GetRequest_ALL:
+		data, err = s.srv.Get()
+	case gpb.GetRequest_CONFIG:
+		data = s.srv.Datastore(nc.Running)
+	default:
+		data = s.srv.State()
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	paths := req.Path
+	if len(paths) == 0 {
+		paths = []*gpb.Path{nil}
+	}
+	t := time.Now()
+	resp := &gpb.GetResponse{}
+	for _, gp := range paths {
+		p, err := parsePath(req.Prefix, gp)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		found, err := nodes(data, p)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		for _, np := range found {
+			node, _ := nc.Find(data, np)
+			n, err := Notification(np, node, req.Encoding, t)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			resp.Notification = append(resp.Notification, n)
+		}
+	}
+	return resp, nil
+}
+
+// Set applies the deletes, the replaces and the updates in this order to
+// running as a single change that succeeds or fails as a whole
+func (s *Server) Set(ctx context.Context, req *gpb.SetRequest) (*gpb.SetResponse, error) {
+	resp := &gpb.SetResponse{Prefix: req.Prefix}
+	err := s.srv.Edit(func(data interface{}) error {
+		for _, gp := range req.Delete {
+			p, err := parsePath(req.Prefix, gp)
+			if err != nil {
+				return err
+			}
+			if len(p) == 0 {
+				v := reflect.ValueOf(data).Elem()
+				v.Set(reflect.Zero(v.Type()))
+			} else if err := nc.EditNode(data, p, nil, nc.OpRemove); err != nil {
+				return err
+			}
+			resp.Response = append(resp.Response, &gpb.UpdateResult{Path: gp, Op: gpb.UpdateResult_DELETE})
+		}
+		edits := []struct {
+			updates []*gpb.Update
+			op      nc.Operation
+			result  gpb.UpdateResult_Operation
+		}{
+			{req.Replace, nc.OpReplace, gpb.UpdateResult_REPLACE},
+			{req.Update, nc.OpMerge, gpb.UpdateResult_UPDATE},
+		}
+		for _, e := range edits {
+			for _, u := range e.updates {
+				p, err := parsePath(req.Prefix, u.Path)
+				if err != nil {
+					return err
+				}
+				if err := Update(data, p, u.Val, e.op); err != nil {
+					return err
+				}
+				resp.Response = append(resp.Response, &gpb.UpdateResult{Path: u.Path, Op: e.result})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp.Timestamp = time.Now().UnixNano()
+	return resp, nil
+}
+
+// Subscribe serves a subscription list. The updates are those of the
+// leaves within the nodes of the paths, of the leaves changed after the
+// initial ones in mode STREAM, along with the deletes of the leaves gone.
+// The subscriptions ON_CHANGE and TARGET_DEFINED report the changes of
+// the datastore and SAMPLE reports the leaves at its interval.
+func (s *Server) Subscribe(stream gpb.GNMI_SubscribeServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	list := req.GetSubscribe()
+	if list == nil {
+		return status.Error(codes.InvalidArgument, "the first request must be a subscription list")
+	}
+	if err := checkEncoding(list.Encoding); err != nil {
+		return err
+	}
+	running := s.srv.Datastore(nc.Running)
+	var subs []*subscription
+	for _, sub := range list.Subscription {
+		p, err := parsePath(list.Prefix, sub.Path)
+		if err == nil {
+			p, err = nc.ResolvePath(running, p)
+		}
+		if err != nil {
+			return toStatus(err)
+		}
+		interval := time.Duration(sub.SampleInterval)
+		if interval == 0 {
+			interval = defaultInterval
+		}
+		subs = append(subs, &subscription{path: p, mode: sub.Mode, interval: interval, suppress: sub.SuppressRedundant})
+	}
+	if len(subs) == 0 {
+		subs = []*subscription{{mode: gpb.SubscriptionMode_TARGET_DEFINED, interval: defaultInterval}}
+	}
+	// The changes are watched from the initial updates on
+	var changes <-chan struct{}
+	if list.Mode == gpb.SubscriptionList_STREAM {
+		var stop func()
+		changes, stop = s.srv.Watch()
+		defer stop()
+	}
+	if err := s.report(stream, subs, list.Encoding, !list.UpdatesOnly); err != nil {
+		return err
+	}
+	switch list.Mode {
+	case gpb.SubscriptionList_ONCE:
+		return nil
+	case gpb.SubscriptionList_POLL:
+		for {
+			req, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if req.GetPoll() == nil {
+				return status.Error(codes.InvalidArgument, "a subscription in mode POLL takes polls only")
+			}
+			if err := s.report(stream, subs, list.Encoding, true); err != nil {
+				return err
+			}
+		}
+	}
+	return s.stream(stream, subs, list.Encoding, changes)
+}
+
+// Send the updates of all the leaves of the subscriptions, unless only
+// the sync response that follows them is sent
+func (s *Server) report(stream gpb.GNMI_SubscribeServer, subs []*subscription, enc gpb.Encoding, send bool) error {
+	data, err := s.srv.Get()
+	if err != nil {
+		return toStatus(err)
+	}
+	t := time.Now()
+	for _, sub := range subs {
+		n, err := sub.changes(data, enc, t, true)
+		if err != nil {
+			return err
+		}
+		if send && n != nil {
+			if err := stream.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_Update{Update: n}}); err != nil {
+				return err
+			}
+		}
+	}
+	return stream.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_SyncResponse{SyncResponse: true}})
+}
+
+// Report the changes and the samples until the client ends the stream
+func (s *Server) stream(stream gpb.GNMI_SubscribeServer, subs []*subscription, enc gpb.Encoding, changes <-chan struct{}) error {
+	ctx := stream.Context()
+	samples := make(chan *subscription)
+	for _, sub := range subs {
+		if sub.mode != gpb.SubscriptionMode_SAMPLE {
+			continue
+		}
+		go func(sub *subscription) {
+			t := time.NewTicker(sub.interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case samples <- sub:
+				}
+			}
+		}(sub)
+	}
+	// The client may only close its side
+	go func() {
+		for {
+			if _, err := stream.Recv(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		var ready []*subscription
+		all := false
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+			for _, sub := range subs {
+				if sub.mode != gpb.SubscriptionMode_SAMPLE {
+					ready = append(ready, sub)
+				}
+			}
+		case sub := <-samples:
+			ready, all = []*subscription{sub}, !sub.suppress
+		}
+		data, err := s.srv.Get()
+		if err != nil {
+			return toStatus(err)
+		}
+		t := time.Now()
+		for _, sub := range ready {
+			n, err := sub.changes(data, enc, t, all)
+			if err != nil {
+				return err
+			}
+			if n == nil {
+				continue
+			}
+			if err := stream.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_Update{Update: n}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// A subscription with the values of the leaves last sent
+type subscription struct {
+	path     nc.Path
+	mode     gpb.SubscriptionMode
+	interval time.Duration
+	suppress bool
+	sent     map[string]leaf
+}
+
+// A leaf sent with its value
+type leaf struct {
+	path nc.Path
+	val  *gpb.TypedValue
+}
+
+// The notification of the leaves of the subscription changed since those
+// sent, all of them if all, and of those gone. It is nil if there are none.
+func (sub *subscription) changes(data interface{}, enc gpb.Encoding, t time.Time, all bool) (*gpb.Notification, error) {
+	found, err := nodes(data, sub.path)
+	if err != nil && status.Code(toStatus(err)) != codes.NotFound {
+		return nil, toStatus(err)
+	}
+	n := &gpb.Notification{Timestamp: t.UnixNano()}
+	now := map[string]leaf{}
+	for _, np := range found {
+		node, _ := nc.Find(data, np)
+		err := nc.Leaves(node, np, func(lp nc.Path, value interface{}) error {
+			ns := lp[len(lp)-1].Namespace
+			var tv *gpb.TypedValue
+			var err error
+			if enc == gpb.Encoding_JSON_IETF || enc == gpb.Encoding_JSON {
+				tv, err = encodeJSON(value, ns, enc)
+			} else {
+				tv, err = TypedValue(value, ns)
+			}
+			if err != nil {
+				return status.Errorf(codes.Internal, "%s: %v", lp, err)
+			}
+			key := lp.String()
+			if old, ok := sub.sent[key]; all || !ok || !proto.Equal(old.val, tv) {
+				n.Update = append(n.Update, &gpb.Update{Path: Path(lp), Val: tv})
+			}
+			now[key] = leaf{lp, tv}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(sub.sent)) {
+		if _, ok := now[key]; !ok {
+			n.Delete = append(n.Delete, Path(sub.sent[key].path))
+		}
+	}
+	sub.sent = now
+	if len(n.Update) == 0 && len(n.Delete) == 0 {
+		return nil, nil
+	}
+	return n, nil
+}
+
+// The paths of the nodes of a path present in data. A list without keys
+// at the end of the path stands for all its entries.
+func nodes(data interface{}, p nc.Path) ([]nc.Path, error) {
+	p, err := nc.ResolvePath(data, p)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := nc.Find(data, p); ok {
+		return []nc.Path{p}, nil
+	}
+	var found []nc.Path
+	if len(p) > 0 && len(p[len(p)-1].Keys) == 0 {
+		parent := p[:len(p)-1]
+		e := p[len(p)-1]
+		if v, ok := nc.Find(data, parent); ok {
+			nc.Leaves(v, parent, func(lp nc.Path, _ interface{}) error {
+				le := lp[len(parent)]
+				if le.Name != e.Name || le.Namespace != e.Namespace || len(le.Keys) == 0 {
+					return nil
+				}
+				entry := append(nc.Path(nil), lp[:len(p)]...)
+				if len(found) == 0 || found[len(found)-1].String() != entry.String() {
+					found = append(found, entry)
+				}
+				return nil
+			})
+		}
+	}
+	if len(found) == 0 {
+		return nil, &nc.RPCError{Type: nc.ErrApplication, Tag: "data-missing", Severity: "error", Path: p.String(), Message: "the node doesn't exist"}
+	}
+	return found, nil
+}
+
+func parsePath(prefix, p *gpb.Path) (nc.Path, error) {
+	r, err := ParsePath(prefix, p)
+	if err != nil {
+		return nil, &nc.RPCError{Type: nc.ErrProtocol, Tag: "invalid-value", Severity: "error", Message: err.Error()}
+	}
+	return r, nil
+}
+
+// The encodings of Get and Subscribe. JSON, the default, is the same JSON
+// of RFC 7951 as JSON_IETF.
+func checkEncoding(enc gpb.Encoding) error {
+	if enc != gpb.Encoding_JSON && enc != gpb.Encoding_JSON_IETF && enc != gpb.Encoding_PROTO {
+		return status.Errorf(codes.Unimplemented, "the encoding %s isn't supported", enc)
+	}
+	return nil
+}
+
+// The status of an error of the datastore by the error-tag of the first
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	errs := server.RPCErrors(err)
+	code := codes.FailedPrecondition
+	switch errs[0].Tag {
+	case "invalid-value", "unknown-element", "bad-element", "missing-element", "unknown-namespace", "malformed-message":
+		code = codes.InvalidArgument
+	case "data-missing":
+		code = codes.NotFound
+	case "data-exists":
+		code = codes.AlreadyExists
+	case "in-use", "lock-denied":
+		code = codes.Aborted
+	case "access-denied":
+		code = codes.PermissionDenied
+	case "operation-not-supported":
+		code = codes.Unimplemented
+	case "resource-denied", "too-big":
+		code = codes.ResourceExhausted
+	}
+	return status.Error(code, errs.Error())
+}
@@ -0,0 +1,189 @@
+package gnmi
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"nc/internal/yang"
+	"nc/nc"
+	"nc/server"
+)
+
+// A client of the gNMI server of a device served on a local listener
+func testClient(t *testing.T) (gpb.GNMIClient, *server.Server) {
+	srv := server.New(&yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Hostname_Prsnt: true, Hostname: "r1", Mtu_Prsnt: true, Mtu: 1500}})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	gpb.RegisterGNMIServer(gs, NewServer(srv))
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return gpb.NewGNMIClient(conn), srv
+}
+
+func systemPath(elems ...string) *gpb.Path {
+	p := &gpb.Path{Elem: []*gpb.PathElem{{Name: "test:system"}}}
+	for _, e := range elems {
+		p.Elem = append(p.Elem, &gpb.PathElem{Name: e})
+	}
+	return p
+}
+
+func TestCapabilities(t *testing.T) {
+	c, _ := testClient(t)
+	resp, err := c.Capabilities(context.Background(), &gpb.CapabilityRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_PROTO}
+	if len(resp.SupportedEncodings) != len(want) {
+		t.Fatalf("encodings %v, want %v", resp.SupportedEncodings, want)
+	}
+	for i, e := range want {
+		if resp.SupportedEncodings[i] != e {
+			t.Errorf("encodings %v, want %v", resp.SupportedEncodings, want)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	c, _ := testClient(t)
+	ctx := context.Background()
+	// JSON is the default encoding
+	for _, enc := range []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF} {
+		resp, err := c.Get(ctx, &gpb.GetRequest{Path: []*gpb.Path{systemPath()}, Encoding: enc})
+		if err != nil {
+			t.Fatalf("%s: %v", enc, err)
+		}
+		val := resp.Notification[0].Update[0].Val
+		b := val.GetJsonIetfVal()
+		if enc == gpb.Encoding_JSON {
+			b = val.GetJsonVal()
+		}
+		if got := string(b); !strings.Contains(got, `"hostname":"r1"`) || !strings.Contains(got, `"mtu":1500`) {
+			t.Errorf("%s: value %v", enc, val)
+		}
+	}
+	resp, err := c.Get(ctx, &gpb.GetRequest{Path: []*gpb.Path{systemPath()}, Encoding: gpb.Encoding_PROTO})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]*gpb.TypedValue{}
+	for _, u := range resp.Notification[0].Update {
+		values[u.Path.Elem[0].Name] = u.Val
+	}
+	if values["hostname"].GetStringVal() != "r1" || values["mtu"].GetUintVal() != 1500 {
+		t.Errorf("PROTO: values %v", values)
+	}
+	_, err = c.Get(ctx, &gpb.GetRequest{Path: []*gpb.Path{systemPath()}, Encoding: gpb.Encoding_ASCII})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("ASCII: %v, want Unimplemented", err)
+	}
+	_, err = c.Get(ctx, &gpb.GetRequest{Path: []*gpb.Path{{Elem: []*gpb.PathElem{{Name: "nope:x"}}}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown module: %v, want InvalidArgument", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	c, srv := testClient(t)
+	ctx := context.Background()
+	_, err := c.Set(ctx, &gpb.SetRequest{
+		Update: []*gpb.Update{{Path: systemPath("hostname"), Val: &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "r2"}}}},
+		Delete: []*gpb.Path{systemPath("mtu")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys := srv.Datastore(nc.Running).(*yang.Device).T_system
+	if sys.Hostname != "r2" || sys.Mtu_Prsnt {
+		t.Errorf("running %+v", sys)
+	}
+	_, err = c.Set(ctx, &gpb.SetRequest{
+		Update: []*gpb.Update{{Path: systemPath("mtu"), Val: &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "big"}}}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid value: %v, want InvalidArgument", err)
+	}
+}
+
+func TestSubscribeOnce(t *testing.T) {
+	c, _ := testClient(t)
+	for _, enc := range []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_PROTO} {
+		stream, err := c.Subscribe(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = stream.Send(&gpb.SubscribeRequest{Request: &gpb.SubscribeRequest_Subscribe{Subscribe: &gpb.SubscriptionList{
+			Mode:         gpb.SubscriptionList_ONCE,
+			Encoding:     enc,
+			Subscription: []*gpb.Subscription{{Path: systemPath("hostname")}},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var updates []*gpb.Update
+		synced := false
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", enc, err)
+			}
+			if resp.GetSyncResponse() {
+				synced = true
+			}
+			updates = append(updates, resp.GetUpdate().GetUpdate()...)
+		}
+		if !synced || len(updates) != 1 {
+			t.Fatalf("%s: synced %v, updates %v", enc, synced, updates)
+		}
+		val := updates[0].Val
+		var got string
+		switch enc {
+		case gpb.Encoding_JSON:
+			got = string(val.GetJsonVal())
+		case gpb.Encoding_JSON_IETF:
+			got = string(val.GetJsonIetfVal())
+		default:
+			got = `"` + val.GetStringVal() + `"`
+		}
+		if got != `"r1"` {
+			t.Errorf("%s: value %v", enc, val)
+		}
+	}
+}
+
+func TestSubscribeEncoding(t *testing.T) {
+	c, _ := testClient(t)
+	stream, err := c.Subscribe(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Send(&gpb.SubscribeRequest{Request: &gpb.SubscribeRequest_Subscribe{Subscribe: &gpb.SubscriptionList{
+		Mode:     gpb.SubscriptionList_ONCE,
+		Encoding: gpb.Encoding_BYTES,
+	}}})
+	if _, err := stream.Recv(); status.Code(err) != codes.Unimplemented {
+		t.Errorf("BYTES: %v, want Unimplemented", err)
+	}
+}
@@ -0,0 +1,17 @@
+module nc
+
+go 1.23.1
+
+require (
+	github.com/openconfig/gnmi v0.14.1
+	golang.org/x/crypto v0.40.0
+	google.golang.org/grpc v1.69.2
+	google.golang.org/protobuf v1.36.2
+)
+
+require (
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250106144421-5f5ef82da422 // indirect
+)
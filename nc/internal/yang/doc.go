@@ -0,0 +1,5 @@
+// Package yang is the code generated of the module test of testdata on
+// which the tests of the runtime, the servers and the client run.
+package yang
+
+//go:generate sh -c "cd ../../../generator && go run . -i ../nc/internal/yang/testdata -s ../nc/internal/yang/testdata -o ../nc/internal -p yang && gofmt -w ../nc/internal/yang"
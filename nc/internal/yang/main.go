@@ -0,0 +1,113 @@
+package yang
+
+import (
+	nc "nc/nc"
+)
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  Device
+//	Description:
+//	  The data of the device made of the top level data nodes
+//	  of all the modules. It is encoded as <data> of netconf
+//
+// -------------------------------------------------------------
+type Device struct {
+	XMLName        nc.XmlId      `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 data"`
+	T_system_Prsnt bool          `xml:",presfield"`
+	T_system       T_system_cont `xml:"urn:test system"`
+	T_top_Prsnt    bool          `xml:",presfield"`
+	T_top          T_top_cont    `xml:"urn:test top"`
+	T_item         []T_item      `xml:"urn:test item"`
+}
+
+func (x *Device) validate(path string, errs *nc.ValidationErrors) {
+	if x.T_system_Prsnt {
+		x.T_system.validate(path+"/test:system", errs)
+	}
+	if x.T_top_Prsnt {
+		x.T_top.validate(path+"/test:top", errs)
+	}
+	for i := range x.T_item {
+		x.T_item[i].validate(nc.EntryPath(path+"/test:item", &x.T_item[i], i), errs)
+	}
+}
+func (x *Device) setDefaults() bool {
+	set := false
+	if x.T_system.setDefaults() {
+		x.T_system_Prsnt = true
+		set = true
+	}
+	if x.T_top.setDefaults() {
+		x.T_top_Prsnt = true
+		set = true
+	}
+	for i := range x.T_item {
+		set = x.T_item[i].setDefaults() || set
+	}
+	return set
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *Device) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x Device) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate("", &errs)
+	nc.CheckConstraints(&x, "", &errs)
+	return errs.Err()
+}
+func (x *Device) GetT_item(k T_item_listkey) *T_item {
+	for i := range x.T_item {
+		if x.T_item[i].ListKey().Equal(k) {
+			return &x.T_item[i]
+		}
+	}
+	return nil
+}
+func (x *Device) SetT_item(e T_item) {
+	e.Name_Prsnt = true
+	k := e.ListKey()
+	for i := range x.T_item {
+		if x.T_item[i].ListKey().Equal(k) {
+			x.T_item[i] = e
+			return
+		}
+	}
+	x.T_item = append(x.T_item, e)
+}
+func (x *Device) DeleteT_item(k T_item_listkey) bool {
+	for i := range x.T_item {
+		if x.T_item[i].ListKey().Equal(k) {
+			x.T_item = append(x.T_item[:i], x.T_item[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Device_path is the path of the top of the data tree
+type Device_path struct {
+	nc.Path
+}
+
+// Root returns the path of the top of the data tree
+func Root() Device_path {
+	return Device_path{}
+}
+func (p Device_path) T_system() T_system_cont_path {
+	return T_system_cont_path{p.Child(Test_ns, "system")}
+}
+func (p Device_path) T_top() T_top_cont_path {
+	return T_top_cont_path{p.Child(Test_ns, "top")}
+}
+func (p Device_path) T_item(item_name string) T_item_path {
+	return T_item_path{p.Child(Test_ns, "item", nc.KeyValue{Name: "name", Value: item_name})}
+}
+func (p Device_path) T_item_any() T_item_path {
+	return T_item_path{p.Child(Test_ns, "item")}
+}
@@ -0,0 +1,1037 @@
+package yang
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	nc "nc/nc"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------
+//
+//	Module Name:
+//	  test
+//	Description:
+//	  The data of the tests of the runtime
+//
+// Revisions:
+//
+//	2024-01-01
+//	     initial
+//
+// -------------------------------------------------------------
+var Test_ns = "urn:test"
+var Test_prefix = "t"
+
+const Test_sid = 60000
+const T_proto_identity_sid = 60001
+const T_tcp_identity_sid = 60002
+const T_udp_identity_sid = 60003
+const T_system_sid = 60004
+const T_system_hostname_sid = 60005
+const T_system_mtu_sid = 60006
+const T_system_enabled_sid = 60007
+const T_system_counter_sid = 60008
+const T_system_offset_sid = 60009
+const T_system_level_sid = 60010
+const T_system_gain_sid = 60011
+const T_system_color_sid = 60012
+const T_system_flags_sid = 60013
+const T_system_proto_sid = 60014
+const T_system_data_sid = 60015
+const T_system_active_sid = 60016
+const T_system_id_sid = 60017
+const T_system_dns_sid = 60018
+const T_system_timers_sid = 60019
+const T_system_timers_hello_sid = 60020
+const T_system_timers_dead_sid = 60021
+const T_system_debug_sid = 60022
+const T_system_debug_level_sid = 60023
+const T_system_auto_sid = 60024
+const T_system_fixed_sid = 60025
+const T_system_stats_sid = 60026
+const T_system_stats_in_sid = 60027
+const T_top_sid = 60028
+const T_top_intf_sid = 60029
+const T_top_intf_name_sid = 60030
+const T_top_intf_mtu_sid = 60031
+const T_top_ref_sid = 60032
+const T_item_sid = 60033
+const T_item_name_sid = 60034
+const T_item_value_sid = 60035
+const T_reset_sid = 60036
+const T_reset_input_delay_sid = 60037
+const T_reset_output_status_sid = 60038
+const T_alarm_sid = 60039
+const T_alarm_severity_sid = 60040
+const T_alarm_text_sid = 60041
+
+var Test_capability = "urn:test?module=test&revision=2024-01-01"
+var Test_revision = "2024-01-01"
+var Test_xpath_prefixes = map[string]string{
+	"t": "urn:test",
+}
+
+// -----------------------------------------------------
+// Dummy code to avoid careful insertion of imports
+var Test_strings = strings.HasSuffix("dummy", "d")
+var Test_re = regexp.MustCompile("dummy")
+var Test_xy = strconv.FormatInt(10, 10)
+var Test_math = math.Abs(10.0)
+var Test_err = fmt.Errorf("dummy")
+var Test_base64 = base64.StdEncoding
+
+//-----------------------------------------------------
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  identity: proto
+//	Description:
+//	  A protocol
+//
+// -------------------------------------------------------------
+type T_proto_id string
+
+var T_proto_id_prefix_map = map[string]string{}
+var T_proto_id_ns_map = map[string]string{}
+
+func (x T_proto_id) MarshalText(ns string) ([]byte, error) {
+	prefix, ok := T_proto_id_prefix_map[string(x)]
+	if ok {
+		prefix = prefix + ":"
+	}
+	return []byte(prefix + string(x)), nil
+}
+func (x *T_proto_id) UnmarshalText(ns string, b []byte) error {
+	var name string
+	s := string(b)
+	parts := strings.Split(s, ":")
+	if len(parts) == 1 {
+		name = parts[0]
+	} else {
+		name = parts[1]
+	}
+	if _, ok := T_proto_id_ns_map[name]; !ok {
+		return fmt.Errorf("Invalid T_proto_id : %s", s)
+	}
+	*x = T_proto_id(name)
+	return nil
+}
+func (x T_proto_id) RuntimeNs() string {
+	if ns, ok := T_proto_id_ns_map[string(x)]; ok {
+		if prefix, ok := T_proto_id_prefix_map[string(x)]; ok {
+			return prefix + "!" + ns
+		} else {
+			return ns
+		}
+	}
+	return Test_ns
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  typedef: color
+//	Description:
+//
+// -------------------------------------------------------------
+type T_color int
+
+const (
+	T_color_Red   T_color = 0
+	T_color_Green T_color = 5
+	T_color_Blue  T_color = 6
+)
+
+var T_color_to_string = map[T_color]string{
+	T_color_Red:   "red",
+	T_color_Green: "green",
+	T_color_Blue:  "blue",
+}
+var string_to_T_color = map[string]T_color{
+	"red":   T_color_Red,
+	"green": T_color_Green,
+	"blue":  T_color_Blue,
+}
+
+func (x T_color) MarshalText(ns string) ([]byte, error) {
+	if s, ok := T_color_to_string[x]; ok {
+		return []byte(s), nil
+	}
+	return nil, fmt.Errorf("Invalid value for T_color")
+}
+func (x *T_color) UnmarshalText(ns string, b []byte) error {
+	if v, ok := string_to_T_color[string(b)]; ok {
+		*x = v
+		return nil
+	}
+	return fmt.Errorf("Invalid value for T_color")
+}
+func (x T_color) RuntimeNs() string {
+	return Test_ns
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  container: system
+//	Description:
+//
+// -------------------------------------------------------------
+type T_system_cont struct {
+	XMLOp          nc.Operation         `xml:",operation"`
+	Timers_Prsnt   bool                 `xml:",presfield"`
+	Timers         T_system_timers_cont `xml:"timers"`
+	Debug_Prsnt    bool                 `xml:",presfield"`
+	Debug          T_system_debug_cont  `xml:"debug"`
+	Stats_Prsnt    bool                 `xml:",presfield"`
+	Stats          T_system_stats_cont  `xml:"stats"`
+	Hostname_Prsnt bool                 `xml:",presfield"`
+	Hostname       string               `xml:"hostname"`
+	Mtu_Prsnt      bool                 `xml:",presfield"`
+	Mtu            T_system_mtu         `xml:"mtu"`
+	Enabled_Prsnt  bool                 `xml:"enabled,presfield"`
+	Counter_Prsnt  bool                 `xml:",presfield"`
+	Counter        uint64               `xml:"counter"`
+	Offset_Prsnt   bool                 `xml:",presfield"`
+	Offset         int64                `xml:"offset"`
+	Level_Prsnt    bool                 `xml:",presfield"`
+	Level          int8                 `xml:"level"`
+	Gain_Prsnt     bool                 `xml:",presfield"`
+	Gain           T_system_gain        `xml:"gain"`
+	Color_Prsnt    bool                 `xml:",presfield"`
+	Color          T_color              `xml:"color"`
+	Flags_Prsnt    bool                 `xml:",presfield"`
+	Flags          T_system_flags       `xml:"flags"`
+	Proto_Prsnt    bool                 `xml:",presfield"`
+	Proto          T_proto_id           `xml:"proto"`
+	Data_Prsnt     bool                 `xml:",presfield"`
+	Data           T_system_data        `xml:"data"`
+	Active_Prsnt   bool                 `xml:",presfield"`
+	Active         bool                 `xml:"active"`
+	Id_Prsnt       bool                 `xml:",presfield"`
+	Id             T_system_id          `xml:"id"`
+	Dns            []string             `xml:"dns"`
+	Speed_Prsnt    bool                 `xml:",presfield"`
+	Speed          T_system_speed       `xml:"speed,inline"`
+}
+
+func (x T_system_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x *T_system_cont) validate(path string, errs *nc.ValidationErrors) {
+	if x.Timers_Prsnt {
+		x.Timers.validate(path+"/timers", errs)
+	}
+	if x.Debug_Prsnt {
+		x.Debug.validate(path+"/debug", errs)
+	}
+	if x.Stats_Prsnt {
+		x.Stats.validate(path+"/stats", errs)
+	}
+	if x.Speed_Prsnt {
+		x.Speed.validate(path, errs)
+	}
+}
+func (x *T_system_cont) setDefaults() bool {
+	set := false
+	if x.Timers.setDefaults() {
+		x.Timers_Prsnt = true
+		set = true
+	}
+	if x.Debug_Prsnt {
+		set = x.Debug.setDefaults() || set
+	}
+	if x.Stats.setDefaults() {
+		x.Stats_Prsnt = true
+		set = true
+	}
+	if !x.Mtu_Prsnt && nc.SetDefault(&x.Mtu, "1500") == nil {
+		x.Mtu_Prsnt = true
+		set = true
+	}
+	if !x.Color_Prsnt && nc.SetDefault(&x.Color, "green") == nil {
+		x.Color_Prsnt = true
+		set = true
+	}
+	if x.Speed.setDefaults() {
+		x.Speed_Prsnt = true
+		set = true
+	}
+	return set
+}
+
+var T_system_cont_defaults = map[string][]string{
+	"Mtu":   {"1500"},
+	"Color": {"green"},
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_system_cont) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_system_cont) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate("/test:system", &errs)
+	nc.CheckConstraints(&x, "/test:system", &errs)
+	return errs.Err()
+}
+
+// T_system_cont_path is the path of a node of type T_system_cont
+type T_system_cont_path struct {
+	nc.Path
+}
+
+func (p T_system_cont_path) Timers() T_system_timers_cont_path {
+	return T_system_timers_cont_path{p.Child("", "timers")}
+}
+func (p T_system_cont_path) Debug() T_system_debug_cont_path {
+	return T_system_debug_cont_path{p.Child("", "debug")}
+}
+func (p T_system_cont_path) Stats() T_system_stats_cont_path {
+	return T_system_stats_cont_path{p.Child("", "stats")}
+}
+func (p T_system_cont_path) Hostname() nc.Path {
+	return p.Child("", "hostname")
+}
+func (p T_system_cont_path) Mtu() nc.Path {
+	return p.Child("", "mtu")
+}
+func (p T_system_cont_path) Enabled() nc.Path {
+	return p.Child("", "enabled")
+}
+func (p T_system_cont_path) Counter() nc.Path {
+	return p.Child("", "counter")
+}
+func (p T_system_cont_path) Offset() nc.Path {
+	return p.Child("", "offset")
+}
+func (p T_system_cont_path) Level() nc.Path {
+	return p.Child("", "level")
+}
+func (p T_system_cont_path) Gain() nc.Path {
+	return p.Child("", "gain")
+}
+func (p T_system_cont_path) Color() nc.Path {
+	return p.Child("", "color")
+}
+func (p T_system_cont_path) Flags() nc.Path {
+	return p.Child("", "flags")
+}
+func (p T_system_cont_path) Proto() nc.Path {
+	return p.Child("", "proto")
+}
+func (p T_system_cont_path) Data() nc.Path {
+	return p.Child("", "data")
+}
+func (p T_system_cont_path) Active() nc.Path {
+	return p.Child("", "active")
+}
+func (p T_system_cont_path) Id() nc.Path {
+	return p.Child("", "id")
+}
+func (p T_system_cont_path) Dns() nc.Path {
+	return p.Child("", "dns")
+}
+func (p T_system_cont_path) Auto() nc.Path {
+	return p.Child("", "auto")
+}
+func (p T_system_cont_path) Fixed() nc.Path {
+	return p.Child("", "fixed")
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  container: timers
+//	Description:
+//
+// -------------------------------------------------------------
+type T_system_timers_cont struct {
+	XMLOp       nc.Operation `xml:",operation"`
+	Hello_Prsnt bool         `xml:",presfield"`
+	Hello       uint16       `xml:"hello"`
+	Dead_Prsnt  bool         `xml:",presfield"`
+	Dead        uint16       `xml:"dead"`
+}
+
+func (x T_system_timers_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x *T_system_timers_cont) validate(path string, errs *nc.ValidationErrors) {
+}
+func (x *T_system_timers_cont) setDefaults() bool {
+	set := false
+	if !x.Hello_Prsnt && nc.SetDefault(&x.Hello, "10") == nil {
+		x.Hello_Prsnt = true
+		set = true
+	}
+	return set
+}
+
+var T_system_timers_cont_defaults = map[string][]string{
+	"Hello": {"10"},
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_system_timers_cont) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_system_timers_cont) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate("/test:system/timers", &errs)
+	nc.CheckConstraints(&x, "/test:system/timers", &errs)
+	return errs.Err()
+}
+
+// T_system_timers_cont_path is the path of a node of type T_system_timers_cont
+type T_system_timers_cont_path struct {
+	nc.Path
+}
+
+func (p T_system_timers_cont_path) Hello() nc.Path {
+	return p.Child("", "hello")
+}
+func (p T_system_timers_cont_path) Dead() nc.Path {
+	return p.Child("", "dead")
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  container: debug
+//	Description:
+//
+// -------------------------------------------------------------
+type T_system_debug_cont struct {
+	XMLOp       nc.Operation `xml:",operation"`
+	Level_Prsnt bool         `xml:",presfield"`
+	Level       uint8        `xml:"level"`
+}
+
+func (x T_system_debug_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x *T_system_debug_cont) validate(path string, errs *nc.ValidationErrors) {
+}
+func (x *T_system_debug_cont) setDefaults() bool {
+	set := false
+	if !x.Level_Prsnt && nc.SetDefault(&x.Level, "1") == nil {
+		x.Level_Prsnt = true
+		set = true
+	}
+	return set
+}
+
+var T_system_debug_cont_defaults = map[string][]string{
+	"Level": {"1"},
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_system_debug_cont) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_system_debug_cont) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate("/test:system/debug", &errs)
+	nc.CheckConstraints(&x, "/test:system/debug", &errs)
+	return errs.Err()
+}
+
+// T_system_debug_cont_path is the path of a node of type T_system_debug_cont
+type T_system_debug_cont_path struct {
+	nc.Path
+}
+
+func (p T_system_debug_cont_path) Level() nc.Path {
+	return p.Child("", "level")
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  container: stats
+//	Description:
+//
+// -------------------------------------------------------------
+type T_system_stats_cont struct {
+	XMLOp    nc.Operation `xml:",operation"`
+	In_Prsnt bool         `xml:",presfield"`
+	In       uint64       `xml:"in"`
+}
+
+func (x T_system_stats_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x *T_system_stats_cont) validate(path string, errs *nc.ValidationErrors) {
+}
+func (x *T_system_stats_cont) setDefaults() bool {
+	set := false
+	return set
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_system_stats_cont) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_system_stats_cont) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate("/test:system/stats", &errs)
+	nc.CheckConstraints(&x, "/test:system/stats", &errs)
+	return errs.Err()
+}
+
+// T_system_stats_cont_path is the path of a node of type T_system_stats_cont
+type T_system_stats_cont_path struct {
+	nc.Path
+}
+
+func (p T_system_stats_cont_path) In() nc.Path {
+	return p.Child("", "in")
+}
+
+type T_system_mtu uint16
+
+func (x T_system_mtu) MarshalText(ns string) ([]byte, error) {
+	if !(x >= 68 && x <= 9000) {
+		return nil, fmt.Errorf("Invalid value %d for T_system_mtu", x)
+	}
+	return []byte(strconv.FormatUint(uint64(x), 10)), nil
+}
+func (x *T_system_mtu) UnmarshalText(ns string, b []byte) error {
+	v, err := strconv.ParseUint(string(b), 10, 16)
+	if err != nil {
+		return fmt.Errorf("Invalid value %s for T_system_mtu", string(b))
+	}
+	if !(v >= 68 && v <= 9000) {
+		return fmt.Errorf("Invalid value %s for T_system_mtu", string(b))
+	}
+	*x = T_system_mtu(v)
+	return nil
+}
+
+type T_system_gain int64
+
+const T_system_gain_fraction_digits = 2
+
+func (x T_system_gain) FractionDigits() int {
+	return T_system_gain_fraction_digits
+}
+func (x T_system_gain) MarshalText(ns string) ([]byte, error) {
+	sign := ""
+	v := uint64(x)
+	if x < 0 {
+		sign = "-"
+		v = uint64(-x)
+	}
+	s := fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+	return []byte(s), nil
+}
+func (x *T_system_gain) UnmarshalText(ns string, b []byte) error {
+	s := string(b)
+	ip, fp, dot := strings.Cut(s, ".")
+	digits := strings.TrimLeft(ip, "+-")
+	if digits == "" || len(ip)-len(digits) > 1 || (dot && fp == "") || len(fp) > 2 {
+		return fmt.Errorf("Invalid value %s for T_system_gain", s)
+	}
+	if strings.ContainsAny(fp, "+-") {
+		return fmt.Errorf("Invalid value %s for T_system_gain", s)
+	}
+	v, err := strconv.ParseInt(ip+fp+strings.Repeat("0", 2-len(fp)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid value %s for T_system_gain", s)
+	}
+	*x = T_system_gain(v)
+	return nil
+}
+
+type T_system_flags uint64
+
+const (
+	T_system_flags_Up   T_system_flags = 1 << 0
+	T_system_flags_Down T_system_flags = 1 << 3
+	T_system_flags_Wide T_system_flags = 1 << 9
+)
+
+var T_system_flags_to_string = map[T_system_flags]string{
+	T_system_flags_Up:   "up",
+	T_system_flags_Down: "down",
+	T_system_flags_Wide: "wide",
+}
+var string_to_T_system_flags = map[string]T_system_flags{
+	"up":   T_system_flags_Up,
+	"down": T_system_flags_Down,
+	"wide": T_system_flags_Wide,
+}
+var T_system_flags_order = []T_system_flags{
+	T_system_flags_Up,
+	T_system_flags_Down,
+	T_system_flags_Wide,
+}
+
+func (x T_system_flags) MarshalText(ns string) ([]byte, error) {
+	var names []string
+	var all T_system_flags
+	for _, bit := range T_system_flags_order {
+		if x&bit != 0 {
+			names = append(names, T_system_flags_to_string[bit])
+		}
+		all |= bit
+	}
+	if x&^all != 0 {
+		return nil, fmt.Errorf("Invalid value %#x for T_system_flags", uint64(x))
+	}
+	return []byte(strings.Join(names, " ")), nil
+}
+func (x *T_system_flags) UnmarshalText(ns string, b []byte) error {
+	var v T_system_flags
+	for _, name := range strings.Fields(string(b)) {
+		bit, ok := string_to_T_system_flags[name]
+		if !ok {
+			return fmt.Errorf("Invalid bit %s for T_system_flags", name)
+		}
+		v |= bit
+	}
+	*x = v
+	return nil
+}
+func (x *T_system_flags) Set(bits T_system_flags) {
+	*x |= bits
+}
+func (x *T_system_flags) Clear(bits T_system_flags) {
+	*x &^= bits
+}
+func (x T_system_flags) Has(bits T_system_flags) bool {
+	return x&bits == bits
+}
+func (x T_system_flags) Bits() uint64 {
+	return uint64(x)
+}
+
+type T_system_data []byte
+
+func (x T_system_data) MarshalText(ns string) ([]byte, error) {
+	s := base64.StdEncoding.EncodeToString(x)
+	return []byte(s), nil
+}
+func (x *T_system_data) UnmarshalText(ns string, b []byte) error {
+	v, err := base64.StdEncoding.DecodeString(string(b))
+	*x = T_system_data(v)
+	return err
+}
+
+type T_system_id struct {
+	Uint8_0_Prsnt  bool                       `xml:",presfield"`
+	Uint8_0        T_system_id_union_uint8_0  `xml:"-"`
+	String_1_Prsnt bool                       `xml:",presfield"`
+	String_1       T_system_id_union_string_1 `xml:"-"`
+}
+
+func (x T_system_id) MarshalText(ns string) ([]byte, error) {
+	if x.Uint8_0_Prsnt {
+		return x.Uint8_0.MarshalText(ns)
+	}
+	if x.String_1_Prsnt {
+		return x.String_1.MarshalText(ns)
+	}
+	return nil, fmt.Errorf("Invalid T_system_id")
+}
+func (x *T_system_id) UnmarshalText(ns string, b []byte) error {
+	if err := (&x.Uint8_0).UnmarshalText(ns, b); err == nil {
+		x.Uint8_0_Prsnt = true
+		return nil
+	}
+	if err := (&x.String_1).UnmarshalText(ns, b); err == nil {
+		x.String_1_Prsnt = true
+		return nil
+	}
+	return fmt.Errorf("Invalid T_system_id: %s", string(b))
+}
+
+/* Generating type for uint8 -parent type */
+type T_system_id_union_uint8_0 uint8
+
+func (x T_system_id_union_uint8_0) MarshalText(ns string) ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(x), 10)), nil
+}
+func (x *T_system_id_union_uint8_0) UnmarshalText(ns string, b []byte) error {
+	v, err := strconv.ParseUint(string(b), 10, 8)
+	if err != nil {
+		return fmt.Errorf("Invalid value %s for T_system_id_union_uint8_0", string(b))
+	}
+	*x = T_system_id_union_uint8_0(v)
+	return nil
+}
+
+/* Generating type for string -parent type */
+type T_system_id_union_string_1 string
+
+func (x T_system_id_union_string_1) MarshalText(ns string) ([]byte, error) {
+	return []byte(x), nil
+}
+func (x *T_system_id_union_string_1) UnmarshalText(ns string, b []byte) error {
+	s := string(b)
+	*x = T_system_id_union_string_1(s)
+	return nil
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  choice: speed
+//	Description:
+//
+// -------------------------------------------------------------
+type T_system_speed struct {
+	Auto_Prsnt  bool   `xml:"auto,presfield"`
+	Fixed_Prsnt bool   `xml:",presfield"`
+	Fixed       uint32 `xml:"fixed"`
+}
+
+func (x T_system_speed) RuntimeNs() string {
+	return Test_ns
+}
+func (x *T_system_speed) validate(path string, errs *nc.ValidationErrors) {
+}
+func (x *T_system_speed) setDefaults() bool {
+	set := false
+	return set
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  container: top
+//	Description:
+//
+// -------------------------------------------------------------
+type T_top_cont struct {
+	XMLOp     nc.Operation `xml:",operation"`
+	Ref_Prsnt bool         `xml:",presfield"`
+	Ref       string       `xml:"ref"`
+	Intf      []T_top_intf `xml:"intf"`
+}
+
+func (x T_top_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x *T_top_cont) validate(path string, errs *nc.ValidationErrors) {
+	for i := range x.Intf {
+		x.Intf[i].validate(nc.EntryPath(path+"/intf", &x.Intf[i], i), errs)
+	}
+}
+
+var T_top_cont_constraints = []nc.Constraint{
+	{Field: "Ref", Kind: nc.Leafref, Expr: "../intf/name", Prefixes: Test_xpath_prefixes, RequireInstance: true},
+	{Field: "Intf", Kind: nc.Must, Expr: "count(../intf[mtu = current()/mtu]) = 1", Prefixes: Test_xpath_prefixes, AppTag: "mtu-unique"},
+}
+
+func (x *T_top_cont) setDefaults() bool {
+	set := false
+	for i := range x.Intf {
+		set = x.Intf[i].setDefaults() || set
+	}
+	return set
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_top_cont) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_top_cont) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate("/test:top", &errs)
+	nc.CheckConstraints(&x, "/test:top", &errs)
+	return errs.Err()
+}
+func (x *T_top_cont) GetIntf(k T_top_intf_listkey) *T_top_intf {
+	for i := range x.Intf {
+		if x.Intf[i].ListKey().Equal(k) {
+			return &x.Intf[i]
+		}
+	}
+	return nil
+}
+func (x *T_top_cont) SetIntf(e T_top_intf) {
+	e.Name_Prsnt = true
+	k := e.ListKey()
+	for i := range x.Intf {
+		if x.Intf[i].ListKey().Equal(k) {
+			x.Intf[i] = e
+			return
+		}
+	}
+	x.Intf = append(x.Intf, e)
+}
+func (x *T_top_cont) DeleteIntf(k T_top_intf_listkey) bool {
+	for i := range x.Intf {
+		if x.Intf[i].ListKey().Equal(k) {
+			x.Intf = append(x.Intf[:i], x.Intf[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// T_top_cont_path is the path of a node of type T_top_cont
+type T_top_cont_path struct {
+	nc.Path
+}
+
+func (p T_top_cont_path) Intf(intf_name string) T_top_intf_path {
+	return T_top_intf_path{p.Child("", "intf", nc.KeyValue{Name: "name", Value: intf_name})}
+}
+func (p T_top_cont_path) Intf_any() T_top_intf_path {
+	return T_top_intf_path{p.Child("", "intf")}
+}
+func (p T_top_cont_path) Ref() nc.Path {
+	return p.Child("", "ref")
+}
+
+type T_top_ref string
+type T_top_intf struct {
+	XMLOp      nc.Operation `xml:",operation"`
+	Name_Prsnt bool         `xml:",presfield"`
+	Name       string       `xml:"name"`
+	Mtu_Prsnt  bool         `xml:",presfield"`
+	Mtu        uint16       `xml:"mtu"`
+}
+
+var T_top_intf_listkeys = []string{"name"}
+
+type T_top_intf_listkey struct {
+	Name string
+}
+
+func (x T_top_intf) ListKeyNames() []string {
+	return T_top_intf_listkeys
+}
+func (x T_top_intf) ListKey() T_top_intf_listkey {
+	return T_top_intf_listkey{Name: x.Name}
+}
+func (k T_top_intf_listkey) Equal(o T_top_intf_listkey) bool {
+	return k.Name == o.Name
+}
+func (x *T_top_intf) validate(path string, errs *nc.ValidationErrors) {
+	if !x.Name_Prsnt {
+		errs.Add(path+"/name", "missing-leaf", "key leaf is missing")
+	}
+}
+func (x *T_top_intf) setDefaults() bool {
+	set := false
+	return set
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_top_intf) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_top_intf) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate(nc.EntryPath("/test:top/intf", &x, -1), &errs)
+	nc.CheckConstraints(&x, nc.EntryPath("/test:top/intf", &x, -1), &errs)
+	return errs.Err()
+}
+
+// T_top_intf_path is the path of a node of type T_top_intf
+type T_top_intf_path struct {
+	nc.Path
+}
+
+func (p T_top_intf_path) Name() nc.Path {
+	return p.Child("", "name")
+}
+func (p T_top_intf_path) Mtu() nc.Path {
+	return p.Child("", "mtu")
+}
+
+type T_item struct {
+	XMLOp       nc.Operation `xml:",operation"`
+	XMLInsert   nc.Insert    `xml:",insert"`
+	Name_Prsnt  bool         `xml:",presfield"`
+	Name        string       `xml:"name"`
+	Value_Prsnt bool         `xml:",presfield"`
+	Value       string       `xml:"value"`
+}
+
+var T_item_listkeys = []string{"name"}
+
+type T_item_listkey struct {
+	Name string
+}
+
+func (x T_item) ListKeyNames() []string {
+	return T_item_listkeys
+}
+func (x T_item) ListKey() T_item_listkey {
+	return T_item_listkey{Name: x.Name}
+}
+func (k T_item_listkey) Equal(o T_item_listkey) bool {
+	return k.Name == o.Name
+}
+func (x *T_item) validate(path string, errs *nc.ValidationErrors) {
+	if !x.Name_Prsnt {
+		errs.Add(path+"/name", "missing-leaf", "key leaf is missing")
+	}
+}
+func (x *T_item) setDefaults() bool {
+	set := false
+	return set
+}
+
+// SetDefaults sets the nodes within that are not present to their default
+func (x *T_item) SetDefaults() {
+	x.setDefaults()
+}
+
+// Validate checks the constraints of the schema on the data within
+func (x T_item) Validate() error {
+	var errs nc.ValidationErrors
+	x.validate(nc.EntryPath("/test:item", &x, -1), &errs)
+	nc.CheckConstraints(&x, nc.EntryPath("/test:item", &x, -1), &errs)
+	return errs.Err()
+}
+
+// T_item_path is the path of a node of type T_item
+type T_item_path struct {
+	nc.Path
+}
+
+func (p T_item_path) Name() nc.Path {
+	return p.Child("", "name")
+}
+func (p T_item_path) Value() nc.Path {
+	return p.Child("", "value")
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  rpc: reset
+//	Description:
+//
+// -------------------------------------------------------------
+var T_reset_rpc = nc.RpcInfo{
+	Name:      "reset",
+	Namespace: Test_ns,
+	Module:    "test",
+}
+
+type T_reset_input_cont struct {
+	XMLName     nc.XmlId `xml:"urn:test reset"`
+	Delay_Prsnt bool     `xml:",presfield"`
+	Delay       uint32   `xml:"delay"`
+}
+
+func (x T_reset_input_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x T_reset_input_cont) RpcInfo() nc.RpcInfo {
+	return T_reset_rpc
+}
+
+type T_reset_output_cont struct {
+	Status_Prsnt bool   `xml:",presfield"`
+	Status       string `xml:"status"`
+}
+
+func (x T_reset_output_cont) RuntimeNs() string {
+	return Test_ns
+}
+func (x T_reset_output_cont) RpcInfo() nc.RpcInfo {
+	return T_reset_rpc
+}
+
+// ------------------------------------------------------------
+//
+//	Name:
+//	  alarm
+//	Description:
+//
+// -------------------------------------------------------------
+type T_alarm_cont struct {
+	XMLName        nc.XmlId `xml:"urn:test alarm"`
+	Severity_Prsnt bool     `xml:",presfield"`
+	Severity       T_color  `xml:"severity"`
+	Text_Prsnt     bool     `xml:",presfield"`
+	Text           string   `xml:"text"`
+}
+
+func (x T_alarm_cont) RuntimeNs() string {
+	return Test_ns
+}
+func init() {
+	s := "dummy"
+	nc.Marshal(s)
+	nc.RegisterModule("test", Test_ns, Test_prefix)
+	nc.RegisterCapability(Test_capability)
+	nc.RegisterSid("test", Test_sid)
+	nc.RegisterSid("test:proto", T_proto_identity_sid)
+	nc.RegisterSid("test:tcp", T_tcp_identity_sid)
+	nc.RegisterSid("test:udp", T_udp_identity_sid)
+	nc.RegisterSid("/test:system", T_system_sid)
+	nc.RegisterSid("/test:system/hostname", T_system_hostname_sid)
+	nc.RegisterSid("/test:system/mtu", T_system_mtu_sid)
+	nc.RegisterSid("/test:system/enabled", T_system_enabled_sid)
+	nc.RegisterSid("/test:system/counter", T_system_counter_sid)
+	nc.RegisterSid("/test:system/offset", T_system_offset_sid)
+	nc.RegisterSid("/test:system/level", T_system_level_sid)
+	nc.RegisterSid("/test:system/gain", T_system_gain_sid)
+	nc.RegisterSid("/test:system/color", T_system_color_sid)
+	nc.RegisterSid("/test:system/flags", T_system_flags_sid)
+	nc.RegisterSid("/test:system/proto", T_system_proto_sid)
+	nc.RegisterSid("/test:system/data", T_system_data_sid)
+	nc.RegisterSid("/test:system/active", T_system_active_sid)
+	nc.RegisterSid("/test:system/id", T_system_id_sid)
+	nc.RegisterSid("/test:system/dns", T_system_dns_sid)
+	nc.RegisterSid("/test:system/timers", T_system_timers_sid)
+	nc.RegisterSid("/test:system/timers/hello", T_system_timers_hello_sid)
+	nc.RegisterSid("/test:system/timers/dead", T_system_timers_dead_sid)
+	nc.RegisterSid("/test:system/debug", T_system_debug_sid)
+	nc.RegisterSid("/test:system/debug/level", T_system_debug_level_sid)
+	nc.RegisterSid("/test:system/auto", T_system_auto_sid)
+	nc.RegisterSid("/test:system/fixed", T_system_fixed_sid)
+	nc.RegisterSid("/test:system/stats", T_system_stats_sid)
+	nc.RegisterSid("/test:system/stats/in", T_system_stats_in_sid)
+	nc.RegisterSid("/test:top", T_top_sid)
+	nc.RegisterSid("/test:top/intf", T_top_intf_sid)
+	nc.RegisterSid("/test:top/intf/name", T_top_intf_name_sid)
+	nc.RegisterSid("/test:top/intf/mtu", T_top_intf_mtu_sid)
+	nc.RegisterSid("/test:top/ref", T_top_ref_sid)
+	nc.RegisterSid("/test:item", T_item_sid)
+	nc.RegisterSid("/test:item/name", T_item_name_sid)
+	nc.RegisterSid("/test:item/value", T_item_value_sid)
+	nc.RegisterSid("/test:reset", T_reset_sid)
+	nc.RegisterSid("/test:reset/input/delay", T_reset_input_delay_sid)
+	nc.RegisterSid("/test:reset/output/status", T_reset_output_status_sid)
+	nc.RegisterSid("/test:alarm", T_alarm_sid)
+	nc.RegisterSid("/test:alarm/severity", T_alarm_severity_sid)
+	nc.RegisterSid("/test:alarm/text", T_alarm_text_sid)
+	T_proto_id_prefix_map["tcp"] = "t"
+	T_proto_id_ns_map["tcp"] = "urn:test"
+	nc.RegisterIdentity("urn:test", "tcp", "urn:test", "proto")
+	T_proto_id_prefix_map["udp"] = "t"
+	T_proto_id_ns_map["udp"] = "urn:test"
+	nc.RegisterIdentity("urn:test", "udp", "urn:test", "proto")
+	nc.RegisterDefaults(T_system_cont{}, T_system_cont_defaults)
+	nc.RegisterDefaults(T_system_timers_cont{}, T_system_timers_cont_defaults)
+	nc.RegisterDefaults(T_system_debug_cont{}, T_system_debug_cont_defaults)
+	nc.RegisterConstraints(T_top_cont{}, T_top_cont_constraints)
+	nc.RegisterNotification(Test_ns, "alarm", func() interface{} { return &T_alarm_cont{} })
+}
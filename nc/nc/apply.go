@@ -0,0 +1,402 @@
+package nc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Edit applies the configuration of edit-config to the structure pointed
+// to by v. The config is of the same type as v, the generated device or
+// a container, and the operation of each node is taken from its field
+// XMLOp and otherwise from its parent. The operation of the top is the
+// default-operation, merge if none. The leaves take the operation of the
+// structure that contains them. The entries of lists are matched by their
+// keys and those ordered by the user are positioned as in XMLInsert. The
+// nodes that can't be created or deleted are reported as an *RPCError
+// with the tag data-exists or data-missing and the instance path.
+func Edit(v, config interface{}, defaultOp Operation) error {
+	dv := reflect.ValueOf(v)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("nc: can't edit %T", v)
+	}
+	dv = dv.Elem()
+	cv := indirect(reflect.ValueOf(config))
+	if !cv.IsValid() || cv.Type() != dv.Type() {
+		return fmt.Errorf("nc: can't edit %s with %T", dv.Type(), config)
+	}
+	if defaultOp == OpNone {
+		defaultOp = OpMerge
+	}
+	// The name is the one of the type as the decoded config carries the
+	// name <config>
+	ti := getTypeInfo(cv.Type())
+	if ti.xmlname == nil {
+		return fmt.Errorf("nc: can't edit %T without XMLName", v)
+	}
+	ns, name := nsOr(ti.xmlname.ns, runtimeNs(cv)), ti.xmlname.name
+	if isData(ns, name) {
+		return editFields(dv, cv, defaultOp, "", "", false)
+	}
+	_, err := editStruct(dv, cv, true, defaultOp, "/"+qualifiedName(ns, name, true), ns)
+	return err
+}
+
+// Clone returns a pointer to a deep copy of the structure v points to
+func Clone(v interface{}) interface{} {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil
+	}
+	c := reflect.New(rv.Type())
+	c.Elem().Set(deepCopy(rv))
+	return c.Interface()
+}
+
+func editError(tag, path, msg string) *RPCError {
+	return &RPCError{Type: ErrApplication, Tag: tag, Severity: "error", Path: path, Message: msg}
+}
+
+// The operation of a container or an entry, its own or the one inherited
+func opOf(v reflect.Value, op Operation) Operation {
+	ti := getTypeInfo(v.Type())
+	if ti.op != nil {
+		if o, _ := v.Field(ti.op.idx).Interface().(Operation); o != OpNone {
+			return o
+		}
+	}
+	return op
+}
+
+// Edit a container or an entry of a list. It returns whether the node
+// remains.
+func editStruct(dst, src reflect.Value, present bool, op Operation, path, ns string) (bool, error) {
+	op = opOf(src, op)
+	switch op {
+	case OpDelete, OpRemove:
+		if op == OpDelete && !present {
+			return false, editError("data-missing", path, "the node doesn't exist")
+		}
+		dst.Set(reflect.Zero(dst.Type()))
+		return false, nil
+	case OpCreate, OpReplace:
+		if op == OpCreate && present {
+			return true, editError("data-exists", path, "the node already exists")
+		}
+		dst.Set(reflect.Zero(dst.Type()))
+		op = OpMerge
+	case OpMerge, OpNoop:
+	default:
+		return present, editError("bad-attribute", path, "unknown operation "+string(op))
+	}
+	if err := editFields(dst, src, op, path, ns, false); err != nil {
+		return true, err
+	}
+	return op == OpMerge || present || hasNodes(dst), nil
+}
+
+// Edit the fields of a structure. The fields of a choice are its cases
+// and the leaves that stand for a case. Setting one removes the others.
+// The namespace is the one of the parent of the fields.
+func editFields(dst, src reflect.Value, op Operation, path, ns string, choice bool) error {
+	ti := getTypeInfo(src.Type())
+	if choice && op != OpDelete && op != OpRemove && op != OpNoop {
+		for _, fi := range ti.fields {
+			if isPresent(src, fi) {
+				for _, other := range ti.fields {
+					if other != fi {
+						clearField(dst, other)
+					}
+				}
+				break
+			}
+		}
+	}
+	for _, fi := range ti.fields {
+		if !isPresent(src, fi) {
+			continue
+		}
+		sf, df := src.Field(fi.idx), dst.Field(fi.idx)
+		childNs := nsOr(fi.ns, ns)
+		childPath := path + "/" + qualifiedName(childNs, fi.name, childNs != ns)
+		switch {
+		case fi.inline:
+			if indirect(sf).Kind() != reflect.Struct {
+				continue
+			}
+			if df.Kind() == reflect.Ptr && df.IsNil() {
+				df.Set(reflect.New(df.Type().Elem()))
+			}
+			// The choices and the cases are the named inline fields and
+			// they alternate, the groupings are embedded
+			if err := editFields(indirect(df), indirect(sf), op, path, ns, fi.name != "" && !choice); err != nil {
+				return err
+			}
+			if fi.prsnt >= 0 {
+				dst.Field(fi.prsnt).SetBool(hasNodes(indirect(df)))
+			}
+		case fi.empty:
+			if err := editLeaf(df, sf, df.Bool(), op, childPath); err != nil {
+				return err
+			}
+		case sf.Kind() == reflect.Slice && sf.Type().Elem().Kind() == reflect.Struct && !isLeafValue(sf.Index(0)):
+			if err := editList(df, sf, op, childPath, childNs); err != nil {
+				return err
+			}
+		case sf.Kind() == reflect.Slice && sf.Type().Elem().Kind() != reflect.Uint8:
+			if err := editLeafList(df, sf, op, childPath); err != nil {
+				return err
+			}
+		case !isLeafValue(sf) && indirect(sf).Kind() == reflect.Struct:
+			if df.Kind() == reflect.Ptr && df.IsNil() {
+				df.Set(reflect.New(df.Type().Elem()))
+			}
+			present := fi.prsnt < 0 || dst.Field(fi.prsnt).Bool()
+			remains, err := editStruct(indirect(df), indirect(sf), present, op, childPath, childNs)
+			if err != nil {
+				return err
+			}
+			if fi.prsnt >= 0 {
+				dst.Field(fi.prsnt).SetBool(remains)
+			}
+		default:
+			present := fi.prsnt < 0 || dst.Field(fi.prsnt).Bool()
+			if err := editLeaf(df, sf, present, op, childPath); err != nil {
+				return err
+			}
+			if fi.prsnt >= 0 && op != OpNoop {
+				dst.Field(fi.prsnt).SetBool(op != OpDelete && op != OpRemove)
+			}
+		}
+	}
+	return nil
+}
+
+// Edit a leaf. The presence is set by the caller.
+func editLeaf(dst, src reflect.Value, present bool, op Operation, path string) error {
+	switch op {
+	case OpCreate:
+		if present {
+			return editError("data-exists", path, "the node already exists")
+		}
+		dst.Set(src)
+	case OpMerge, OpReplace:
+		dst.Set(src)
+	case OpDelete, OpRemove:
+		if op == OpDelete && !present {
+			return editError("data-missing", path, "the node doesn't exist")
+		}
+		dst.Set(reflect.Zero(dst.Type()))
+	}
+	return nil
+}
+
+func editLeafList(dst, src reflect.Value, op Operation, path string) error {
+	for i := 0; i < src.Len(); i++ {
+		sv := src.Index(i)
+		pos := -1
+		for j := 0; j < dst.Len(); j++ {
+			if reflect.DeepEqual(dst.Index(j).Interface(), sv.Interface()) {
+				pos = j
+				break
+			}
+		}
+		switch op {
+		case OpCreate, OpMerge, OpReplace:
+			if pos >= 0 && op == OpCreate {
+				return editError("data-exists", path, "the entry already exists")
+			}
+			if pos < 0 {
+				dst.Set(reflect.Append(dst, sv))
+			}
+		case OpDelete, OpRemove:
+			if pos < 0 && op == OpDelete {
+				return editError("data-missing", path, "the entry doesn't exist")
+			}
+			if pos >= 0 {
+				removeAt(dst, pos)
+			}
+		}
+	}
+	return nil
+}
+
+func editList(dst, src reflect.Value, op Operation, path, ns string) error {
+	for i := 0; i < src.Len(); i++ {
+		entry := indirect(src.Index(i))
+		if !entry.IsValid() {
+			continue
+		}
+		entryPath := EntryPath(path, entry.Interface(), i)
+		pos := findEntry(dst, entry)
+		if pos < 0 {
+			eop := opOf(entry, op)
+			if eop == OpDelete {
+				return editError("data-missing", entryPath, "the entry doesn't exist")
+			}
+			if eop == OpRemove || eop == OpNoop {
+				continue
+			}
+			n := reflect.New(dst.Type().Elem()).Elem()
+			if _, err := editStruct(indirect(allocate(n)), entry, false, op, entryPath, ns); err != nil {
+				return err
+			}
+			dst.Set(reflect.Append(dst, n))
+			pos = dst.Len() - 1
+		} else {
+			remains, err := editStruct(indirect(allocate(dst.Index(pos))), entry, true, op, entryPath, ns)
+			if err != nil {
+				return err
+			}
+			if !remains {
+				removeAt(dst, pos)
+				continue
+			}
+		}
+		if err := insertEntry(dst, pos, entry, entryPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the entry at pos of a list. The list without entries is nil as
+// the empty lists aren't encoded.
+func removeAt(list reflect.Value, pos int) {
+	if list.Len() == 1 {
+		list.Set(reflect.Zero(list.Type()))
+		return
+	}
+	list.Set(reflect.AppendSlice(list.Slice(0, pos), list.Slice(pos+1, list.Len())))
+}
+
+func allocate(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return v
+}
+
+// The position of the entry of the list with the same keys as entry, -1
+// if there is none. The entries of lists without keys never match.
+func findEntry(list, entry reflect.Value) int {
+	k, ok := entry.Interface().(Keyed)
+	if !ok || len(k.ListKeyNames()) == 0 {
+		return -1
+	}
+	ti := getTypeInfo(entry.Type())
+	for i := 0; i < list.Len(); i++ {
+		e := indirect(list.Index(i))
+		if !e.IsValid() {
+			continue
+		}
+		match := true
+		for _, name := range k.ListKeyNames() {
+			path := ti.lookup("", name)
+			if !reflect.DeepEqual(readPath(e, path).Interface(), readPath(entry, path).Interface()) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// Move the entry at pos of a list ordered by the user to the position
+// given by the attribute insert of the edit
+func insertEntry(list reflect.Value, pos int, entry reflect.Value, path string) error {
+	ti := getTypeInfo(entry.Type())
+	if ti.insert == nil {
+		return nil
+	}
+	ins, _ := entry.Field(ti.insert.idx).Interface().(Insert)
+	to := pos
+	switch ins.Where {
+	case InsertNone:
+		return nil
+	case InsertFirst:
+		to = 0
+	case InsertLast:
+		to = list.Len() - 1
+	case InsertBefore, InsertAfter:
+		to = -1
+		for i := 0; i < list.Len(); i++ {
+			if i != pos && entryHasKeys(indirect(list.Index(i)), ins.Key) {
+				to = i
+				break
+			}
+		}
+		if to < 0 {
+			e := editError("bad-attribute", path, "the entry of the key doesn't exist")
+			e.AppTag = "missing-instance"
+			return e
+		}
+		if ins.Where == InsertAfter {
+			to++
+		}
+		if to > pos {
+			to--
+		}
+	default:
+		return editError("bad-attribute", path, "unknown insert "+string(ins.Where))
+	}
+	moved := reflect.New(list.Type().Elem()).Elem()
+	moved.Set(list.Index(pos))
+	reflect.Copy(list.Slice(pos, list.Len()), list.Slice(pos+1, list.Len()))
+	reflect.Copy(list.Slice(to+1, list.Len()), list.Slice(to, list.Len()-1))
+	list.Index(to).Set(moved)
+	return nil
+}
+
+// Whether the keys of an entry have the values passed. The values are
+// compared in their canonical text.
+func entryHasKeys(e reflect.Value, keys []KeyValue) bool {
+	if !e.IsValid() {
+		return false
+	}
+	ti := getTypeInfo(e.Type())
+	for _, k := range keys {
+		fv := readPath(e, ti.lookup("", k.Name))
+		if !fv.IsValid() || keyText(fv.Interface(), "") != keyText(k.Value, "") {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether the node of a field is present
+func isPresent(v reflect.Value, fi *fieldInfo) bool {
+	fv := v.Field(fi.idx)
+	switch {
+	case fi.empty:
+		return fv.Bool()
+	case fi.prsnt >= 0:
+		return v.Field(fi.prsnt).Bool()
+	case fi.inline:
+		fv = indirect(fv)
+		return fv.IsValid() && fv.Kind() == reflect.Struct && hasNodes(fv)
+	case fv.Kind() == reflect.Slice:
+		return fv.Len() > 0
+	}
+	return !fv.IsZero()
+}
+
+// Whether any node within a structure is present
+func hasNodes(v reflect.Value) bool {
+	for _, fi := range getTypeInfo(v.Type()).fields {
+		if isPresent(v, fi) {
+			return true
+		}
+	}
+	return false
+}
+
+func clearField(v reflect.Value, fi *fieldInfo) {
+	f := v.Field(fi.idx)
+	f.Set(reflect.Zero(f.Type()))
+	if fi.prsnt >= 0 {
+		v.Field(fi.prsnt).SetBool(false)
+	}
+}
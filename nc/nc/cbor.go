@@ -0,0 +1,905 @@
+package nc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The CBOR encoding of RFC 9254. The members of the objects are
+// identified either by their names as in the JSON encoding or by their
+// SIDs. A SID is encoded as the difference with the SID of the parent
+// except at the top where the parent is taken as 0. The values follow
+// the types of yang:
+//   - the integers are integers including int64 and uint64
+//   - decimal64 is a decimal fraction of tag 4
+//   - an enumeration is its value as an integer
+//   - bits are a byte string with the bit at position n in bit n%8 of the
+//     byte n/8
+//   - empty is null
+//   - an identity is its SID or its name qualified by its module
+//   - string is a text string and binary a byte string
+//
+// Within a union an enumeration is the text of tag 44, bits are the text
+// of tag 43 and an identity by SID is of tag 45 so that the member can
+// be identified.
+
+// The major types of CBOR
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTagged = 6
+	cborSimple = 7
+)
+
+// The tags of RFC 8949 and RFC 9254
+const (
+	cborTagDecimal  = 4
+	cborTagBits     = 43
+	cborTagEnum     = 44
+	cborTagIdentity = 45
+	cborTagSid      = 47
+)
+
+const (
+	cborFalse = 0xf4
+	cborTrue  = 0xf5
+	cborNull  = 0xf6
+)
+
+// The nesting of the decoded items is limited so that malformed input
+// doesn't exhaust the stack.
+const cborMaxDepth = 512
+
+// MarshalCBOR returns the CBOR encoding of v with the members identified
+// by their names. If v is the <data> of the device, the members of the
+// top level map are its children. Otherwise, the map has a single member
+// for the element of v.
+func MarshalCBOR(v interface{}) ([]byte, error) {
+	return marshalCBOR(v, false)
+}
+
+// MarshalCBORSid returns the CBOR encoding of v with the members
+// identified by their SIDs which must have been registered.
+func MarshalCBORSid(v interface{}) ([]byte, error) {
+	return marshalCBOR(v, true)
+}
+
+// UnmarshalCBOR decodes the CBOR encoding in data into v which must be a
+// pointer to a structure. The members may be identified by their names
+// or by their SIDs.
+func UnmarshalCBOR(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("nc: can't decode CBOR into %T", v)
+	}
+	r := &cborReader{data: data}
+	item, err := r.item(0)
+	if err != nil {
+		return err
+	}
+	pairs, ok := item.([]cborPair)
+	if !ok {
+		return fmt.Errorf("nc: expected a map at the top of CBOR")
+	}
+	rv = rv.Elem()
+	ns, name := elementName(rv)
+	if isData(ns, name) {
+		return cborDecodeObject(rv, pairs, cborNode{})
+	}
+	for _, p := range pairs {
+		c, err := cborMember(cborNode{}, p.key)
+		if err != nil {
+			return err
+		}
+		if c.name == name && (ns == "" || c.ns == ns) {
+			return cborDecodeValue(rv, p.value, c)
+		}
+	}
+	return fmt.Errorf("nc: member %s not found", name)
+}
+
+// A node of the data tree being encoded or decoded. The path is the
+// identifier of the node in the .sid files.
+type cborNode struct {
+	ns   string
+	name string
+	path string
+	sid  uint64
+}
+
+// The child of a node. The name of the module qualifies the child in the
+// path when the namespace changes.
+func (n cborNode) child(ns, name string) (cborNode, error) {
+	if ns == "" {
+		ns = n.ns
+	}
+	c := cborNode{ns: ns, name: name, path: n.path + "/" + name}
+	if ns != n.ns {
+		m, ok := ModuleByNs(ns)
+		if !ok {
+			return c, fmt.Errorf("nc: no module registered for namespace %s", ns)
+		}
+		c.path = n.path + "/" + m.Name + ":" + name
+	}
+	return c, nil
+}
+
+// The name of the member which is the last part of the path
+func (n cborNode) member() string {
+	return n.path[strings.LastIndex(n.path, "/")+1:]
+}
+
+type cborEncoder struct {
+	sids bool
+}
+
+func marshalCBOR(v interface{}, sids bool) ([]byte, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("nc: can't encode %T as CBOR", v)
+	}
+	e := &cborEncoder{sids: sids}
+	var b bytes.Buffer
+	ns, name := elementName(rv)
+	if isData(ns, name) {
+		if err := e.object(&b, rv, cborNode{}); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("nc: can't encode %T without XMLName", v)
+	}
+	var members bytes.Buffer
+	count := 0
+	if err := e.member(&members, &count, rv, cborNode{}, ns, name); err != nil {
+		return nil, err
+	}
+	cborHead(&b, cborMap, uint64(count))
+	b.Write(members.Bytes())
+	return b.Bytes(), nil
+}
+
+// Write the key of a member which is its name or the delta of its SID
+func (e *cborEncoder) key(b *bytes.Buffer, parent cborNode, ns, name string) (cborNode, error) {
+	c, err := parent.child(ns, name)
+	if err != nil {
+		return c, err
+	}
+	if !e.sids {
+		cborString(b, c.member())
+		return c, nil
+	}
+	sid, ok := SidOf(c.path)
+	if !ok {
+		return c, fmt.Errorf("nc: no SID registered for %s", c.path)
+	}
+	c.sid = sid
+	cborInt(b, int64(sid-parent.sid))
+	return c, nil
+}
+
+func (e *cborEncoder) member(b *bytes.Buffer, count *int, v reflect.Value, parent cborNode, ns, name string) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	c, err := e.key(b, parent, ns, name)
+	if err != nil {
+		return err
+	}
+	*count++
+	return e.value(b, v, c)
+}
+
+func (e *cborEncoder) value(b *bytes.Buffer, v reflect.Value, n cborNode) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		b.WriteByte(cborNull)
+		return nil
+	}
+	if isLeafValue(v) {
+		return e.leaf(b, v, n.ns, false)
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		cborHead(b, cborArray, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := e.value(b, v.Index(i), n); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return e.object(b, v, n)
+	}
+	return fmt.Errorf("nc: can't encode %s as CBOR", v.Type())
+}
+
+// The members are counted as they are written as the map carries the
+// count ahead of them.
+func (e *cborEncoder) object(b *bytes.Buffer, v reflect.Value, n cborNode) error {
+	var members bytes.Buffer
+	count := 0
+	if err := e.fields(&members, &count, v, n); err != nil {
+		return err
+	}
+	cborHead(b, cborMap, uint64(count))
+	b.Write(members.Bytes())
+	return nil
+}
+
+// The fields of choices, cases and groupings are members of the map of
+// the structure that contains them.
+func (e *cborEncoder) fields(b *bytes.Buffer, count *int, v reflect.Value, n cborNode) error {
+	ti := getTypeInfo(v.Type())
+	for _, fi := range ti.fields {
+		fv := v.Field(fi.idx)
+		if fi.prsnt >= 0 && !v.Field(fi.prsnt).Bool() {
+			continue
+		}
+		if fi.empty {
+			if !fv.Bool() {
+				continue
+			}
+			if _, err := e.key(b, n, fi.ns, fi.name); err != nil {
+				return err
+			}
+			*count++
+			b.WriteByte(cborNull)
+			continue
+		}
+		if fi.inline {
+			if fv = indirect(fv); fv.IsValid() && fv.Kind() == reflect.Struct {
+				if err := e.fields(b, count, fv, n); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if fi.prsnt < 0 && fv.IsZero() {
+			continue
+		}
+		if err := e.member(b, count, fv, n, fi.ns, fi.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode a leaf. The value is first validated through MarshalText() of
+// the generated type.
+func (e *cborEncoder) leaf(b *bytes.Buffer, v reflect.Value, ns string, union bool) error {
+	if v.Kind() == reflect.Struct {
+		if m := unionMember(v); m.IsValid() {
+			return e.leaf(b, m, ns, true)
+		}
+	}
+	text, err := marshalText(v, ns)
+	if err != nil {
+		return err
+	}
+	if id, ok := identityName(v, text); ok {
+		if sid, ok := SidOf(id); ok && e.sids {
+			if union {
+				cborHead(b, cborTagged, cborTagIdentity)
+			}
+			cborHead(b, cborUint, sid)
+			return nil
+		}
+		cborString(b, id)
+		return nil
+	}
+	if d, ok := v.Interface().(Decimal64); ok {
+		cborHead(b, cborTagged, cborTagDecimal)
+		cborHead(b, cborArray, 2)
+		cborInt(b, -int64(d.FractionDigits()))
+		cborInt(b, v.Int())
+		return nil
+	}
+	if bits, ok := v.Interface().(Bits); ok {
+		if union {
+			cborHead(b, cborTagged, cborTagBits)
+			cborString(b, string(text))
+			return nil
+		}
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], bits.Bits())
+		cborByteString(b, bytes.TrimRight(buf[:], "\x00"))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int:
+		// The generated enumerations are of type int
+		if union {
+			cborHead(b, cborTagged, cborTagEnum)
+			cborString(b, string(text))
+			return nil
+		}
+		cborInt(b, v.Int())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cborInt(b, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		cborHead(b, cborUint, v.Uint())
+	case reflect.Bool:
+		if v.Bool() {
+			b.WriteByte(cborTrue)
+		} else {
+			b.WriteByte(cborFalse)
+		}
+	case reflect.Float32, reflect.Float64:
+		b.WriteByte(cborSimple<<5 | 27)
+		binary.Write(b, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.Slice:
+		cborByteString(b, v.Bytes())
+	default:
+		cborString(b, string(text))
+	}
+	return nil
+}
+
+func cborHead(b *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		b.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		b.WriteByte(major | 24)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(major | 25)
+		binary.Write(b, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		b.WriteByte(major | 26)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	default:
+		b.WriteByte(major | 27)
+		binary.Write(b, binary.BigEndian, n)
+	}
+}
+
+func cborInt(b *bytes.Buffer, i int64) {
+	if i < 0 {
+		cborHead(b, cborNegint, uint64(-1-i))
+		return
+	}
+	cborHead(b, cborUint, uint64(i))
+}
+
+func cborString(b *bytes.Buffer, s string) {
+	cborHead(b, cborText, uint64(len(s)))
+	b.WriteString(s)
+}
+
+func cborByteString(b *bytes.Buffer, s []byte) {
+	cborHead(b, cborBytes, uint64(len(s)))
+	b.Write(s)
+}
+
+// The member identified by a key which is either a name or a SID. The
+// SID is the delta from the one of the parent unless of tag 47.
+func cborMember(parent cborNode, key interface{}) (cborNode, error) {
+	var sid uint64
+	switch k := key.(type) {
+	case string:
+		ns, name, err := jsonSplitName(k, parent.ns)
+		if err != nil {
+			return cborNode{}, err
+		}
+		return parent.child(ns, name)
+	case uint64:
+		sid = parent.sid + k
+	case int64:
+		sid = parent.sid + uint64(k)
+	case cborTag:
+		abs, ok := k.value.(uint64)
+		if k.num != cborTagSid || !ok {
+			return cborNode{}, fmt.Errorf("nc: invalid key %v", key)
+		}
+		sid = abs
+	default:
+		return cborNode{}, fmt.Errorf("nc: invalid key %v", key)
+	}
+	path, ok := SidIdentifier(sid)
+	if !ok {
+		return cborNode{}, fmt.Errorf("nc: unknown SID %d", sid)
+	}
+	c := cborNode{ns: parent.ns, path: path, sid: sid}
+	c.name = c.member()
+	if module, name, ok := strings.Cut(c.name, ":"); ok {
+		m, ok := ModuleByName(module)
+		if !ok {
+			return cborNode{}, fmt.Errorf("nc: unknown module %s", module)
+		}
+		c.ns, c.name = m.Namespace, name
+	}
+	return c, nil
+}
+
+func cborDecodeObject(v reflect.Value, pairs []cborPair, n cborNode) error {
+	ti := getTypeInfo(v.Type())
+	for _, p := range pairs {
+		c, err := cborMember(n, p.key)
+		if err != nil {
+			return err
+		}
+		path := ti.lookup(c.ns, c.name)
+		if path == nil {
+			continue
+		}
+		fv, fi := walkPath(v, path)
+		if fi.empty {
+			fv.SetBool(true)
+			continue
+		}
+		if err := cborDecodeValue(fv, p.value, c); err != nil {
+			return fmt.Errorf("%s: %s", c.name, err.Error())
+		}
+	}
+	return nil
+}
+
+func cborDecodeValue(v reflect.Value, item interface{}, n cborNode) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items, ok := item.([]interface{})
+		if !ok {
+			return fmt.Errorf("nc: expected an array for %s", v.Type())
+		}
+		for _, x := range items {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := cborDecodeValue(e, x, n); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, e))
+		}
+		return nil
+	case reflect.Struct:
+		if _, ok := asTextUnmarshaler(v); ok {
+			break
+		}
+		pairs, ok := item.([]cborPair)
+		if !ok {
+			return fmt.Errorf("nc: expected a map for %s", v.Type())
+		}
+		return cborDecodeObject(v, pairs, n)
+	}
+	return cborDecodeLeaf(v, item, n.ns)
+}
+
+// Decode a leaf. The values that have a native encoding are set directly
+// and then validated through MarshalText(). The others, including the
+// unions, are converted to their text.
+func cborDecodeLeaf(v reflect.Value, item interface{}, ns string) error {
+	set, err := cborSetNative(v, item)
+	if err != nil {
+		return err
+	}
+	if set {
+		if _, ok := asTextMarshaler(v); ok {
+			_, err = marshalText(v, ns)
+		}
+		return err
+	}
+	// The generated identities are strings and an integer can only be the
+	// SID of an identity
+	if sid, ok := item.(uint64); ok && v.Kind() == reflect.String {
+		item = cborTag{num: cborTagIdentity, value: sid}
+	}
+	text, err := cborItemText(item)
+	if err != nil {
+		return err
+	}
+	if u, ok := asTextUnmarshaler(v); ok {
+		return u.UnmarshalText(ns, []byte(text))
+	}
+	return unmarshalBasic(v, []byte(text))
+}
+
+func cborSetNative(v reflect.Value, item interface{}) (bool, error) {
+	if d, ok := v.Interface().(Decimal64); ok {
+		t, ok := item.(cborTag)
+		if !ok || t.num != cborTagDecimal {
+			return false, nil
+		}
+		e, m, err := cborDecimal(t.value)
+		if err != nil {
+			return false, err
+		}
+		x, err := scaleDecimal(m, e+int64(d.FractionDigits()))
+		if err != nil {
+			return false, err
+		}
+		v.SetInt(x)
+		return true, nil
+	}
+	if _, ok := v.Interface().(Bits); ok {
+		s, ok := item.([]byte)
+		if !ok {
+			return false, nil
+		}
+		if len(s) > 8 {
+			return false, fmt.Errorf("nc: too many bits for %s", v.Type())
+		}
+		var buf [8]byte
+		copy(buf[:], s)
+		v.SetUint(binary.LittleEndian.Uint64(buf[:]))
+		return true, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch x := item.(type) {
+		case uint64:
+			if x > math.MaxInt64 {
+				return false, fmt.Errorf("nc: value %d overflows %s", x, v.Type())
+			}
+			i = int64(x)
+		case int64:
+			i = x
+		default:
+			return false, nil
+		}
+		if v.OverflowInt(i) {
+			return false, fmt.Errorf("nc: value %d overflows %s", i, v.Type())
+		}
+		v.SetInt(i)
+		return true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, ok := item.(uint64)
+		if !ok {
+			return false, nil
+		}
+		if v.OverflowUint(x) {
+			return false, fmt.Errorf("nc: value %d overflows %s", x, v.Type())
+		}
+		v.SetUint(x)
+		return true, nil
+	case reflect.Bool:
+		x, ok := item.(bool)
+		if !ok {
+			return false, nil
+		}
+		v.SetBool(x)
+		return true, nil
+	case reflect.Float32, reflect.Float64:
+		x, ok := item.(float64)
+		if !ok {
+			return false, nil
+		}
+		v.SetFloat(x)
+		return true, nil
+	case reflect.Slice:
+		x, ok := item.([]byte)
+		if !ok {
+			return false, nil
+		}
+		v.SetBytes(x)
+		return true, nil
+	}
+	return false, nil
+}
+
+// The exponent and the mantissa of a decimal fraction
+func cborDecimal(item interface{}) (int64, int64, error) {
+	a, ok := item.([]interface{})
+	if !ok || len(a) != 2 {
+		return 0, 0, fmt.Errorf("nc: invalid decimal fraction")
+	}
+	var x [2]int64
+	for i, n := range a {
+		switch n := n.(type) {
+		case uint64:
+			if n > math.MaxInt64 {
+				return 0, 0, fmt.Errorf("nc: decimal fraction out of range")
+			}
+			x[i] = int64(n)
+		case int64:
+			x[i] = n
+		default:
+			return 0, 0, fmt.Errorf("nc: invalid decimal fraction")
+		}
+	}
+	return x[0], x[1], nil
+}
+
+// Scale the mantissa by 10 to the power of e. Scaling down must not drop
+// any digit.
+func scaleDecimal(m, e int64) (int64, error) {
+	for ; e > 0; e-- {
+		if m > math.MaxInt64/10 || m < math.MinInt64/10 {
+			return 0, fmt.Errorf("nc: decimal fraction out of range")
+		}
+		m *= 10
+	}
+	for ; e < 0; e++ {
+		if m%10 != 0 {
+			return 0, fmt.Errorf("nc: too many fraction digits")
+		}
+		m /= 10
+	}
+	return m, nil
+}
+
+// The text of a leaf from its CBOR value. The identities by SID are
+// converted to their names.
+func cborItemText(item interface{}) (string, error) {
+	switch x := item.(type) {
+	case string:
+		return x, nil
+	case uint64:
+		return strconv.FormatUint(x, 10), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x), nil
+	case cborTag:
+		switch x.num {
+		case cborTagBits, cborTagEnum:
+			if s, ok := x.value.(string); ok {
+				return s, nil
+			}
+		case cborTagIdentity:
+			if sid, ok := x.value.(uint64); ok {
+				if id, ok := SidIdentifier(sid); ok {
+					return id, nil
+				}
+				return "", fmt.Errorf("nc: unknown SID %d", sid)
+			}
+		case cborTagDecimal:
+			e, m, err := cborDecimal(x.value)
+			if err != nil {
+				return "", err
+			}
+			return decimalText(e, m), nil
+		}
+	}
+	return "", fmt.Errorf("nc: invalid value %v for a leaf", item)
+}
+
+// The text of the decimal fraction m * 10^e
+func decimalText(e, m int64) string {
+	if e >= 0 {
+		return strconv.FormatInt(m, 10) + strings.Repeat("0", int(e))
+	}
+	digits := strconv.FormatInt(m, 10)
+	sign := ""
+	if m < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	fd := int(-e)
+	if len(digits) <= fd {
+		digits = strings.Repeat("0", fd-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-fd] + "." + digits[len(digits)-fd:]
+}
+
+// A member of a map as decoded. The keys are kept in the order found.
+type cborPair struct {
+	key   interface{}
+	value interface{}
+}
+
+// A tagged item as decoded
+type cborTag struct {
+	num   uint64
+	value interface{}
+}
+
+// The decoder of CBOR items. The items are decoded as uint64, int64,
+// []byte, string, []interface{}, []cborPair, cborTag, bool, float64 and
+// nil for null and undefined.
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("nc: unexpected end of CBOR")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// Read the head of an item. The argument of an indefinite length is
+// reported through the flag.
+func (r *cborReader) head() (byte, byte, uint64, bool, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info == 24:
+		b, err = r.next(1)
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		return major, info, uint64(b[0]), false, nil
+	case info == 25:
+		b, err = r.next(2)
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		return major, info, uint64(binary.BigEndian.Uint16(b)), false, nil
+	case info == 26:
+		b, err = r.next(4)
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		return major, info, uint64(binary.BigEndian.Uint32(b)), false, nil
+	case info == 27:
+		b, err = r.next(8)
+		if err != nil {
+			return 0, 0, 0, false, err
+		}
+		return major, info, binary.BigEndian.Uint64(b), false, nil
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		return major, info, 0, true, nil
+	case info == 31 && major == cborSimple:
+		return major, info, 0, false, nil
+	}
+	return 0, 0, 0, false, fmt.Errorf("nc: invalid CBOR item at %d", r.pos-1)
+}
+
+// Check for the break that ends an item of indefinite length
+func (r *cborReader) isBreak() bool {
+	if r.pos < len(r.data) && r.data[r.pos] == 0xff {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *cborReader) item(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("nc: CBOR nested too deeply")
+	}
+	major, info, n, indefinite, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return n, nil
+	case cborNegint:
+		if n > math.MaxInt64 {
+			return nil, fmt.Errorf("nc: negative integer out of range")
+		}
+		return -1 - int64(n), nil
+	case cborBytes, cborText:
+		var s []byte
+		if indefinite {
+			for !r.isBreak() {
+				chunk, err := r.item(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				switch c := chunk.(type) {
+				case []byte:
+					if major != cborBytes {
+						return nil, fmt.Errorf("nc: invalid chunk in CBOR string")
+					}
+					s = append(s, c...)
+				case string:
+					if major != cborText {
+						return nil, fmt.Errorf("nc: invalid chunk in CBOR string")
+					}
+					s = append(s, c...)
+				default:
+					return nil, fmt.Errorf("nc: invalid chunk in CBOR string")
+				}
+			}
+		} else {
+			b, err := r.next(n)
+			if err != nil {
+				return nil, err
+			}
+			s = append([]byte{}, b...)
+		}
+		if major == cborText {
+			return string(s), nil
+		}
+		return s, nil
+	case cborArray:
+		var a []interface{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && r.isBreak() {
+				break
+			}
+			x, err := r.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, x)
+		}
+		return a, nil
+	case cborMap:
+		var m []cborPair
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && r.isBreak() {
+				break
+			}
+			k, err := r.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			x, err := r.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, cborPair{key: k, value: x})
+		}
+		return m, nil
+	case cborTagged:
+		x, err := r.item(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTag{num: n, value: x}, nil
+	}
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfToFloat(uint16(n)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 27:
+		return math.Float64frombits(n), nil
+	}
+	return nil, fmt.Errorf("nc: unsupported CBOR simple value %d", n)
+}
+
+func halfToFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,110 @@
+package nc_test
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+func systemDevice(sys yang.T_system_cont) *yang.Device {
+	return &yang.Device{XMLName: dataName, T_system_Prsnt: true, T_system: sys}
+}
+
+// The SIDs are those of testdata/test.sid. The SID of a member is the
+// delta from the one of its parent, system being 60004 (0x19ea64).
+func TestMarshalCBORSid(t *testing.T) {
+	var top yang.T_top_cont
+	top.SetIntf(yang.T_top_intf{Name: "eth0"})
+	tests := []struct {
+		name string
+		dev  *yang.Device
+		want string
+	}{
+		{"decimal64", systemDevice(yang.T_system_cont{Gain_Prsnt: true, Gain: -1250}), "a119ea64a107c482213904e1"},
+		{"bits", systemDevice(yang.T_system_cont{Flags_Prsnt: true, Flags: yang.T_system_flags_Up | yang.T_system_flags_Wide}), "a119ea64a109420102"},
+		{"enumeration", systemDevice(yang.T_system_cont{Color_Prsnt: true, Color: yang.T_color_Blue}), "a119ea64a10806"},
+		{"empty", systemDevice(yang.T_system_cont{Enabled_Prsnt: true}), "a119ea64a103f6"},
+		{"identity", systemDevice(yang.T_system_cont{Proto_Prsnt: true, Proto: "udp"}), "a119ea64a10a19ea63"},
+		{"uint64", systemDevice(yang.T_system_cont{Counter_Prsnt: true, Counter: 1<<64 - 1}), "a119ea64a1041bffffffffffffffff"},
+		{"int64", systemDevice(yang.T_system_cont{Offset_Prsnt: true, Offset: -1 << 63}), "a119ea64a1053b7fffffffffffffff"},
+		{"binary", systemDevice(yang.T_system_cont{Data_Prsnt: true, Data: yang.T_system_data{0, 1, 0xfe, 0xff}}), "a119ea64a10b440001feff"},
+		{"container", systemDevice(yang.T_system_cont{Timers_Prsnt: true, Timers: yang.T_system_timers_cont{Hello_Prsnt: true, Hello: 5}}), "a119ea64a10fa10105"},
+		{"list", &yang.Device{T_top_Prsnt: true, T_top: top}, "a119ea7ca10181a1016465746830"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := nc.MarshalCBORSid(tt.dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(b); got != tt.want {
+				t.Errorf("MarshalCBORSid() = %s, want %s", got, tt.want)
+			}
+			got := yang.Device{XMLName: dataName}
+			if err := nc.UnmarshalCBOR(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			tt.dev.XMLName = dataName
+			if !reflect.DeepEqual(&got, tt.dev) {
+				t.Errorf("UnmarshalCBOR() = %+v, want %+v", got, *tt.dev)
+			}
+		})
+	}
+}
+
+// The members identified by their names carry the same values
+func TestMarshalCBOR(t *testing.T) {
+	dev := systemDevice(yang.T_system_cont{Gain_Prsnt: true, Gain: 250, Color_Prsnt: true, Color: yang.T_color_Green})
+	b, err := nc.MarshalCBOR(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// {"test:system": {"gain": 4([-2, 250]), "color": 5}}
+	want := "a16b746573743a73797374656da2646761696ec4822118fa65636f6c6f7205"
+	if got := hex.EncodeToString(b); got != want {
+		t.Errorf("MarshalCBOR() = %s, want %s", got, want)
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	want := testDevice()
+	for _, marshal := range []func(interface{}) ([]byte, error){nc.MarshalCBOR, nc.MarshalCBORSid} {
+		b, err := marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := yang.Device{XMLName: dataName}
+		if err := nc.UnmarshalCBOR(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("UnmarshalCBOR() = %+v, want %+v", got, *want)
+		}
+	}
+}
+
+func TestUnmarshalCBORInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"unknown sid", "a119ea64a15001"},
+		{"enumeration", "a119ea64a10807"},
+		{"bits", "a119ea64a109420204"},
+		{"decimal64 digits", "a119ea64a107c482220b"},
+		{"range", "a119ea64a1020a"},
+		{"truncated", "a119ea64a1010b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.in)
+			var dev yang.Device
+			if err := nc.UnmarshalCBOR(b, &dev); err == nil {
+				t.Errorf("UnmarshalCBOR(%s) = %+v, want an error", tt.in, dev)
+			}
+		})
+	}
+}
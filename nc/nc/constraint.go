@@ -0,0 +1,147 @@
+package nc
+
+import (
+	"reflect"
+	"sync"
+
+	"nc/xpath"
+)
+
+// ConstraintKind is the statement a constraint comes from
+type ConstraintKind int
+
+const (
+	// Must is a must statement which the node must satisfy
+	Must ConstraintKind = iota
+	// When is a when statement without which the node must not exist
+	When
+	// Leafref is the path of a leaf of type leafref. The path is used by
+	// deref() and must select a node with the value of the leaf when
+	// RequireInstance is set.
+	Leafref
+)
+
+// Constraint is an XPath expression of the schema attached to a field of
+// a generated structure. The generated code registers a table of them for
+// each structure with fields that carry a must, a when or a leafref. The
+// context node is the node of the field or, when Parent is set, the node
+// that contains the field as for the when of an augment. The when of a
+// choice, a case or a uses applies to the node that contains them.
+type Constraint struct {
+	Field           string // the go name of the field
+	Kind            ConstraintKind
+	Parent          bool
+	Expr            string
+	Prefixes        map[string]string // the prefixes of the module of the expression
+	Message         string            // error-message
+	AppTag          string            // error-app-tag
+	RequireInstance bool              // require-instance of a leafref
+}
+
+// The constraints indexed by the type of the structure and the name of
+// the field
+var constraints sync.Map
+
+// RegisterConstraints registers the constraints of the fields of the
+// structure of v
+func RegisterConstraints(v interface{}, c []Constraint) {
+	m := map[string][]*Constraint{}
+	for i := range c {
+		m[c[i].Field] = append(m[c[i].Field], &c[i])
+	}
+	constraints.Store(reflect.TypeOf(v), m)
+}
+
+func constraintsOf(t reflect.Type) map[string][]*Constraint {
+	if m, ok := constraints.Load(t); ok {
+		return m.(map[string][]*Constraint)
+	}
+	return nil
+}
+
+func (c *Constraint) env() *xpath.Env {
+	return &xpath.Env{Prefixes: c.Prefixes, Bases: IdentityBases}
+}
+
+// CheckConstraints evaluates the must and when constraints of the nodes
+// within v and checks that the leafrefs which require an instance refer
+// to an existing node. The violations are added to errs. The path is the
+// one of v which is the root of the tree the expressions are evaluated on
+// unless it is the <data> of the device. The constraints of the node of v
+// itself belong to the structure that contains it and are not checked and
+// neither are those that refer to nodes outside of v.
+func CheckConstraints(v interface{}, path string, errs *ValidationErrors) {
+	root := newTree(v, path)
+	if root == nil {
+		return
+	}
+	root.check(errs)
+}
+
+// Check the constraints of the node and those of its descendants
+func (n *dataNode) check(errs *ValidationErrors) {
+	for _, c := range n.cons {
+		switch {
+		case c.Kind == Leafref:
+			if c.RequireInstance {
+				c.checkInstance(n, errs)
+			}
+		case c.Parent:
+			// The constraint is for the field as a whole and is
+			// checked once for the entries of a list
+			if n.pos <= 0 {
+				c.check(n.parent, n.path, errs)
+			}
+		default:
+			c.check(n, n.path, errs)
+		}
+	}
+	for _, c := range n.inline {
+		c.check(n, n.path, errs)
+	}
+	for _, child := range n.Children() {
+		child.(*dataNode).check(errs)
+	}
+}
+
+func (c *Constraint) check(ctx *dataNode, path string, errs *ValidationErrors) {
+	e, err := xpath.CompileCached(c.Expr)
+	if err != nil {
+		errs.Add(path, "", err.Error())
+		return
+	}
+	ctx.tree.escaped = false
+	ok, err := e.Bool(c.env(), ctx)
+	switch {
+	case ctx.tree.escaped:
+	case err != nil:
+		errs.Add(path, "", err.Error())
+	case ok:
+	case c.Kind == When:
+		errs.Add(path, "", "when condition "+c.Expr+" is not satisfied")
+	default:
+		tag, msg := c.AppTag, c.Message
+		if tag == "" {
+			tag = "must-violation"
+		}
+		if msg == "" {
+			msg = "must condition " + c.Expr + " is not satisfied"
+		}
+		errs.Add(path, tag, msg)
+	}
+}
+
+// The path of the leafref must select a node with the value of the leaf.
+// The predicates of the path are evaluated as well so that the target is
+// in the entry selected by them.
+func (c *Constraint) checkInstance(n *dataNode, errs *ValidationErrors) {
+	n.tree.escaped = false
+	targets, err := n.deref(c)
+	switch {
+	case n.tree.escaped:
+	case err != nil:
+		errs.Add(n.path, "", err.Error())
+	case len(targets) == 0:
+		errs.Add(n.path, "instance-required", "leafref "+c.Expr+" refers to no instance of "+n.Value())
+	}
+}
@@ -0,0 +1,65 @@
+package nc_test
+
+import (
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+// The violations by path with their error-app-tag
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+	m := map[string]string{}
+	if err == nil {
+		return m
+	}
+	errs, ok := err.(nc.ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() = %v, want violations", err)
+	}
+	for _, e := range errs {
+		m[e.Path] = e.AppTag
+	}
+	return m
+}
+
+func testTop(ref string, mtus ...uint16) yang.T_top_cont {
+	var top yang.T_top_cont
+	for i, mtu := range mtus {
+		top.SetIntf(yang.T_top_intf{Name: string(rune('a' + i)), Mtu_Prsnt: true, Mtu: mtu})
+	}
+	top.Ref, top.Ref_Prsnt = ref, true
+	return top
+}
+
+// The node-sets of several nodes are sorted in document order, which
+// doesn't move the evaluation above the structure validated alone
+func TestConstraintsOfPart(t *testing.T) {
+	tests := []struct {
+		name string
+		top  yang.T_top_cont
+		path string
+		tag  string
+	}{
+		{"leafref", testTop("nope", 1500, 9000), "/test:top/ref", "instance-required"},
+		{"must", testTop("a", 1500, 1500), "/test:top/intf[name='a']", "mtu-unique"},
+		{"valid", testTop("b", 1500, 9000, 68), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, err := range []error{tt.top.Validate(), yang.Device{T_top_Prsnt: true, T_top: tt.top}.Validate()} {
+				got := violations(t, err)
+				if tt.path == "" {
+					if len(got) != 0 {
+						t.Errorf("violations %v, want none", got)
+					}
+					continue
+				}
+				if tag, ok := got[tt.path]; !ok || tag != tt.tag {
+					t.Errorf("violations %v, want %s at %s", got, tt.tag, tt.path)
+				}
+			}
+		})
+	}
+}
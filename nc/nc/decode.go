@@ -0,0 +1,248 @@
+package nc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// Decoder reads the XML encoding of the generated structures. The
+// elements that have no matching field are skipped.
+type Decoder struct {
+	d *xml.Decoder
+}
+
+// NewDecoder returns a decoder that reads from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{d: xml.NewDecoder(r)}
+}
+
+// Unmarshal decodes the first element of data into v which must be a
+// pointer to a structure.
+func Unmarshal(data []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Token returns the next token. The names of the elements carry the
+// namespace in Space.
+func (d *Decoder) Token() (xml.Token, error) {
+	return d.d.Token()
+}
+
+// Skip skips the rest of the current element.
+func (d *Decoder) Skip() error {
+	return d.d.Skip()
+}
+
+// Decode reads the next element and decodes it into v.
+func (d *Decoder) Decode(v interface{}) error {
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return d.DecodeElement(v, &start)
+		}
+	}
+}
+
+// DecodeElement decodes the element whose start has already been read
+// into v which must be a pointer.
+func (d *Decoder) DecodeElement(v interface{}, start *xml.StartElement) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("nc: can't decode into %T", v)
+	}
+	return d.decodeElement(rv.Elem(), start)
+}
+
+func (d *Decoder) decodeElement(v reflect.Value, start *xml.StartElement) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := asTextUnmarshaler(v); ok {
+		text, err := d.text()
+		if err != nil {
+			return err
+		}
+		if err := u.UnmarshalText(start.Name.Space, text); err != nil {
+			return fmt.Errorf("nc: %s: %s", start.Name.Local, err.Error())
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		if err := d.decodeElement(e, start); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, e))
+		return nil
+	case reflect.Struct:
+		return d.decodeStruct(v, start)
+	case reflect.Interface:
+		return d.d.Skip()
+	}
+	text, err := d.text()
+	if err != nil {
+		return err
+	}
+	if err := unmarshalBasic(v, text); err != nil {
+		return fmt.Errorf("nc: %s: %s", start.Name.Local, err.Error())
+	}
+	return nil
+}
+
+// Decode the children of an element into the fields of a structure. The
+// presence fields of the nodes found are set along with those of the
+// choices and cases that contain them.
+func (d *Decoder) decodeStruct(v reflect.Value, start *xml.StartElement) error {
+	ti := getTypeInfo(v.Type())
+	if ti.xmlname != nil {
+		if id, ok := v.Field(ti.xmlname.idx).Addr().Interface().(*XmlId); ok {
+			id.Space, id.Local = start.Name.Space, start.Name.Local
+		}
+	}
+	if err := decodeEditAttrs(v, start.Attr); err != nil {
+		return err
+	}
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path := ti.lookup(t.Name.Space, t.Name.Local)
+			if path == nil {
+				if err := d.d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.decodeField(v, path, &t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (d *Decoder) decodeField(v reflect.Value, path []*fieldInfo, start *xml.StartElement) error {
+	fv, fi := walkPath(v, path)
+	if fi.empty {
+		fv.SetBool(true)
+		return d.d.Skip()
+	}
+	return d.decodeElement(fv, start)
+}
+
+// Walk the path to a field from the structure passed. The presence fields
+// along the path are set as the node is present and so are the choices,
+// the cases and the groupings that contain it.
+func walkPath(v reflect.Value, path []*fieldInfo) (reflect.Value, *fieldInfo) {
+	for i, fi := range path {
+		if fi.prsnt >= 0 {
+			v.Field(fi.prsnt).SetBool(true)
+		}
+		fv := v.Field(fi.idx)
+		if i == len(path)-1 {
+			return fv, fi
+		}
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		v = fv
+	}
+	return reflect.Value{}, nil
+}
+
+// The text of a leaf. Any element within the leaf is ignored.
+func (d *Decoder) text() ([]byte, error) {
+	var b []byte
+	depth := 0
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			if depth == 0 {
+				b = append(b, t...)
+			}
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return bytes.TrimSpace(b), nil
+			}
+			depth--
+		}
+	}
+}
+
+func asTextUnmarshaler(v reflect.Value) (TextUnmarshaler, bool) {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(TextUnmarshaler); ok {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func unmarshalBasic(v reflect.Value, text []byte) error {
+	s := string(text)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+	default:
+		return fmt.Errorf("can't decode into %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package nc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+// The names may be qualified by any prefix and the identities by the
+// prefixes declared where they appear
+func TestUnmarshalPrefixes(t *testing.T) {
+	in := `<nc:data xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:a="urn:test">
+  <a:system>
+    <a:hostname>r1</a:hostname>
+    <proto xmlns="urn:test" xmlns:b="urn:test">b:tcp</proto>
+    <a:enabled/>
+  </a:system>
+</nc:data>`
+	var got yang.Device
+	if err := nc.Unmarshal([]byte(in), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := yang.Device{XMLName: dataName, T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Hostname_Prsnt: true, Hostname: "r1", Proto_Prsnt: true, Proto: "tcp", Enabled_Prsnt: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"range", `<system xmlns="urn:test"><mtu>10</mtu></system>`},
+		{"enum", `<system xmlns="urn:test"><color>black</color></system>`},
+		{"bits", `<system xmlns="urn:test"><flags>up left</flags></system>`},
+		{"decimal", `<system xmlns="urn:test"><gain>1.234</gain></system>`},
+		{"identity", `<system xmlns="urn:test"><proto>sctp</proto></system>`},
+		{"int", `<system xmlns="urn:test"><level>128</level></system>`},
+		{"binary", `<system xmlns="urn:test"><data>%%</data></system>`},
+		{"xml", `<system xmlns="urn:test"><hostname>r1</system>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sys yang.T_system_cont
+			if err := nc.Unmarshal([]byte(tt.in), &sys); err == nil {
+				t.Errorf("Unmarshal(%s) = %+v, want an error", tt.in, sys)
+			}
+		})
+	}
+}
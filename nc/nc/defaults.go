@@ -0,0 +1,188 @@
+package nc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// The namespace of the attribute default of with-defaults report-all-tagged
+const DefaultAttrNs = "urn:ietf:params:xml:ns:netconf:default:1.0"
+
+// WithDefaults is the mode of RFC 6243 in which the leaves that have a
+// default value are encoded
+type WithDefaults int
+
+const (
+	// Explicit encodes the leaves that are present whatever their value
+	Explicit WithDefaults = iota
+	// ReportAll encodes the leaves set to their default in addition
+	ReportAll
+	// Trim leaves out the leaves whose value is their default
+	Trim
+	// ReportAllTagged is ReportAll with the leaves whose value is their
+	// default tagged with the attribute default
+	ReportAllTagged
+)
+
+var withDefaultsNames = []string{"explicit", "report-all", "trim", "report-all-tagged"}
+
+func (m WithDefaults) String() string {
+	if m < 0 || int(m) >= len(withDefaultsNames) {
+		return fmt.Sprintf("WithDefaults(%d)", int(m))
+	}
+	return withDefaultsNames[m]
+}
+
+// ParseWithDefaults returns the mode named as in the parameter
+// with-defaults of the operations
+func ParseWithDefaults(s string) (WithDefaults, error) {
+	for i, name := range withDefaultsNames {
+		if s == name {
+			return WithDefaults(i), nil
+		}
+	}
+	return Explicit, fmt.Errorf("nc: unknown with-defaults mode %s", s)
+}
+
+// Defaulter is implemented by the generated containers and lists which
+// set the nodes within that are not present to their default
+type Defaulter interface {
+	SetDefaults()
+}
+
+// The default values indexed by the type of the structure and the name
+// of the field
+var defaults sync.Map
+
+// RegisterDefaults registers the default values of the leaves and the
+// leaf-lists of the structure of v. The values are in the lexical form
+// of the schema.
+func RegisterDefaults(v interface{}, d map[string][]string) {
+	defaults.Store(reflect.TypeOf(v), d)
+}
+
+func defaultsOf(t reflect.Type) map[string][]string {
+	if d, ok := defaults.Load(t); ok {
+		return d.(map[string][]string)
+	}
+	return nil
+}
+
+// DefaultOf returns the default values of the field named field of the
+// structure of v. A leaf has a single value.
+func DefaultOf(v interface{}, field string) []string {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil
+	}
+	return defaultsOf(rv.Type())[field]
+}
+
+// SetDefault sets the leaf pointed to by v to its default value or
+// appends the values to the leaf-list pointed to by v
+func SetDefault(v interface{}, values ...string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("nc: can't set default of %T", v)
+	}
+	rv = rv.Elem()
+	if isLeafValue(rv) {
+		if len(values) != 1 {
+			return fmt.Errorf("nc: a leaf has a single default")
+		}
+		return parseDefault(rv, values[0])
+	}
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("nc: can't set default of %T", v)
+	}
+	s := reflect.MakeSlice(rv.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := parseDefault(s.Index(i), value); err != nil {
+			return err
+		}
+	}
+	rv.Set(reflect.AppendSlice(rv, s))
+	return nil
+}
+
+// The defaults are parsed without the namespace of the element which
+// the generated types don't need to read a value
+func parseDefault(v reflect.Value, s string) error {
+	if u, ok := asTextUnmarshaler(v); ok {
+		return u.UnmarshalText("", []byte(s))
+	}
+	return unmarshalBasic(v, []byte(s))
+}
+
+// Whether the value of a leaf or a leaf-list is its default. The values
+// are compared in their canonical form.
+func isDefault(v reflect.Value, ns string, def []string) bool {
+	if isLeafValue(v) {
+		return len(def) == 1 && sameValue(v, ns, def[0])
+	}
+	if v.Kind() != reflect.Slice || v.Len() != len(def) {
+		return false
+	}
+	for i := range def {
+		if !sameValue(v.Index(i), ns, def[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameValue(v reflect.Value, ns string, def string) bool {
+	d := reflect.New(v.Type()).Elem()
+	if parseDefault(d, def) != nil {
+		return false
+	}
+	a, err := marshalText(v, ns)
+	if err != nil {
+		return false
+	}
+	b, err := marshalText(d, ns)
+	return err == nil && string(a) == string(b)
+}
+
+// The copy of v with the nodes that are not present set to their default.
+// The value is copied so that v isn't changed.
+func withDefaults(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type())
+	c.Elem().Set(deepCopy(v))
+	if d, ok := c.Interface().(Defaulter); ok {
+		d.SetDefaults()
+	}
+	return c.Elem()
+}
+
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,97 @@
+package nc_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+// The leaves that are not present take their default, in the presence
+// containers that are present only
+func TestSetDefaults(t *testing.T) {
+	dev := yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Mtu_Prsnt: true, Mtu: 9000, Timers_Prsnt: true, Timers: yang.T_system_timers_cont{Dead_Prsnt: true, Dead: 40}}}
+	dev.SetDefaults()
+	sys := dev.T_system
+	want := yang.T_system_cont{Mtu_Prsnt: true, Mtu: 9000, Color_Prsnt: true, Color: yang.T_color_Green,
+		Timers_Prsnt: true, Timers: yang.T_system_timers_cont{Hello_Prsnt: true, Hello: 10, Dead_Prsnt: true, Dead: 40}}
+	if !reflect.DeepEqual(sys, want) {
+		t.Errorf("SetDefaults() = %+v, want %+v", sys, want)
+	}
+
+	sys = yang.T_system_cont{Debug_Prsnt: true}
+	sys.SetDefaults()
+	if !sys.Debug.Level_Prsnt || sys.Debug.Level != 1 {
+		t.Errorf("SetDefaults() = %+v, want the level of debug set", sys.Debug)
+	}
+
+	// A container without presence is there when a node within is
+	var empty yang.Device
+	empty.SetDefaults()
+	if !empty.T_system_Prsnt || !empty.T_system.Mtu_Prsnt || empty.T_system.Mtu != 1500 {
+		t.Errorf("SetDefaults() = %+v, want the defaults of system", empty.T_system)
+	}
+}
+
+func TestDefaultOf(t *testing.T) {
+	if got := nc.DefaultOf(yang.T_system_cont{}, "Mtu"); !reflect.DeepEqual(got, []string{"1500"}) {
+		t.Errorf("DefaultOf(Mtu) = %v", got)
+	}
+	if got := nc.DefaultOf(&yang.T_system_cont{}, "Hostname"); got != nil {
+		t.Errorf("DefaultOf(Hostname) = %v", got)
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	const (
+		head   = `<data xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><system xmlns="urn:test">`
+		tail   = `</system></data>`
+		tagged = ` xmlns:wd="urn:ietf:params:xml:ns:netconf:default:1.0" wd:default="true"`
+	)
+	tests := []struct {
+		mode nc.WithDefaults
+		want string
+	}{
+		{nc.Explicit, `<timers><dead>40</dead></timers><debug></debug><hostname>r1</hostname><mtu>1500</mtu>`},
+		{nc.Trim, `<timers><dead>40</dead></timers><debug></debug><hostname>r1</hostname>`},
+		{nc.ReportAll, `<timers><hello>10</hello><dead>40</dead></timers><debug><level>1</level></debug>` +
+			`<hostname>r1</hostname><mtu>1500</mtu><color>green</color>`},
+		{nc.ReportAllTagged, `<timers><hello` + tagged + `>10</hello><dead>40</dead></timers>` +
+			`<debug><level` + tagged + `>1</level></debug><hostname>r1</hostname>` +
+			`<mtu` + tagged + `>1500</mtu><color` + tagged + `>green</color>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			dev := &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+				Hostname_Prsnt: true, Hostname: "r1", Mtu_Prsnt: true, Mtu: 1500, Debug_Prsnt: true,
+				Timers_Prsnt: true, Timers: yang.T_system_timers_cont{Dead_Prsnt: true, Dead: 40}}}
+			before := *dev
+			var b bytes.Buffer
+			e := nc.NewEncoder(&b)
+			e.SetWithDefaults(tt.mode)
+			if err := e.Encode(dev); err != nil {
+				t.Fatal(err)
+			}
+			if want := head + tt.want + tail; b.String() != want {
+				t.Errorf("Encode() = %s, want %s", b.String(), want)
+			}
+			if !reflect.DeepEqual(*dev, before) {
+				t.Errorf("Encode() changed the data to %+v", *dev)
+			}
+		})
+	}
+}
+
+func TestParseWithDefaults(t *testing.T) {
+	for _, mode := range []nc.WithDefaults{nc.Explicit, nc.ReportAll, nc.Trim, nc.ReportAllTagged} {
+		if got, err := nc.ParseWithDefaults(mode.String()); err != nil || got != mode {
+			t.Errorf("ParseWithDefaults(%s) = %v, %v", mode, got, err)
+		}
+	}
+	if _, err := nc.ParseWithDefaults("all"); err == nil {
+		t.Errorf("ParseWithDefaults(all) succeeded")
+	}
+}
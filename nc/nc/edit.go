@@ -0,0 +1,171 @@
+package nc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Operation is the operation of edit-config on a node. The generated
+// containers and lists carry it as the field XMLOp tagged ",operation"
+// and it is encoded as the attribute operation of the netconf namespace.
+type Operation string
+
+const (
+	// OpNone leaves the operation to the parent or to default-operation
+	OpNone    Operation = ""
+	OpMerge   Operation = "merge"
+	OpReplace Operation = "replace"
+	OpCreate  Operation = "create"
+	OpDelete  Operation = "delete"
+	OpRemove  Operation = "remove"
+	// OpNoop is the default-operation none. Only the nodes with an
+	// operation of their own are changed.
+	OpNoop Operation = "none"
+)
+
+// InsertWhere is where an entry of a list ordered by the user is inserted
+type InsertWhere string
+
+const (
+	InsertNone   InsertWhere = ""
+	InsertFirst  InsertWhere = "first"
+	InsertLast   InsertWhere = "last"
+	InsertBefore InsertWhere = "before"
+	InsertAfter  InsertWhere = "after"
+)
+
+// Insert is the position at which edit-config inserts an entry of a list
+// ordered by the user. The keys identify the entry before or after which
+// it is inserted. The generated entries of such lists carry it as the
+// field XMLInsert tagged ",insert" and it is encoded as the attributes
+// insert and key of the yang namespace.
+type Insert struct {
+	Where InsertWhere
+	Key   []KeyValue
+}
+
+// SetInsert sets the position at which edit-config inserts the generated
+// entry v points to. It fails unless the entry is one of a list ordered
+// by the user.
+func SetInsert(v interface{}, ins Insert) error {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Struct && !isLeafValue(rv) {
+		if ti := getTypeInfo(rv.Type()); ti.insert != nil {
+			rv.Field(ti.insert.idx).Set(reflect.ValueOf(ins))
+			return nil
+		}
+	}
+	return fmt.Errorf("nc: %T isn't an entry of a list ordered by the user", v)
+}
+
+// The attributes of the operation and the insertion of a node
+func editAttrs(v reflect.Value, ns string) string {
+	ti := getTypeInfo(v.Type())
+	var b strings.Builder
+	if ti.op != nil {
+		if op, _ := v.Field(ti.op.idx).Interface().(Operation); op != OpNone {
+			b.WriteString(" xmlns:nc=\"" + NetconfNs + "\" nc:operation=\"")
+			xml.EscapeText(&b, []byte(op))
+			b.WriteString("\"")
+		}
+	}
+	if ti.insert != nil {
+		if ins, _ := v.Field(ti.insert.idx).Interface().(Insert); ins.Where != InsertNone {
+			b.WriteString(" xmlns:yang=\"" + YangNs + "\" yang:insert=\"")
+			xml.EscapeText(&b, []byte(ins.Where))
+			b.WriteString("\"")
+			if len(ins.Key) > 0 {
+				b.WriteString(keyAttr(ins.Key, ns))
+			}
+		}
+	}
+	return b.String()
+}
+
+// The attribute key made of a predicate for each key. The names of the
+// keys are qualified by the prefix of the module of the list which is
+// declared along.
+func keyAttr(keys []KeyValue, ns string) string {
+	prefix := ""
+	var b strings.Builder
+	if m, ok := ModuleByNs(ns); ok && m.Prefix != "" {
+		prefix = m.Prefix + ":"
+		b.WriteString(" xmlns:" + m.Prefix + "=\"")
+		xml.EscapeText(&b, []byte(ns))
+		b.WriteString("\"")
+	}
+	var pred strings.Builder
+	for _, k := range keys {
+		pred.WriteString("[" + prefix + k.Name + "=" + quoteValue(keyText(k.Value, ns)) + "]")
+	}
+	b.WriteString(" yang:key=\"")
+	xml.EscapeText(&b, []byte(pred.String()))
+	b.WriteString("\"")
+	return b.String()
+}
+
+// Set the operation and the insertion of a node from the attributes of
+// its element
+func decodeEditAttrs(v reflect.Value, attrs []xml.Attr) error {
+	ti := getTypeInfo(v.Type())
+	var op *Operation
+	var ins *Insert
+	if ti.op != nil {
+		op, _ = v.Field(ti.op.idx).Addr().Interface().(*Operation)
+	}
+	if ti.insert != nil {
+		ins, _ = v.Field(ti.insert.idx).Addr().Interface().(*Insert)
+	}
+	for _, a := range attrs {
+		switch {
+		case a.Name.Space == NetconfNs && a.Name.Local == "operation" && op != nil:
+			*op = Operation(a.Value)
+		case a.Name.Space == YangNs && a.Name.Local == "insert" && ins != nil:
+			ins.Where = InsertWhere(a.Value)
+		case a.Name.Space == YangNs && a.Name.Local == "key" && ins != nil:
+			keys, err := parsePredicates(a.Value)
+			if err != nil {
+				return err
+			}
+			ins.Key = keys
+		}
+	}
+	return nil
+}
+
+// Parse the predicates [name='value'] of the attribute key. The values
+// are returned as strings.
+func parsePredicates(s string) ([]KeyValue, error) {
+	var keys []KeyValue
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		if rest[0] != '[' {
+			return nil, fmt.Errorf("nc: invalid key %s", s)
+		}
+		name, value, ok := strings.Cut(rest[1:], "=")
+		if !ok {
+			return nil, fmt.Errorf("nc: invalid key %s", s)
+		}
+		name = strings.TrimSpace(name)
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			name = name[i+1:]
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || (value[0] != '\'' && value[0] != '"') {
+			return nil, fmt.Errorf("nc: invalid key %s", s)
+		}
+		end := strings.IndexByte(value[1:], value[0])
+		if end < 0 {
+			return nil, fmt.Errorf("nc: invalid key %s", s)
+		}
+		keys = append(keys, KeyValue{Name: name, Value: value[1 : end+1]})
+		rest = strings.TrimSpace(value[end+2:])
+		if rest == "" || rest[0] != ']' {
+			return nil, fmt.Errorf("nc: invalid key %s", s)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return keys, nil
+}
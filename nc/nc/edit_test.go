@@ -0,0 +1,184 @@
+package nc_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+// The running configuration the edits apply to
+func testRunning() *yang.Device {
+	dev := &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Hostname_Prsnt: true, Hostname: "r1", Mtu_Prsnt: true, Mtu: 1500}}
+	for _, name := range []string{"a", "b", "c"} {
+		dev.SetT_item(yang.T_item{Name: name})
+	}
+	return dev
+}
+
+func itemNames(dev *yang.Device) string {
+	var names []string
+	for _, it := range dev.T_item {
+		names = append(names, it.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func item(name string, op nc.Operation, where nc.InsertWhere, key string) yang.T_item {
+	it := yang.T_item{XMLOp: op, Name_Prsnt: true, Name: name}
+	it.XMLInsert.Where = where
+	if key != "" {
+		it.XMLInsert.Key = []nc.KeyValue{{Name: "name", Value: key}}
+	}
+	return it
+}
+
+// The operation and the position are attributes of the elements
+func TestEditAttributes(t *testing.T) {
+	config := &yang.Device{
+		T_system_Prsnt: true, T_system: yang.T_system_cont{XMLOp: nc.OpReplace, Hostname_Prsnt: true, Hostname: "r2"},
+		T_item: []yang.T_item{item("d", nc.OpCreate, nc.InsertAfter, "a")},
+	}
+	b, err := nc.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<data xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">` +
+		`<system xmlns="urn:test" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="replace"><hostname>r2</hostname></system>` +
+		`<item xmlns="urn:test" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="create"` +
+		` xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="after" xmlns:t="urn:test" yang:key="[t:name=&#39;a&#39;]">` +
+		`<name>d</name></item></data>`
+	if string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+	got := yang.Device{XMLName: dataName}
+	if err := nc.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	config.XMLName = dataName
+	if !reflect.DeepEqual(&got, config) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, *config)
+	}
+}
+
+func TestUnmarshalInsertKey(t *testing.T) {
+	in := `<item xmlns="urn:test" xmlns:yang="urn:ietf:params:xml:ns:yang:1" xmlns:x="urn:test"` +
+		` yang:insert="before" yang:key="[x:name=&quot;it&apos;s&quot;]"><name>d</name></item>`
+	var it yang.T_item
+	if err := nc.Unmarshal([]byte(in), &it); err != nil {
+		t.Fatal(err)
+	}
+	want := nc.Insert{Where: nc.InsertBefore, Key: []nc.KeyValue{{Name: "name", Value: "it's"}}}
+	if !reflect.DeepEqual(it.XMLInsert, want) {
+		t.Errorf("XMLInsert = %+v, want %+v", it.XMLInsert, want)
+	}
+	in = `<item xmlns="urn:test" xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="before" yang:key="name=a"><name>d</name></item>`
+	if err := nc.Unmarshal([]byte(in), &it); err == nil {
+		t.Errorf("Unmarshal() of an invalid key succeeded")
+	}
+}
+
+func TestSetInsert(t *testing.T) {
+	var it yang.T_item
+	ins := nc.Insert{Where: nc.InsertFirst}
+	if err := nc.SetInsert(&it, ins); err != nil || !reflect.DeepEqual(it.XMLInsert, ins) {
+		t.Errorf("SetInsert() = %v, %+v", err, it.XMLInsert)
+	}
+	if err := nc.SetInsert(&yang.T_top_intf{}, ins); err == nil {
+		t.Errorf("SetInsert() of an entry of a list ordered by the system succeeded")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *yang.Device
+		op     nc.Operation
+		check  func(*yang.Device) bool
+		tag    string
+		path   string
+	}{
+		{"merge", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r2"}}, nc.OpNone,
+			func(d *yang.Device) bool { return d.T_system.Hostname == "r2" && d.T_system.Mtu_Prsnt }, "", ""},
+		{"replace", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{XMLOp: nc.OpReplace, Hostname_Prsnt: true, Hostname: "r2"}}, nc.OpNone,
+			func(d *yang.Device) bool { return d.T_system.Hostname == "r2" && !d.T_system.Mtu_Prsnt }, "", ""},
+		{"create existing", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{XMLOp: nc.OpCreate}}, nc.OpNone,
+			nil, "data-exists", "/test:system"},
+		{"create leaf", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r2"}}, nc.OpCreate,
+			nil, "data-exists", "/test:system"},
+		{"delete", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{XMLOp: nc.OpDelete}}, nc.OpNone,
+			func(d *yang.Device) bool { return !d.T_system_Prsnt && d.T_system.Hostname == "" }, "", ""},
+		{"delete missing", &yang.Device{T_top_Prsnt: true, T_top: yang.T_top_cont{XMLOp: nc.OpDelete}}, nc.OpNone,
+			nil, "data-missing", "/test:top"},
+		{"remove missing", &yang.Device{T_top_Prsnt: true, T_top: yang.T_top_cont{XMLOp: nc.OpRemove}}, nc.OpNone,
+			func(d *yang.Device) bool { return !d.T_top_Prsnt }, "", ""},
+		{"delete entry", &yang.Device{T_item: []yang.T_item{item("b", nc.OpDelete, "", "")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "a c" }, "", ""},
+		{"delete missing entry", &yang.Device{T_item: []yang.T_item{item("d", nc.OpDelete, "", "")}}, nc.OpNone,
+			nil, "data-missing", "/test:item[name='d']"},
+		{"create entry", &yang.Device{T_item: []yang.T_item{item("d", nc.OpCreate, "", "")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "a b c d" }, "", ""},
+		{"create existing entry", &yang.Device{T_item: []yang.T_item{item("a", nc.OpCreate, "", "")}}, nc.OpNone,
+			nil, "data-exists", "/test:item[name='a']"},
+		{"none", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r2"},
+			T_item: []yang.T_item{item("d", nc.OpCreate, "", "")}}, nc.OpNoop,
+			func(d *yang.Device) bool { return d.T_system.Hostname == "r1" && itemNames(d) == "a b c d" }, "", ""},
+		{"insert first", &yang.Device{T_item: []yang.T_item{item("d", nc.OpNone, nc.InsertFirst, "")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "d a b c" }, "", ""},
+		{"insert after", &yang.Device{T_item: []yang.T_item{item("d", nc.OpNone, nc.InsertAfter, "a")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "a d b c" }, "", ""},
+		{"insert before", &yang.Device{T_item: []yang.T_item{item("d", nc.OpNone, nc.InsertBefore, "c")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "a b d c" }, "", ""},
+		{"move last", &yang.Device{T_item: []yang.T_item{item("a", nc.OpNone, nc.InsertLast, "")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "b c a" }, "", ""},
+		{"move after", &yang.Device{T_item: []yang.T_item{item("a", nc.OpMerge, nc.InsertAfter, "b")}}, nc.OpNone,
+			func(d *yang.Device) bool { return itemNames(d) == "b a c" }, "", ""},
+		{"missing point", &yang.Device{T_item: []yang.T_item{item("d", nc.OpNone, nc.InsertAfter, "e")}}, nc.OpNone,
+			nil, "bad-attribute", "/test:item[name='d']"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			running := testRunning()
+			err := nc.Edit(running, tt.config, tt.op)
+			if tt.tag != "" {
+				var rerr *nc.RPCError
+				if !errors.As(err, &rerr) || rerr.Tag != tt.tag || rerr.Path != tt.path {
+					t.Errorf("Edit() = %v, want %s at %s", err, tt.tag, tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(running) {
+				t.Errorf("Edit() = %+v", *running)
+			}
+		})
+	}
+}
+
+func TestEditInvalid(t *testing.T) {
+	running := testRunning()
+	if err := nc.Edit(*running, &yang.Device{}, nc.OpMerge); err == nil {
+		t.Errorf("Edit() of a device that isn't a pointer succeeded")
+	}
+	if err := nc.Edit(running, &yang.T_system_cont{}, nc.OpMerge); err == nil {
+		t.Errorf("Edit() with a config of another type succeeded")
+	}
+}
+
+func TestClone(t *testing.T) {
+	running := testRunning()
+	c := nc.Clone(running).(*yang.Device)
+	if !reflect.DeepEqual(c, running) {
+		t.Fatalf("Clone() = %+v, want %+v", *c, *running)
+	}
+	c.T_item[0].Name = "z"
+	if running.T_item[0].Name != "a" {
+		t.Errorf("the clone shares the entries of the list")
+	}
+}
@@ -0,0 +1,345 @@
+package nc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes the generated structures as XML. The namespace of an
+// element is declared only when it differs from the one of its parent.
+type Encoder struct {
+	w    *bufio.Writer
+	mode WithDefaults
+	// Encoding a subtree filter, the keys are those of the entry whose
+	// fields are being encoded
+	filter bool
+	keys   map[string]bool
+}
+
+// NewEncoder returns an encoder that writes to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// SetWithDefaults sets the mode in which the leaves that have a default
+// are encoded. The mode is Explicit unless set.
+func (e *Encoder) SetWithDefaults(mode WithDefaults) {
+	e.mode = mode
+}
+
+// Marshal returns the XML encoding of v. The name of the element is
+// taken from the field XMLName of v.
+func Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// MarshalElement returns the XML encoding of v as the element with the
+// namespace and the name passed.
+func MarshalElement(v interface{}, ns, name string) ([]byte, error) {
+	var b bytes.Buffer
+	if err := NewEncoder(&b).EncodeElement(v, ns, name); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Encode writes the XML encoding of v. The name of the element is taken
+// from the field XMLName. If the tag of XMLName doesn't carry namespace,
+// the namespace provided by RuntimeNs() is used.
+func (e *Encoder) Encode(v interface{}) error {
+	if a, ok := v.(Action); ok {
+		return e.encodeAction(a)
+	}
+	if a, ok := v.(*Action); ok {
+		return e.encodeAction(*a)
+	}
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return fmt.Errorf("nc: can't encode %T without the name of the element", v)
+	}
+	ns, name := elementName(rv)
+	if name == "" {
+		return fmt.Errorf("nc: can't encode %T without XMLName", v)
+	}
+	return e.EncodeElement(rv.Interface(), ns, name)
+}
+
+// EncodeElement writes the XML encoding of v as the element with the
+// namespace and the name passed.
+func (e *Encoder) EncodeElement(v interface{}, ns, name string) error {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.IsValid() && (e.mode == ReportAll || e.mode == ReportAllTagged) {
+		rv = withDefaults(rv)
+	}
+	if err := e.encodeElement(rv, "", ns, name); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// ElementName returns the namespace and the name of the element v is
+// encoded as. The name is empty when v isn't a structure with XMLName.
+func ElementName(v interface{}) (string, string) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return "", ""
+	}
+	return elementName(rv)
+}
+
+// The name of the element of a structure from its XMLName. The value of
+// XMLName takes precedence over the tag as it is set when decoding.
+func elementName(v reflect.Value) (string, string) {
+	ti := getTypeInfo(v.Type())
+	if ti.xmlname == nil {
+		return "", ""
+	}
+	ns, name := ti.xmlname.ns, ti.xmlname.name
+	if id, ok := v.Field(ti.xmlname.idx).Interface().(XmlId); ok && id.Local != "" {
+		ns, name = id.Space, id.Local
+	}
+	if ns == "" {
+		ns = runtimeNs(v)
+	}
+	return ns, name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func runtimeNs(v reflect.Value) string {
+	if r, ok := v.Interface().(RuntimeNser); ok {
+		return r.RuntimeNs()
+	}
+	return ""
+}
+
+// Encode a value as an element. The namespace of the element is the one
+// of the parent unless specified.
+func (e *Encoder) encodeElement(v reflect.Value, parentNs, ns, name string) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if ns == "" {
+		ns = parentNs
+	}
+	if isLeafValue(v) {
+		return e.encodeLeaf(v, parentNs, ns, name, "")
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encodeElement(v.Index(i), parentNs, ns, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		if e.filter {
+			keys := e.keys
+			e.keys = entryKeys(v)
+			defer func() { e.keys = keys }()
+		}
+		attrs := ""
+		if !e.filter {
+			attrs = editAttrs(v, ns)
+		}
+		e.startElement(name, ns, parentNs, attrs)
+		if err := e.encodeFields(v, ns, ns); err != nil {
+			return err
+		}
+		e.endElement(name)
+		return nil
+	}
+	return fmt.Errorf("nc: %s: can't encode %s", name, v.Type())
+}
+
+func (e *Encoder) encodeLeaf(v reflect.Value, parentNs, ns, name, attrs string) error {
+	text, err := marshalText(v, ns)
+	if err != nil {
+		return fmt.Errorf("nc: %s: %s", name, err.Error())
+	}
+	e.startElement(name, ns, parentNs, prefixDecl(v)+attrs)
+	xml.EscapeText(e.w, text)
+	e.endElement(name)
+	return nil
+}
+
+// The attribute that tags a leaf set to its default
+const defaultAttr = ` xmlns:wd="` + DefaultAttrNs + `" wd:default="true"`
+
+// Encode the fields of a structure as the children of an element. The
+// fields of choices, cases and groupings are encoded as if they were the
+// fields of the structure itself. The leaves whose value is their default
+// are left out or tagged as per the mode of with-defaults. The namespace
+// of the children is ns unless they have their own and it is declared
+// when it differs from parentNs, the one in effect.
+func (e *Encoder) encodeFields(v reflect.Value, parentNs, ns string) error {
+	ti := getTypeInfo(v.Type())
+	var defs map[string][]string
+	if e.mode == Trim || e.mode == ReportAllTagged {
+		defs = defaultsOf(v.Type())
+	}
+	for _, fi := range ti.fields {
+		fv := v.Field(fi.idx)
+		if fi.prsnt >= 0 && !v.Field(fi.prsnt).Bool() {
+			continue
+		}
+		if fi.empty {
+			if fv.Bool() {
+				e.startElement(fi.name, nsOr(fi.ns, ns), parentNs, "")
+				e.endElement(fi.name)
+			}
+			continue
+		}
+		if fi.inline {
+			if fv = indirect(fv); fv.IsValid() && fv.Kind() == reflect.Struct {
+				if err := e.encodeFields(fv, parentNs, ns); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if fi.prsnt < 0 && fv.IsZero() {
+			continue
+		}
+		if e.filter {
+			ok, err := e.encodeFilterLeaf(fv, ns, fi)
+			if err != nil {
+				return err
+			}
+			if ok {
+				continue
+			}
+		}
+		if def, ok := defs[fi.goname]; ok && isDefault(fv, nsOr(fi.ns, ns), def) {
+			if e.mode == Trim {
+				continue
+			}
+			if err := e.encodeTagged(fv, parentNs, nsOr(fi.ns, ns), fi.name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.encodeElement(fv, parentNs, nsOr(fi.ns, ns), fi.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode a leaf or the entries of a leaf-list tagged as default
+func (e *Encoder) encodeTagged(v reflect.Value, parentNs, ns, name string) error {
+	if isLeafValue(v) {
+		return e.encodeLeaf(v, parentNs, ns, name, defaultAttr)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encodeLeaf(v.Index(i), parentNs, ns, name, defaultAttr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nsOr(ns, def string) string {
+	if ns != "" {
+		return ns
+	}
+	return def
+}
+
+func (e *Encoder) startElement(name, ns, parentNs, attrs string) {
+	e.w.WriteString("<" + name)
+	if ns != parentNs {
+		e.w.WriteString(" xmlns=\"")
+		xml.EscapeText(e.w, []byte(ns))
+		e.w.WriteString("\"")
+	}
+	e.w.WriteString(attrs)
+	e.w.WriteString(">")
+}
+
+func (e *Encoder) endElement(name string) {
+	e.w.WriteString("</" + name + ">")
+}
+
+// The leaves are either of the generated types that implement the
+// MarshalText() or of the basic types of go.
+func isLeafValue(v reflect.Value) bool {
+	if _, ok := asTextMarshaler(v); ok {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array:
+		return false
+	case reflect.Slice:
+		return v.Type().Elem().Kind() == reflect.Uint8
+	}
+	return true
+}
+
+func asTextMarshaler(v reflect.Value) (TextMarshaler, bool) {
+	if m, ok := v.Interface().(TextMarshaler); ok {
+		return m, true
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(TextMarshaler); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func marshalText(v reflect.Value, ns string) ([]byte, error) {
+	if m, ok := asTextMarshaler(v); ok {
+		return m.MarshalText(ns)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Bool:
+		return []byte(strconv.FormatBool(v.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []byte(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []byte(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return []byte(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())), nil
+	case reflect.Slice:
+		return []byte(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+	}
+	return nil, fmt.Errorf("can't encode %s", v.Type())
+}
+
+// The identities report their namespace as "prefix!namespace" and the
+// prefix used in the value is declared on the element carrying it.
+func prefixDecl(v reflect.Value) string {
+	prefix, ns, ok := strings.Cut(runtimeNs(v), "!")
+	if !ok || prefix == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(" xmlns:" + prefix + "=\"")
+	xml.EscapeText(&b, []byte(ns))
+	b.WriteString("\"")
+	return b.String()
+}
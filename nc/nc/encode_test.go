@@ -0,0 +1,111 @@
+package nc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+// The name that the decoder fills in
+var dataName = nc.XmlId{Space: nc.NetconfNs, Local: "data"}
+
+// A device whose nodes are of most of the types
+func testDevice() *yang.Device {
+	sys := yang.T_system_cont{
+		Hostname_Prsnt: true, Hostname: "r1 & <r2>",
+		Mtu_Prsnt: true, Mtu: 9000,
+		Enabled_Prsnt: true,
+		Counter_Prsnt: true, Counter: 18446744073709551615,
+		Offset_Prsnt: true, Offset: -9223372036854775808,
+		Level_Prsnt: true, Level: -3,
+		Gain_Prsnt: true, Gain: -1250,
+		Color_Prsnt: true, Color: yang.T_color_Blue,
+		Flags_Prsnt: true, Flags: yang.T_system_flags_Up | yang.T_system_flags_Wide,
+		Proto_Prsnt: true, Proto: "udp",
+		Data_Prsnt: true, Data: yang.T_system_data{0, 1, 0xfe, 0xff},
+		Active_Prsnt: true, Active: false,
+		Id_Prsnt: true, Id: yang.T_system_id{String_1_Prsnt: true, String_1: "x1"},
+		Dns:          []string{"8.8.8.8", "1.1.1.1"},
+		Timers_Prsnt: true, Timers: yang.T_system_timers_cont{Dead_Prsnt: true, Dead: 40},
+		Debug_Prsnt: true,
+		Speed_Prsnt: true, Speed: yang.T_system_speed{Fixed_Prsnt: true, Fixed: 100},
+	}
+	dev := &yang.Device{XMLName: dataName, T_system_Prsnt: true, T_system: sys, T_top_Prsnt: true}
+	dev.T_top.SetIntf(yang.T_top_intf{Name: "eth0", Mtu_Prsnt: true, Mtu: 1500})
+	dev.T_top.SetIntf(yang.T_top_intf{Name: "eth1"})
+	dev.T_top.Ref, dev.T_top.Ref_Prsnt = "eth1", true
+	dev.SetT_item(yang.T_item{Name: "b", Value_Prsnt: true, Value: "2"})
+	dev.SetT_item(yang.T_item{Name: "a"})
+	return dev
+}
+
+const testXML = `<data xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">` +
+	`<system xmlns="urn:test"><timers><dead>40</dead></timers><debug></debug>` +
+	`<hostname>r1 &amp; &lt;r2&gt;</hostname><mtu>9000</mtu><enabled></enabled>` +
+	`<counter>18446744073709551615</counter><offset>-9223372036854775808</offset>` +
+	`<level>-3</level><gain>-12.50</gain><color>blue</color><flags>up wide</flags>` +
+	`<proto xmlns:t="urn:test">t:udp</proto><data>AAH+/w==</data><active>false</active>` +
+	`<id>x1</id><dns>8.8.8.8</dns><dns>1.1.1.1</dns><fixed>100</fixed></system>` +
+	`<top xmlns="urn:test"><ref>eth1</ref><intf><name>eth0</name><mtu>1500</mtu></intf>` +
+	`<intf><name>eth1</name></intf></top>` +
+	`<item xmlns="urn:test"><name>b</name><value>2</value></item>` +
+	`<item xmlns="urn:test"><name>a</name></item></data>`
+
+func TestMarshal(t *testing.T) {
+	b, err := nc.Marshal(testDevice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != testXML {
+		t.Errorf("Marshal() = %s, want %s", b, testXML)
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	want := testDevice()
+	b, err := nc.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got yang.Device
+	if err := nc.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, *want)
+	}
+}
+
+func TestMarshalElement(t *testing.T) {
+	sys := yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r1", Enabled_Prsnt: true}
+	b, err := nc.MarshalElement(sys, "urn:test", "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<system xmlns="urn:test"><hostname>r1</hostname><enabled></enabled></system>`
+	if string(b) != want {
+		t.Errorf("MarshalElement() = %s, want %s", b, want)
+	}
+	var got yang.T_system_cont
+	if err := nc.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sys) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, sys)
+	}
+}
+
+// The encoding of a value that the schema rejects fails
+func TestMarshalInvalid(t *testing.T) {
+	for _, sys := range []yang.T_system_cont{
+		{Mtu_Prsnt: true, Mtu: 10},
+		{Flags_Prsnt: true, Flags: 1 << 1},
+		{Id_Prsnt: true},
+	} {
+		if b, err := nc.MarshalElement(sys, "urn:test", "system"); err == nil {
+			t.Errorf("MarshalElement(%+v) = %s, want an error", sys, b)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package nc
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// The description of a field of a generated structure derived from its
+// xml tag. The tag has the form "[namespace ]name[,option...]".
+type fieldInfo struct {
+	idx    int
+	goname string
+	name   string
+	ns     string
+	prsnt  int  // index of the presence field, -1 if there is none
+	inline bool // choice, case or uses whose fields belong to the parent
+	empty  bool // leaf of type empty that is only a presence field
+	opts   []string
+	typ    reflect.Type // structure type of an inline field
+}
+
+type typeInfo struct {
+	fields  []*fieldInfo
+	xmlname *fieldInfo
+	op      *fieldInfo // XMLOp, the operation of edit-config
+	insert  *fieldInfo // XMLInsert, the insertion in a list ordered by the user
+}
+
+var typeInfoMap sync.Map
+
+// Analyze the fields of a structure. The result is cached as the same
+// types are encoded and decoded repeatedly.
+func getTypeInfo(t reflect.Type) *typeInfo {
+	if ti, ok := typeInfoMap.Load(t); ok {
+		return ti.(*typeInfo)
+	}
+	ti := &typeInfo{}
+	prsnt := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("xml")
+		if tag == "-" {
+			continue
+		}
+		fi := &fieldInfo{idx: i, goname: f.Name, prsnt: -1}
+		parts := strings.Split(tag, ",")
+		fi.opts = parts[1:]
+		if ns, name, ok := strings.Cut(parts[0], " "); ok {
+			fi.ns = ns
+			fi.name = name
+		} else {
+			fi.name = parts[0]
+		}
+		if f.Name == "XMLName" {
+			ti.xmlname = fi
+			continue
+		}
+		if fi.hasOpt("operation") {
+			ti.op = fi
+			continue
+		}
+		if fi.hasOpt("insert") {
+			ti.insert = fi
+			continue
+		}
+		if fi.hasOpt("presfield") {
+			if fi.name == "" {
+				prsnt[strings.TrimSuffix(f.Name, "_Prsnt")] = i
+				continue
+			}
+			fi.empty = true
+		}
+		fi.inline = f.Anonymous || fi.hasOpt("inline")
+		if fi.inline {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fi.typ = ft
+			}
+		}
+		if fi.name == "" && !fi.inline {
+			fi.name = f.Name
+		}
+		ti.fields = append(ti.fields, fi)
+	}
+	for _, fi := range ti.fields {
+		if p, ok := prsnt[fi.goname]; ok {
+			fi.prsnt = p
+		}
+	}
+	actual, _ := typeInfoMap.LoadOrStore(t, ti)
+	return actual.(*typeInfo)
+}
+
+func (fi *fieldInfo) hasOpt(opt string) bool {
+	for _, o := range fi.opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// Locate the field for an element. The fields with the namespace that
+// matches are preferred over those without a namespace which match any
+// namespace. The path returned passes through the inline fields that
+// contain the field.
+func (ti *typeInfo) lookup(ns, name string) []*fieldInfo {
+	if path := ti.lookupNs(ns, name, true); path != nil {
+		return path
+	}
+	return ti.lookupNs(ns, name, false)
+}
+
+func (ti *typeInfo) lookupNs(ns, name string, exact bool) []*fieldInfo {
+	for _, fi := range ti.fields {
+		if fi.inline || fi.name != name {
+			continue
+		}
+		if (exact && fi.ns == ns) || (!exact && fi.ns == "") {
+			return []*fieldInfo{fi}
+		}
+	}
+	for _, fi := range ti.fields {
+		if !fi.inline || fi.typ == nil {
+			continue
+		}
+		if path := getTypeInfo(fi.typ).lookupNs(ns, name, exact); path != nil {
+			return append([]*fieldInfo{fi}, path...)
+		}
+	}
+	return nil
+}
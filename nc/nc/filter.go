@@ -0,0 +1,87 @@
+package nc
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// Filter returns the subtree filter of RFC 6241 made of the nodes present
+// in v. The structures are the containment nodes and those with no node
+// present within are the selection nodes. The keys of the list entries
+// and the leaves with a value are the content match nodes. The leaves
+// that are present with the zero value of their type are the selection
+// nodes as are the entries of leaf-lists with the zero value. The nodes
+// of the <data> of the device make the filter without <data> itself.
+func Filter(v interface{}) ([]byte, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("nc: can't make a filter from %T", v)
+	}
+	ns, name := elementName(rv)
+	if name == "" {
+		return nil, fmt.Errorf("nc: can't make a filter from %T without XMLName", v)
+	}
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	e.filter = true
+	var err error
+	if isData(ns, name) {
+		err = e.encodeFields(rv, "", "")
+	} else {
+		err = e.encodeElement(rv, "", ns, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.w.Flush(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// The keys of the entry of a list whose fields are being encoded
+func entryKeys(v reflect.Value) map[string]bool {
+	k, ok := v.Interface().(Keyed)
+	if !ok {
+		return nil
+	}
+	keys := map[string]bool{}
+	for _, name := range k.ListKeyNames() {
+		keys[name] = true
+	}
+	return keys
+}
+
+// Encode a leaf or a leaf-list of a filter. The leaves with the zero value
+// other than the keys are encoded as selection nodes. It returns false
+// for the other nodes.
+func (e *Encoder) encodeFilterLeaf(v reflect.Value, parentNs string, fi *fieldInfo) (bool, error) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return false, nil
+	}
+	ns := nsOr(fi.ns, parentNs)
+	if isLeafValue(v) {
+		if !v.IsZero() || e.keys[fi.name] {
+			return false, nil
+		}
+		e.startElement(fi.name, ns, parentNs, "")
+		e.endElement(fi.name)
+		return true, nil
+	}
+	if v.Kind() != reflect.Slice || v.Len() == 0 || !isLeafValue(v.Index(0)) {
+		return false, nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		if !v.Index(i).IsZero() {
+			if err := e.encodeElement(v.Index(i), parentNs, fi.ns, fi.name); err != nil {
+				return true, err
+			}
+			continue
+		}
+		e.startElement(fi.name, ns, parentNs, "")
+		e.endElement(fi.name)
+	}
+	return true, nil
+}
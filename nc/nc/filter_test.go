@@ -0,0 +1,80 @@
+package nc_test
+
+import (
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"selection", &yang.Device{T_system_Prsnt: true},
+			`<system xmlns="urn:test"></system>`},
+		{"leaf selection", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true}},
+			`<system xmlns="urn:test"><hostname></hostname></system>`},
+		{"content match", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r1", Mtu_Prsnt: true}},
+			`<system xmlns="urn:test"><hostname>r1</hostname><mtu></mtu></system>`},
+		{"containment", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Timers_Prsnt: true, Timers: yang.T_system_timers_cont{Dead_Prsnt: true}}},
+			`<system xmlns="urn:test"><timers><dead></dead></timers></system>`},
+		{"leaf-list", &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{Dns: []string{"", "1.1.1.1"}}},
+			`<system xmlns="urn:test"><dns></dns><dns>1.1.1.1</dns></system>`},
+		{"entry", &yang.Device{T_top_Prsnt: true, T_top: yang.T_top_cont{Intf: []yang.T_top_intf{{Name_Prsnt: true, Name: "eth0", Mtu_Prsnt: true}}}},
+			`<top xmlns="urn:test"><intf><name>eth0</name><mtu></mtu></intf></top>`},
+		{"key", &yang.Device{T_item: []yang.T_item{{Name_Prsnt: true}}},
+			`<item xmlns="urn:test"><name></name></item>`},
+		{"several", &yang.Device{T_system_Prsnt: true, T_top_Prsnt: true},
+			`<system xmlns="urn:test"></system><top xmlns="urn:test"></top>`},
+		{"element", yang.T_alarm_cont{Text_Prsnt: true},
+			`<alarm xmlns="urn:test"><text></text></alarm>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := nc.Filter(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Filter() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterInvalid(t *testing.T) {
+	for _, v := range []interface{}{"system", yang.T_system_cont{}} {
+		if b, err := nc.Filter(v); err == nil {
+			t.Errorf("Filter(%T) = %s, want an error", v, b)
+		}
+	}
+}
+
+// The filter of a path selects the node at its end
+func TestPathFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		p    nc.Path
+		want string
+	}{
+		{"container", yang.Root().T_system().Timers().Path, `<system xmlns="urn:test"><timers></timers></system>`},
+		{"leaf", yang.Root().T_system().Hostname(), `<system xmlns="urn:test"><hostname></hostname></system>`},
+		{"entry", yang.Root().T_top().Intf("eth0").Mtu(), `<top xmlns="urn:test"><intf><name>eth0</name><mtu></mtu></intf></top>`},
+		{"list", yang.Root().T_item_any().Path, `<item xmlns="urn:test"></item>`},
+		{"escaped", yang.Root().T_item("a&b").Path, `<item xmlns="urn:test"><name>a&amp;b</name></item>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.p.Filter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Filter() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
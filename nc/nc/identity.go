@@ -0,0 +1,29 @@
+package nc
+
+import (
+	"encoding/xml"
+	"sync"
+)
+
+// The generated code registers the direct bases of each identity so that
+// derived-from() of XPath can follow the derivation across modules. The
+// identities are named by their namespace and name.
+var identities = struct {
+	sync.RWMutex
+	bases map[xml.Name][]xml.Name
+}{bases: map[xml.Name][]xml.Name{}}
+
+// RegisterIdentity registers one of the direct bases of an identity
+func RegisterIdentity(ns, name, baseNs, baseName string) {
+	identities.Lock()
+	defer identities.Unlock()
+	id := xml.Name{Space: ns, Local: name}
+	identities.bases[id] = append(identities.bases[id], xml.Name{Space: baseNs, Local: baseName})
+}
+
+// IdentityBases returns the direct bases of an identity
+func IdentityBases(id xml.Name) []xml.Name {
+	identities.RLock()
+	defer identities.RUnlock()
+	return identities.bases[id]
+}
@@ -0,0 +1,419 @@
+package nc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The JSON encoding of RFC 7951. The names of the members are qualified
+// by the name of the module when the namespace of the node differs from
+// the one of its parent, which is always the case at the top. The values
+// follow the types of yang:
+//   - int64, uint64 and decimal64 are strings and so are the enumerations
+//     and the bits which are encoded by their names
+//   - the other integers are numbers and booleans are true or false
+//   - empty is [null]
+//   - identities are qualified by the name of the module as module:name
+//   - a union is encoded as the member that is present
+//
+// The generated types of enumerations are int, of decimal64 int64 and of
+// bits uint64. The kind of the type therefore decides the encoding when
+// the value is produced by MarshalText().
+
+// MarshalJSON returns the JSON encoding of v as a top level object. If v
+// is the <data> of the device, the members are its children. Otherwise,
+// the object has a single member named after the element of v.
+func MarshalJSON(v interface{}) ([]byte, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("nc: can't encode %T as JSON", v)
+	}
+	var b bytes.Buffer
+	ns, name := elementName(rv)
+	if isData(ns, name) {
+		if err := jsonObject(&b, rv, ""); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("nc: can't encode %T without XMLName", v)
+	}
+	b.WriteString("{")
+	first := true
+	if err := jsonMember(&b, &first, rv, "", ns, name); err != nil {
+		return nil, err
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+// MarshalJSONValue returns the JSON encoding of the content of v, which is
+// an object for a structure, in the namespace passed. The members within
+// are qualified when their namespace differs from ns.
+func MarshalJSONValue(v interface{}, ns string) ([]byte, error) {
+	var b bytes.Buffer
+	if err := jsonValue(&b, reflect.ValueOf(v), ns); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func isData(ns, name string) bool {
+	return ns == NetconfNs && name == "data"
+}
+
+// The name of a member. It is qualified by the name of the module when
+// the namespace changes.
+func jsonName(ns, parentNs, name string) (string, error) {
+	if ns == parentNs {
+		return name, nil
+	}
+	m, ok := ModuleByNs(ns)
+	if !ok {
+		return "", fmt.Errorf("nc: no module registered for namespace %s", ns)
+	}
+	return m.Name + ":" + name, nil
+}
+
+func jsonString(b *bytes.Buffer, s string) {
+	out, _ := json.Marshal(s)
+	b.Write(out)
+}
+
+// Write a member of an object. The members of lists and leaf-lists are
+// arrays.
+func jsonMember(b *bytes.Buffer, first *bool, v reflect.Value, parentNs, ns, name string) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if ns == "" {
+		ns = parentNs
+	}
+	mname, err := jsonName(ns, parentNs, name)
+	if err != nil {
+		return err
+	}
+	if !*first {
+		b.WriteString(",")
+	}
+	*first = false
+	jsonString(b, mname)
+	b.WriteString(":")
+	return jsonValue(b, v, ns)
+}
+
+func jsonValue(b *bytes.Buffer, v reflect.Value, ns string) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		b.WriteString("null")
+		return nil
+	}
+	if isLeafValue(v) {
+		return jsonLeaf(b, v, ns)
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		b.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			if err := jsonValue(b, v.Index(i), ns); err != nil {
+				return err
+			}
+		}
+		b.WriteString("]")
+		return nil
+	case reflect.Struct:
+		return jsonObject(b, v, ns)
+	}
+	return fmt.Errorf("nc: can't encode %s as JSON", v.Type())
+}
+
+func jsonObject(b *bytes.Buffer, v reflect.Value, ns string) error {
+	b.WriteString("{")
+	first := true
+	if err := jsonFields(b, &first, v, ns); err != nil {
+		return err
+	}
+	b.WriteString("}")
+	return nil
+}
+
+// The fields of choices, cases and groupings are members of the object
+// of the structure that contains them.
+func jsonFields(b *bytes.Buffer, first *bool, v reflect.Value, ns string) error {
+	ti := getTypeInfo(v.Type())
+	for _, fi := range ti.fields {
+		fv := v.Field(fi.idx)
+		if fi.prsnt >= 0 && !v.Field(fi.prsnt).Bool() {
+			continue
+		}
+		if fi.empty {
+			if !fv.Bool() {
+				continue
+			}
+			mname, err := jsonName(nsOr(fi.ns, ns), ns, fi.name)
+			if err != nil {
+				return err
+			}
+			if !*first {
+				b.WriteString(",")
+			}
+			*first = false
+			jsonString(b, mname)
+			b.WriteString(":[null]")
+			continue
+		}
+		if fi.inline {
+			if fv = indirect(fv); fv.IsValid() && fv.Kind() == reflect.Struct {
+				if err := jsonFields(b, first, fv, ns); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if fi.prsnt < 0 && fv.IsZero() {
+			continue
+		}
+		if err := jsonMember(b, first, fv, ns, fi.ns, fi.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode a leaf. The generated unions are structures with a presence field
+// for each member and the member present is encoded.
+func jsonLeaf(b *bytes.Buffer, v reflect.Value, ns string) error {
+	if v.Kind() == reflect.Struct {
+		if m := unionMember(v); m.IsValid() {
+			return jsonLeaf(b, m, ns)
+		}
+	}
+	text, err := marshalText(v, ns)
+	if err != nil {
+		return err
+	}
+	if id, ok := identityName(v, text); ok {
+		jsonString(b, id)
+		return nil
+	}
+	if jsonNative(v.Kind()) {
+		b.Write(text)
+	} else {
+		jsonString(b, string(text))
+	}
+	return nil
+}
+
+// The name of an identity qualified by the name of its module from the
+// text of the value which carries the prefix.
+func identityName(v reflect.Value, text []byte) (string, bool) {
+	prefix, ns, ok := strings.Cut(runtimeNs(v), "!")
+	if !ok {
+		return "", false
+	}
+	m, ok := ModuleByNs(ns)
+	if !ok {
+		return "", false
+	}
+	return m.Name + ":" + strings.TrimPrefix(string(text), prefix+":"), true
+}
+
+func unionMember(v reflect.Value) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Type.Kind() != reflect.Bool || !strings.HasSuffix(f.Name, "_Prsnt") {
+			continue
+		}
+		if v.Field(i).Bool() {
+			return v.FieldByName(strings.TrimSuffix(f.Name, "_Prsnt"))
+		}
+	}
+	return reflect.Value{}
+}
+
+// UnmarshalJSON decodes the top level object in data into v which must be
+// a pointer to a structure. If v is the <data> of the device, the members
+// are decoded as its children. Otherwise, the member named after the
+// element of v is decoded.
+func UnmarshalJSON(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("nc: can't decode JSON into %T", v)
+	}
+	var obj map[string]interface{}
+	if err := decodeJSON(data, &obj); err != nil {
+		return err
+	}
+	rv = rv.Elem()
+	ns, name := elementName(rv)
+	if isData(ns, name) {
+		return jsonDecodeObject(rv, obj, "")
+	}
+	for k, val := range obj {
+		mns, mname, err := jsonSplitName(k, "")
+		if err != nil {
+			return err
+		}
+		if mname == name && (ns == "" || mns == ns) {
+			return jsonDecodeValue(rv, val, mns)
+		}
+	}
+	return fmt.Errorf("nc: member %s not found", name)
+}
+
+// UnmarshalJSONValue decodes the content of a node in the namespace passed
+// into v which must be a pointer.
+func UnmarshalJSONValue(data []byte, v interface{}, ns string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("nc: can't decode JSON into %T", v)
+	}
+	var val interface{}
+	if err := decodeJSON(data, &val); err != nil {
+		return err
+	}
+	return jsonDecodeValue(rv.Elem(), val, ns)
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("nc: %s", err.Error())
+	}
+	return nil
+}
+
+// Split the name of a member into the namespace and the name. The name
+// without the module inherits the namespace of the parent and the members
+// at the top, which have none, must be qualified.
+func jsonSplitName(member, parentNs string) (string, string, error) {
+	module, name, ok := strings.Cut(member, ":")
+	if !ok {
+		if parentNs == "" {
+			return "", "", fmt.Errorf("nc: member %s isn't qualified by the name of its module", member)
+		}
+		return parentNs, member, nil
+	}
+	m, ok := ModuleByName(module)
+	if !ok {
+		return "", "", fmt.Errorf("nc: unknown module %s", module)
+	}
+	return m.Namespace, name, nil
+}
+
+func jsonDecodeObject(v reflect.Value, obj map[string]interface{}, ns string) error {
+	ti := getTypeInfo(v.Type())
+	for k, val := range obj {
+		mns, name, err := jsonSplitName(k, ns)
+		if err != nil {
+			return err
+		}
+		path := ti.lookup(mns, name)
+		if path == nil {
+			return fmt.Errorf("nc: unknown member %s", k)
+		}
+		fv, fi := walkPath(v, path)
+		if fi.empty {
+			if arr, ok := val.([]interface{}); !ok || len(arr) != 1 || arr[0] != nil {
+				return fmt.Errorf("nc: %s: the value of an empty leaf must be [null]", k)
+			}
+			fv.SetBool(true)
+			continue
+		}
+		if err := jsonDecodeValue(fv, val, mns); err != nil {
+			return fmt.Errorf("%s: %s", k, err.Error())
+		}
+	}
+	return nil
+}
+
+func jsonDecodeValue(v reflect.Value, val interface{}, ns string) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := asTextUnmarshaler(v); ok {
+		text, err := jsonText(v, val)
+		if err != nil {
+			return err
+		}
+		return u.UnmarshalText(ns, []byte(text))
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		arr, ok := val.([]interface{})
+		if !ok {
+			return fmt.Errorf("nc: expected an array for %s", v.Type())
+		}
+		for _, x := range arr {
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := jsonDecodeValue(e, x, ns); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, e))
+		}
+		return nil
+	case reflect.Struct:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("nc: expected an object for %s", v.Type())
+		}
+		return jsonDecodeObject(v, obj, ns)
+	}
+	text, err := jsonText(v, val)
+	if err != nil {
+		return err
+	}
+	return unmarshalBasic(v, []byte(text))
+}
+
+// The text of a leaf from its JSON value. The value must be of the JSON
+// type of the encoding of the leaf, which jsonLeaf() decides from the kind
+// of its type, except for the unions whose member isn't known yet. The
+// identities keep the name of the module as prefix which the generated
+// types strip.
+func jsonText(v reflect.Value, val interface{}) (string, error) {
+	union := v.Kind() == reflect.Struct
+	switch x := val.(type) {
+	case string:
+		if union || !jsonNative(v.Kind()) {
+			return x, nil
+		}
+	case json.Number:
+		if union || jsonNative(v.Kind()) && v.Kind() != reflect.Bool {
+			return x.String(), nil
+		}
+	case bool:
+		if union || v.Kind() == reflect.Bool {
+			return strconv.FormatBool(x), nil
+		}
+	}
+	return "", fmt.Errorf("nc: invalid value %v for %s", val, v.Type())
+}
+
+// The kinds whose values are encoded as JSON numbers and booleans rather
+// than strings
+func jsonNative(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package nc_test
+
+import (
+	"reflect"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+)
+
+const testJSON = `{"test:system":{"timers":{"dead":40},"debug":{},` +
+	`"hostname":"r1 \u0026 \u003cr2\u003e","mtu":9000,"enabled":[null],` +
+	`"counter":"18446744073709551615","offset":"-9223372036854775808","level":-3,` +
+	`"gain":"-12.50","color":"blue","flags":"up wide","proto":"test:udp",` +
+	`"data":"AAH+/w==","active":false,"id":"x1","dns":["8.8.8.8","1.1.1.1"],"fixed":100},` +
+	`"test:top":{"ref":"eth1","intf":[{"name":"eth0","mtu":1500},{"name":"eth1"}]},` +
+	`"test:item":[{"name":"b","value":"2"},{"name":"a"}]}`
+
+func TestMarshalJSON(t *testing.T) {
+	b, err := nc.MarshalJSON(testDevice())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != testJSON {
+		t.Errorf("MarshalJSON() = %s, want %s", b, testJSON)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	want := testDevice()
+	b, err := nc.MarshalJSON(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := yang.Device{XMLName: dataName}
+	if err := nc.UnmarshalJSON(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("UnmarshalJSON() = %+v, want %+v", got, *want)
+	}
+}
+
+// A structure other than the <data> of the device is the member named
+// after it
+func TestUnmarshalJSONMember(t *testing.T) {
+	var alarm yang.T_alarm_cont
+	in := `{"test:alarm":{"severity":"blue","test:text":"hot"}}`
+	if err := nc.UnmarshalJSON([]byte(in), &alarm); err != nil {
+		t.Fatal(err)
+	}
+	want := yang.T_alarm_cont{Severity_Prsnt: true, Severity: yang.T_color_Blue, Text_Prsnt: true, Text: "hot"}
+	if !reflect.DeepEqual(alarm, want) {
+		t.Errorf("UnmarshalJSON() = %+v, want %+v", alarm, want)
+	}
+	if err := nc.UnmarshalJSON([]byte(`{"alarm":{}}`), &alarm); err == nil {
+		t.Errorf("UnmarshalJSON() of an unqualified member succeeded")
+	}
+}
+
+// The values must be of the JSON types of RFC 7951 and the members must be
+// those of the schema
+func TestUnmarshalJSONInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty null", `{"test:system":{"enabled":null}}`},
+		{"empty true", `{"test:system":{"enabled":true}}`},
+		{"uint64 number", `{"test:system":{"counter":18446744073709551615}}`},
+		{"int64 number", `{"test:system":{"offset":-1}}`},
+		{"decimal64 number", `{"test:system":{"gain":1.5}}`},
+		{"uint16 string", `{"test:system":{"mtu":"1500"}}`},
+		{"int8 string", `{"test:system":{"level":"-3"}}`},
+		{"boolean string", `{"test:system":{"active":"true"}}`},
+		{"string number", `{"test:system":{"hostname":1}}`},
+		{"enum number", `{"test:system":{"color":5}}`},
+		{"unknown member", `{"test:system":{"bogus":1}}`},
+		{"unknown module", `{"bogus:system":{}}`},
+		{"unqualified", `{"system":{}}`},
+		{"range", `{"test:system":{"mtu":10}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dev yang.Device
+			if err := nc.UnmarshalJSON([]byte(tt.in), &dev); err == nil {
+				t.Errorf("UnmarshalJSON(%s) = %+v, want an error", tt.in, dev)
+			}
+		})
+	}
+}
+
+// The members at the top of a value are qualified unless the namespace
+// of the node is given. The member of a union is the first whose type the
+// value is of.
+func TestUnmarshalJSONValue(t *testing.T) {
+	var sys yang.T_system_cont
+	in := `{"hostname":"r1","id":7,"test:enabled":[null]}`
+	if err := nc.UnmarshalJSONValue([]byte(in), &sys, "urn:test"); err != nil {
+		t.Fatal(err)
+	}
+	want := yang.T_system_cont{Hostname_Prsnt: true, Hostname: "r1", Enabled_Prsnt: true,
+		Id_Prsnt: true, Id: yang.T_system_id{Uint8_0_Prsnt: true, Uint8_0: 7}}
+	if !reflect.DeepEqual(sys, want) {
+		t.Errorf("UnmarshalJSONValue() = %+v, want %+v", sys, want)
+	}
+	if err := nc.UnmarshalJSONValue([]byte(in), &sys, ""); err == nil {
+		t.Errorf("UnmarshalJSONValue() of unqualified members succeeded")
+	}
+}
@@ -0,0 +1,64 @@
+package nc
+
+import "sync"
+
+// ModuleInfo identifies a yang module by its name, namespace and prefix
+type ModuleInfo struct {
+	Name      string
+	Namespace string
+	Prefix    string
+}
+
+// The generated code registers each module so that the encodings which
+// qualify the names by the name of the module can map them to namespaces
+var modules = struct {
+	sync.RWMutex
+	byNs   map[string]ModuleInfo
+	byName map[string]ModuleInfo
+	caps   []string
+}{byNs: map[string]ModuleInfo{}, byName: map[string]ModuleInfo{}}
+
+// RegisterModule registers a module with its namespace and prefix
+func RegisterModule(name, ns, prefix string) {
+	modules.Lock()
+	defer modules.Unlock()
+	m := ModuleInfo{Name: name, Namespace: ns, Prefix: prefix}
+	modules.byNs[ns] = m
+	modules.byName[name] = m
+}
+
+// ModuleByNs returns the module with the namespace passed
+func ModuleByNs(ns string) (ModuleInfo, bool) {
+	modules.RLock()
+	defer modules.RUnlock()
+	m, ok := modules.byNs[ns]
+	return m, ok
+}
+
+// ModuleByName returns the module with the name passed
+func ModuleByName(name string) (ModuleInfo, bool) {
+	modules.RLock()
+	defer modules.RUnlock()
+	m, ok := modules.byName[name]
+	return m, ok
+}
+
+// RegisterCapability registers the capability of a module which is
+// "namespace?module=name&revision=date" as in the hello of netconf
+func RegisterCapability(c string) {
+	modules.Lock()
+	defer modules.Unlock()
+	for _, have := range modules.caps {
+		if have == c {
+			return
+		}
+	}
+	modules.caps = append(modules.caps, c)
+}
+
+// Capabilities returns the capabilities of the modules registered
+func Capabilities() []string {
+	modules.RLock()
+	defer modules.RUnlock()
+	return append([]string(nil), modules.caps...)
+}
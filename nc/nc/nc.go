@@ -0,0 +1,89 @@
+// Package nc is the runtime for the code generated by ncgen from yang
+// modules. It encodes and decodes the generated structures following the
+// conventions of the generator.
+//
+// The structures carry a field for each data node. The xml tag of the
+// field gives the name of the element and optionally its namespace as
+// "namespace name". A node that is not a list or a leaf-list is preceded
+// by a presence field named after it with the suffix _Prsnt and tagged as
+// ",presfield". The node is encoded only when the presence field is set
+// and the decoder sets it when the node is found. A leaf of type empty
+// has only the presence field which then carries the name of the leaf.
+// Choices, cases and the groupings included through uses do not appear
+// in the encoding and their fields are encoded as part of the parent.
+//
+// The values of leaves implement MarshalText(ns) and UnmarshalText(ns, b)
+// where ns is the namespace of the element. A type may also implement
+// RuntimeNs() to report its namespace. Identities report "prefix!ns"
+// so that the prefix used in the value can be declared on the element.
+//
+// The containers and the entries of lists also carry the field XMLOp
+// tagged ",operation" for the operation of edit-config on the node and
+// the entries of lists ordered by the user the field XMLInsert tagged
+// ",insert" for their position. They are encoded as attributes. Edit
+// applies them to a structure as a server does and EditNode applies an
+// operation to the node of a path as the resources of RESTCONF are.
+package nc
+
+// The version of the runtime. It changes whenever the conventions shared
+// with the generator change.
+const Version = "0.2.0"
+
+// The namespace of the netconf base protocol
+const NetconfNs = "urn:ietf:params:xml:ns:netconf:base:1.0"
+
+// The namespace of yang used for the action operation
+const YangNs = "urn:ietf:params:xml:ns:yang:1"
+
+// The namespace of the notifications of RFC 5277
+const NotificationNs = "urn:ietf:params:xml:ns:netconf:notification:1.0"
+
+// The namespace of the dynamic subscriptions of RFC 8639
+const SubscribedNotificationsNs = "urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"
+
+// XmlId holds the name of the element a structure is encoded as. The
+// generated structures include it as field XMLName with the name and
+// the namespace in the tag. The decoder fills in the name found.
+type XmlId struct {
+	Space string
+	Local string
+}
+
+// TextMarshaler is implemented by the generated types of leaves. The
+// namespace passed is the one of the element that carries the value.
+type TextMarshaler interface {
+	MarshalText(ns string) ([]byte, error)
+}
+
+// TextUnmarshaler is implemented by the pointers to the generated types
+// of leaves.
+type TextUnmarshaler interface {
+	UnmarshalText(ns string, b []byte) error
+}
+
+// TextEqual reports whether two values of a generated type of leaves have
+// the same text. The generated keys compare with it the values of types
+// that aren't comparable.
+func TextEqual(a, b TextMarshaler) bool {
+	ta, erra := a.MarshalText("")
+	tb, errb := b.MarshalText("")
+	return (erra == nil) == (errb == nil) && string(ta) == string(tb)
+}
+
+// RuntimeNser is implemented by the generated types to provide their
+// namespace at runtime. The identities return "prefix!namespace".
+type RuntimeNser interface {
+	RuntimeNs() string
+}
+
+// Decimal64 is implemented by the generated decimal64 types. The value is
+// the decimal scaled by 10 to the power of the fraction digits.
+type Decimal64 interface {
+	FractionDigits() int
+}
+
+// Bits is implemented by the generated bits types. The bit at position n
+// is 1 << n in the value returned.
+type Bits interface {
+	Bits() uint64
+}
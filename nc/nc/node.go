@@ -0,0 +1,518 @@
+package nc
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// The nodes of the data tree are addressed by a path from the generated
+// device as the resources of RESTCONF and the paths of gNMI are. The keys
+// of a path may be typed or the text of the values, and those without a
+// name are named in the order of the keys of the list.
+
+// ResolvePath checks that the path exists in the schema of the structure
+// v points to, the generated device. The namespaces missing are those of
+// the parents and the keys are named. A list at the end of the path may
+// have no keys which stands for an entry whose keys are in its value.
+func ResolvePath(v interface{}, p Path) (Path, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("nc: can't resolve a path in %T", v)
+	}
+	r := make(Path, len(p))
+	ns := ""
+	for i, e := range p {
+		if e.Namespace == "" {
+			e.Namespace = ns
+		}
+		ns = e.Namespace
+		fields := getTypeInfo(t).lookup(e.Namespace, e.Name)
+		if fields == nil {
+			return nil, nodeError("unknown-element", p[:i+1], "no such node")
+		}
+		ft := fieldType(t, fields)
+		switch {
+		case isListType(ft):
+			t = ft.Elem()
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			names := keyNames(t)
+			if len(e.Keys) != len(names) && (len(e.Keys) > 0 || i < len(p)-1) {
+				return nil, nodeError("invalid-value", p[:i+1], "the keys of the entry are missing")
+			}
+			keys := make([]KeyValue, len(e.Keys))
+			for j, k := range e.Keys {
+				if k.Name == "" {
+					k.Name = names[j]
+				} else if !slices.Contains(names, k.Name) {
+					return nil, nodeError("invalid-value", p[:i+1], "no key "+k.Name)
+				}
+				keys[j] = k
+			}
+			// The keys named may come in any order as those of gNMI
+			slices.SortStableFunc(keys, func(a, b KeyValue) int {
+				return slices.Index(names, a.Name) - slices.Index(names, b.Name)
+			})
+			e.Keys = keys
+		case ft.Kind() == reflect.Struct && !isLeafType(ft):
+			t = ft
+		default:
+			if i != len(p)-1 {
+				return nil, nodeError("unknown-element", p[:i+2], "no such node")
+			}
+		}
+		r[i] = e
+	}
+	return r, nil
+}
+
+// Find returns a pointer to the node of the path within the structure v
+// points to, the generated device. The entries of lists are returned as
+// a pointer to the entry and the leaves as a pointer to their value. It
+// returns false when the node isn't present.
+func Find(v interface{}, p Path) (interface{}, bool) {
+	p, err := ResolvePath(v, p)
+	if err != nil {
+		return nil, false
+	}
+	rv := indirect(reflect.ValueOf(v))
+	if len(p) == 0 {
+		return rv.Addr().Interface(), true
+	}
+	parent, ok := walkTo(rv, p[:len(p)-1], false)
+	if !ok {
+		return nil, false
+	}
+	e := p[len(p)-1]
+	node, ok := child(parent, getTypeInfo(parent.Type()).lookup(e.Namespace, e.Name), e)
+	if !ok {
+		return nil, false
+	}
+	return node.Addr().Interface(), true
+}
+
+// NewNode returns a pointer to a new value of the type of the node of the
+// path within the structure v points to, the generated device.
+func NewNode(v interface{}, p Path) (interface{}, error) {
+	p, err := ResolvePath(v, p)
+	if err != nil {
+		return nil, err
+	}
+	t := indirect(reflect.ValueOf(v)).Type()
+	for _, e := range p {
+		ft := fieldType(t, getTypeInfo(t).lookup(e.Namespace, e.Name))
+		if isListType(ft) {
+			ft = ft.Elem()
+		}
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		t = ft
+	}
+	return reflect.New(t).Interface(), nil
+}
+
+// EditNode applies the operation of edit-config to the node of the path
+// within the structure v points to, the generated device. The value, of
+// the type NewNode returns, is the content of the node which is ignored
+// by delete and remove. The ancestors are created when missing unless
+// the node is deleted. The keys of an entry are those of the path.
+func EditNode(v interface{}, p Path, value interface{}, op Operation) error {
+	p, err := ResolvePath(v, p)
+	if err != nil {
+		return err
+	}
+	if op == OpNone {
+		op = OpMerge
+	}
+	if len(p) == 0 {
+		return Edit(v, value, op)
+	}
+	deleting := op == OpDelete || op == OpRemove
+	rv := indirect(reflect.ValueOf(v))
+	parent, ok := walkTo(rv, p[:len(p)-1], !deleting)
+	if !ok {
+		if op == OpRemove {
+			return nil
+		}
+		return nodeError("data-missing", p, "the node doesn't exist")
+	}
+	// The edit is a structure of the type of the parent which holds the
+	// node only, applied as a configuration of edit-config is.
+	e := p[len(p)-1]
+	src := reflect.New(parent.Type()).Elem()
+	fields := getTypeInfo(src.Type()).lookup(e.Namespace, e.Name)
+	fv, fi := walkPath(src, fields)
+	val := indirect(reflect.ValueOf(value))
+	switch {
+	case isListType(fv.Type()):
+		entry := reflect.New(fv.Type().Elem()).Elem()
+		ev := indirect(allocate(entry))
+		if val.IsValid() && !deleting {
+			ev.Set(val)
+		}
+		if err := setKeys(ev, e); err != nil {
+			return err
+		}
+		setOp(ev, op)
+		fv.Set(reflect.Append(fv, entry))
+	case fi.empty:
+		fv.SetBool(true)
+	default:
+		if val.IsValid() && !deleting {
+			indirect(allocate(fv)).Set(val)
+		}
+		if setOp(indirect(allocate(fv)), op) {
+			break
+		}
+		if (!val.IsValid() || deleting) && fi.prsnt < 0 && isLeafValue(indirect(allocate(fv))) {
+			// The leaves without presence field are present when set
+			if cur, ok := Find(v, p); ok {
+				indirect(allocate(fv)).Set(reflect.ValueOf(cur).Elem())
+			} else if op == OpRemove {
+				return nil
+			} else {
+				return nodeError("data-missing", p, "the node doesn't exist")
+			}
+		}
+	}
+	path, ns := "", ""
+	if len(p) > 1 {
+		path, ns = p[:len(p)-1].String(), p[len(p)-2].Namespace
+	}
+	return editFields(parent, src, op, path, ns, false)
+}
+
+// Set the operation of a container or an entry. It returns false for the
+// leaves.
+func setOp(v reflect.Value, op Operation) bool {
+	if v.Kind() != reflect.Struct || isLeafValue(v) {
+		return false
+	}
+	if ti := getTypeInfo(v.Type()); ti.op != nil {
+		v.Field(ti.op.idx).Set(reflect.ValueOf(op))
+	}
+	return true
+}
+
+// Walk down to the node of the path. With create, the containers and the
+// entries missing are created.
+func walkTo(v reflect.Value, p Path, create bool) (reflect.Value, bool) {
+	for _, e := range p {
+		parent := v
+		fields := getTypeInfo(parent.Type()).lookup(e.Namespace, e.Name)
+		node, ok := child(parent, fields, e)
+		if !ok {
+			if !create {
+				return reflect.Value{}, false
+			}
+			fv, _ := walkPath(parent, fields)
+			if isListType(fv.Type()) {
+				entry := reflect.New(fv.Type().Elem()).Elem()
+				if err := setKeys(indirect(allocate(entry)), e); err != nil {
+					return reflect.Value{}, false
+				}
+				fv.Set(reflect.Append(fv, entry))
+				node = indirect(fv.Index(fv.Len() - 1))
+			} else {
+				node = indirect(allocate(fv))
+			}
+		}
+		v = node
+	}
+	return v, true
+}
+
+// The child of a structure that is present. The entry of a list is the
+// one with the keys of the element.
+func child(parent reflect.Value, fields []*fieldInfo, e PathElem) (reflect.Value, bool) {
+	v := parent
+	for i, fi := range fields {
+		if i < len(fields)-1 {
+			if !isPresent(v, fi) {
+				return reflect.Value{}, false
+			}
+			v = indirect(v.Field(fi.idx))
+			continue
+		}
+		if !isPresent(v, fi) {
+			return reflect.Value{}, false
+		}
+		fv := v.Field(fi.idx)
+		if !isListType(fv.Type()) {
+			return indirect(allocate(fv)), true
+		}
+		if len(e.Keys) == 0 {
+			break
+		}
+		for j := 0; j < fv.Len(); j++ {
+			if entryHasKeys(indirect(fv.Index(j)), e.Keys) {
+				return indirect(fv.Index(j)), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Leaves calls f with the path and the value of each leaf and leaf-list
+// present within the node v of the path p, v itself if it is a leaf. The
+// entries of the lists are in the paths by their keys. A list without
+// keys is passed whole as its entries can't be addressed and an empty
+// leaf is passed as true.
+func Leaves(v interface{}, p Path, f func(Path, interface{}) error) error {
+	ns := ""
+	if len(p) > 0 {
+		ns = p[len(p)-1].Namespace
+	}
+	return leaves(reflect.ValueOf(v), p, ns, f)
+}
+
+func leaves(v reflect.Value, p Path, ns string, f func(Path, interface{}) error) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if isLeafValue(v) || v.Kind() != reflect.Struct {
+		return f(p, v.Interface())
+	}
+	for _, fi := range getTypeInfo(v.Type()).fields {
+		if !isPresent(v, fi) {
+			continue
+		}
+		fv := v.Field(fi.idx)
+		if fi.inline {
+			if err := leaves(fv, p, ns, f); err != nil {
+				return err
+			}
+			continue
+		}
+		cns := nsOr(fi.ns, ns)
+		c := p.Child(cns, fi.name)
+		var err error
+		switch {
+		case fi.empty:
+			err = f(c, true)
+		case isListType(fv.Type()) && len(keyNames(elemType(fv.Type()))) > 0:
+			for j := 0; j < fv.Len() && err == nil; j++ {
+				entry := indirect(fv.Index(j))
+				err = leaves(entry, p.Child(cns, fi.name, Keys(entry.Interface())...), cns, f)
+			}
+		case isListType(fv.Type()):
+			err = f(c, fv.Interface())
+		default:
+			err = leaves(fv, c, cns, f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LeafValue returns the value of a leaf, or a pointer to it, as a basic
+// type which is string for the strings, the enumerations, the bits, the
+// identities qualified by the name of their module and the types known
+// by their text only, bool, int64 and uint64 for the integers, float64
+// and []byte for binary. A decimal64 is returned as is and the value of
+// a union is the one of its member.
+func LeafValue(v interface{}, ns string) (interface{}, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || !isLeafValue(rv) {
+		return nil, fmt.Errorf("nc: %T isn't a leaf", v)
+	}
+	if rv.Kind() == reflect.Struct {
+		if m := unionMember(rv); m.IsValid() {
+			return LeafValue(m.Interface(), ns)
+		}
+	}
+	text, err := marshalText(rv, ns)
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := identityName(rv, text); ok {
+		return id, nil
+	}
+	if _, ok := rv.Interface().(Decimal64); ok {
+		return rv.Interface(), nil
+	}
+	if _, ok := rv.Interface().(Bits); ok {
+		return string(text), nil
+	}
+	switch rv.Kind() {
+	case reflect.Int:
+		// The generated enumerations are of type int
+		if _, ok := asTextMarshaler(rv); ok {
+			return string(text), nil
+		}
+		return rv.Int(), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Slice:
+		return rv.Bytes(), nil
+	}
+	return string(text), nil
+}
+
+// SetLeafValue sets the leaf or the leaf-list leaf points to from a value
+// of a basic type as LeafValue returns, the text of the decimal64. The
+// value of a leaf-list is a slice of those of its leaves.
+func SetLeafValue(leaf interface{}, value interface{}, ns string) error {
+	v := reflect.ValueOf(leaf)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("nc: can't set %T", leaf)
+	}
+	v = v.Elem()
+	if v.Kind() == reflect.Slice && !isLeafValue(v) {
+		values, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("nc: the value of a leaf-list must be a slice")
+		}
+		list := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setLeaf(list.Index(i), value, ns); err != nil {
+				return err
+			}
+		}
+		v.Set(list)
+		return nil
+	}
+	return setLeaf(v, value, ns)
+}
+
+func setLeaf(v reflect.Value, value interface{}, ns string) error {
+	var text []byte
+	switch value := value.(type) {
+	case []byte:
+		if v.Kind() == reflect.Slice {
+			v.SetBytes(value)
+			return nil
+		}
+		text = value
+	default:
+		text = []byte(keyText(value, ns))
+	}
+	if u, ok := asTextUnmarshaler(v); ok {
+		return u.UnmarshalText(ns, text)
+	}
+	return unmarshalBasic(v, text)
+}
+
+// Keys returns the keys of an entry of a list with their values
+func Keys(entry interface{}) []KeyValue {
+	k, ok := entry.(Keyed)
+	v := indirect(reflect.ValueOf(entry))
+	if !ok || !v.IsValid() || v.Kind() != reflect.Struct {
+		return nil
+	}
+	ti := getTypeInfo(v.Type())
+	var keys []KeyValue
+	for _, name := range k.ListKeyNames() {
+		if fv := readPath(v, ti.lookup("", name)); fv.IsValid() {
+			keys = append(keys, KeyValue{Name: name, Value: fv.Interface()})
+		}
+	}
+	return keys
+}
+
+// SetKeys sets the keys of an entry of a list to those of the element of
+// its path
+func SetKeys(entry interface{}, e PathElem) error {
+	v := indirect(reflect.ValueOf(entry))
+	if !v.IsValid() || v.Kind() != reflect.Struct || !v.CanAddr() {
+		return fmt.Errorf("nc: can't set the keys of %T", entry)
+	}
+	return setKeys(v, e)
+}
+
+// Set the keys of an entry from the keys of the path. The keys given as
+// text are decoded as the type of the key.
+func setKeys(entry reflect.Value, e PathElem) error {
+	ti := getTypeInfo(entry.Type())
+	for _, k := range e.Keys {
+		fv, _ := walkPath(entry, ti.lookup("", k.Name))
+		if !fv.IsValid() {
+			return fmt.Errorf("nc: no key %s", k.Name)
+		}
+		kv := reflect.ValueOf(k.Value)
+		if kv.Type().AssignableTo(fv.Type()) {
+			fv.Set(kv)
+			continue
+		}
+		text := []byte(keyText(k.Value, e.Namespace))
+		if u, ok := asTextUnmarshaler(fv); ok {
+			if err := u.UnmarshalText(e.Namespace, text); err != nil {
+				return fmt.Errorf("nc: %s: %s", k.Name, err.Error())
+			}
+			continue
+		}
+		if err := unmarshalBasic(fv, text); err != nil {
+			return fmt.Errorf("nc: %s: %s", k.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// The type of the field at the end of the fields from a structure
+func fieldType(t reflect.Type, fields []*fieldInfo) reflect.Type {
+	for _, fi := range fields {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(fi.idx).Type
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isListType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	e := t.Elem()
+	for e.Kind() == reflect.Ptr {
+		e = e.Elem()
+	}
+	return e.Kind() == reflect.Struct && !isLeafType(e)
+}
+
+// The leaves of a structure type are those of the generated types that
+// implement MarshalText() such as the unions and the decimal64.
+func isLeafType(t reflect.Type) bool {
+	return isLeafValue(reflect.New(t).Elem())
+}
+
+// The type of the entries of a list
+func elemType(t reflect.Type) reflect.Type {
+	t = t.Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func keyNames(t reflect.Type) []string {
+	if k, ok := reflect.New(t).Interface().(Keyed); ok {
+		return k.ListKeyNames()
+	}
+	if k, ok := reflect.New(t).Elem().Interface().(Keyed); ok {
+		return k.ListKeyNames()
+	}
+	return nil
+}
+
+func nodeError(tag string, p Path, msg string) *RPCError {
+	return &RPCError{Type: ErrApplication, Tag: tag, Severity: "error", Path: p.String(), Message: msg}
+}
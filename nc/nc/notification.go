@@ -0,0 +1,33 @@
+package nc
+
+import (
+	"encoding/xml"
+	"sync"
+)
+
+// The generated code registers each notification so that it can be
+// decoded from the name of its element.
+var notifications = struct {
+	sync.RWMutex
+	m map[xml.Name]func() interface{}
+}{m: map[xml.Name]func() interface{}{}}
+
+// RegisterNotification registers the function that creates the structure
+// for the notification with the namespace and the name passed.
+func RegisterNotification(ns, name string, f func() interface{}) {
+	notifications.Lock()
+	defer notifications.Unlock()
+	notifications.m[xml.Name{Space: ns, Local: name}] = f
+}
+
+// NewNotification returns a pointer to a new structure for the
+// notification with the namespace and the name passed.
+func NewNotification(ns, name string) (interface{}, bool) {
+	notifications.RLock()
+	defer notifications.RUnlock()
+	f, ok := notifications.m[xml.Name{Space: ns, Local: name}]
+	if !ok {
+		return nil, false
+	}
+	return f(), true
+}
@@ -0,0 +1,231 @@
+package nc
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Keyed is implemented by the generated entries of lists with keys
+type Keyed interface {
+	ListKeyNames() []string
+}
+
+// EntryPath returns the instance path of an entry of a list from the path
+// of the list. The entry is identified by the values of its keys or by
+// its position, starting at 0, when the list has no keys. A negative
+// position is an entry whose position isn't known and is left out.
+func EntryPath(path string, entry interface{}, pos int) string {
+	k, ok := entry.(Keyed)
+	v := indirect(reflect.ValueOf(entry))
+	if !ok || !v.IsValid() || v.Kind() != reflect.Struct || len(k.ListKeyNames()) == 0 {
+		return positionPath(path, pos)
+	}
+	var b strings.Builder
+	b.WriteString(path)
+	ti := getTypeInfo(v.Type())
+	for _, name := range k.ListKeyNames() {
+		fv := readPath(v, ti.lookup("", name))
+		if !fv.IsValid() {
+			return positionPath(path, pos)
+		}
+		text, err := marshalText(fv, "")
+		if err != nil {
+			text = []byte(fmt.Sprint(fv.Interface()))
+		}
+		b.WriteString("[" + name + "=" + quoteValue(string(text)) + "]")
+	}
+	return b.String()
+}
+
+func positionPath(path string, pos int) string {
+	if pos < 0 {
+		return path
+	}
+	return fmt.Sprintf("%s[%d]", path, pos+1)
+}
+
+// Quote a value in a predicate. The quotes of XPath can't be escaped and
+// the double quote is used when the value contains a single quote.
+func quoteValue(s string) string {
+	if strings.Contains(s, "'") {
+		return "\"" + s + "\""
+	}
+	return "'" + s + "'"
+}
+
+// Read the field at the end of the path without changing the structure
+func readPath(v reflect.Value, path []*fieldInfo) reflect.Value {
+	if path == nil {
+		return reflect.Value{}
+	}
+	for _, fi := range path {
+		v = indirect(v)
+		if !v.IsValid() {
+			return v
+		}
+		v = v.Field(fi.idx)
+	}
+	return v
+}
+
+// Path is the path to a data node from the top of the data tree as built
+// by the generated path builders. The keys of a list identify an entry
+// while a list without keys stands for all its entries.
+type Path []PathElem
+
+// Child returns the path of a child of the node of p. The namespace of the
+// child is the one of its parent when ns is empty.
+func (p Path) Child(ns, name string, keys ...KeyValue) Path {
+	if ns == "" && len(p) > 0 {
+		ns = p[len(p)-1].Namespace
+	}
+	c := make(Path, len(p), len(p)+1)
+	copy(c, p)
+	return append(c, PathElem{Name: name, Namespace: ns, Keys: keys})
+}
+
+// String returns the path as an instance-identifier of RFC 7951 where the
+// name of a node is qualified by the name of its module when the module
+// changes.
+func (p Path) String() string {
+	var b strings.Builder
+	ns := ""
+	for _, e := range p {
+		b.WriteString("/" + qualifiedName(e.Namespace, e.Name, e.Namespace != ns))
+		for _, k := range e.Keys {
+			b.WriteString("[" + k.Name + "=" + quoteValue(keyText(k.Value, e.Namespace)) + "]")
+		}
+		ns = e.Namespace
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// XPath returns the path as an instance-identifier of the XML encoding,
+// which may also serve as an XPath filter, along with the namespaces of
+// the prefixes it uses. The prefixes are those of the modules.
+func (p Path) XPath() (string, map[string]string) {
+	var b strings.Builder
+	prefixes := map[string]string{}
+	prefixed := func(ns, name string) string {
+		m, ok := ModuleByNs(ns)
+		if !ok {
+			return name
+		}
+		prefixes[m.Prefix] = ns
+		return m.Prefix + ":" + name
+	}
+	for _, e := range p {
+		b.WriteString("/" + prefixed(e.Namespace, e.Name))
+		for _, k := range e.Keys {
+			b.WriteString("[" + prefixed(e.Namespace, k.Name) + "=" + quoteValue(keyText(k.Value, e.Namespace)) + "]")
+		}
+	}
+	if b.Len() == 0 {
+		return "/", prefixes
+	}
+	return b.String(), prefixes
+}
+
+// Restconf returns the path as the data resource identifier of RFC 8040
+// relative to {+restconf}/data. The names are qualified by the name of
+// the module as in String() and the keys of an entry follow its name.
+func (p Path) Restconf() string {
+	var b strings.Builder
+	ns := ""
+	for _, e := range p {
+		b.WriteString("/" + qualifiedName(e.Namespace, e.Name, e.Namespace != ns))
+		for i, k := range e.Keys {
+			if i == 0 {
+				b.WriteString("=")
+			} else {
+				b.WriteString(",")
+			}
+			b.WriteString(escapeKey(keyText(k.Value, e.Namespace)))
+		}
+		ns = e.Namespace
+	}
+	return b.String()
+}
+
+// Percent-encode a key value as a segment of a URI where the comma that
+// separates the keys is reserved too
+func escapeKey(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ",", "%2C")
+}
+
+// GnmiElem is an element of a path of gNMI
+type GnmiElem struct {
+	Name string
+	Key  map[string]string
+}
+
+// Gnmi returns the elements of the path for gNMI. The names are qualified
+// by the name of the module as in String().
+func (p Path) Gnmi() []GnmiElem {
+	elems := make([]GnmiElem, len(p))
+	ns := ""
+	for i, e := range p {
+		elems[i].Name = qualifiedName(e.Namespace, e.Name, e.Namespace != ns)
+		if len(e.Keys) > 0 {
+			elems[i].Key = map[string]string{}
+			for _, k := range e.Keys {
+				elems[i].Key[k.Name] = keyText(k.Value, e.Namespace)
+			}
+		}
+		ns = e.Namespace
+	}
+	return elems
+}
+
+// Filter returns the subtree filter of RFC 6241 that selects the node of
+// the path. The keys are the content match nodes that select the entries.
+func (p Path) Filter() ([]byte, error) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	ns := ""
+	for _, elem := range p {
+		e.startElement(elem.Name, elem.Namespace, ns, "")
+		ns = elem.Namespace
+		for _, k := range elem.Keys {
+			if err := e.encodeElement(reflect.ValueOf(k.Value), ns, "", k.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := len(p) - 1; i >= 0; i-- {
+		e.endElement(p[i].Name)
+	}
+	if err := e.w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func qualifiedName(ns, name string, qualify bool) string {
+	if !qualify {
+		return name
+	}
+	if m, ok := ModuleByNs(ns); ok {
+		return m.Name + ":" + name
+	}
+	return name
+}
+
+// The text of the value of a key
+func keyText(v interface{}, ns string) string {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return ""
+	}
+	text, err := marshalText(rv, ns)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(text)
+}
@@ -0,0 +1,95 @@
+package nc
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// RpcInfo describes an rpc or an action. The generated input and output
+// structures of each rpc return it through RpcInfo().
+type RpcInfo struct {
+	Name      string
+	Namespace string
+	Module    string
+}
+
+// Rpc is implemented by the generated input and output structures
+type Rpc interface {
+	RpcInfo() RpcInfo
+}
+
+// MarshalOutput returns the XML encoding of the output of an rpc or an
+// action as the children of <rpc-reply>. The namespace of the rpc, from
+// RpcInfo() or RuntimeNs(), is declared on each of them.
+func MarshalOutput(v interface{}) ([]byte, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("nc: can't encode %T as the output of an rpc", v)
+	}
+	ns := runtimeNs(rv)
+	if r, ok := rv.Interface().(Rpc); ok {
+		ns = r.RpcInfo().Namespace
+	}
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	if err := e.encodeFields(rv, NetconfNs, ns); err != nil {
+		return nil, err
+	}
+	if err := e.w.Flush(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// KeyValue is the value of a key of a list in a path
+type KeyValue struct {
+	Name  string
+	Value interface{}
+}
+
+// PathElem is an element of the path to a data node. The keys identify
+// the entry when the element is a list.
+type PathElem struct {
+	Name      string
+	Namespace string
+	Keys      []KeyValue
+}
+
+// Action is the envelope of a yang 1.1 action. The input is encoded
+// within the data node identified by the path which in turn is encoded
+// within the element <action> of the yang namespace.
+type Action struct {
+	Path  []PathElem
+	Input interface{}
+}
+
+func (e *Encoder) encodeAction(a Action) error {
+	e.startElement("action", YangNs, "", "")
+	ns := YangNs
+	for _, p := range a.Path {
+		e.startElement(p.Name, p.Namespace, ns, "")
+		ns = p.Namespace
+		for _, k := range p.Keys {
+			if err := e.encodeElement(reflect.ValueOf(k.Value), ns, "", k.Name); err != nil {
+				return err
+			}
+		}
+	}
+	rv := indirect(reflect.ValueOf(a.Input))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return fmt.Errorf("nc: invalid input %T for action", a.Input)
+	}
+	inputNs, name := elementName(rv)
+	if name == "" {
+		return fmt.Errorf("nc: can't encode %T without XMLName", a.Input)
+	}
+	if err := e.encodeElement(rv, ns, inputNs, name); err != nil {
+		return err
+	}
+	for i := len(a.Path) - 1; i >= 0; i-- {
+		e.endElement(a.Path[i].Name)
+	}
+	e.endElement("action")
+	return e.w.Flush()
+}
@@ -0,0 +1,73 @@
+package nc
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Datastore is a configuration datastore of netconf
+type Datastore string
+
+const (
+	Running   Datastore = "running"
+	Candidate Datastore = "candidate"
+	Startup   Datastore = "startup"
+)
+
+// The error-type of an rpc-error
+const (
+	ErrTransport   = "transport"
+	ErrRpc         = "rpc"
+	ErrProtocol    = "protocol"
+	ErrApplication = "application"
+)
+
+// RPCError is an <rpc-error> of a reply. The info is the XML content of
+// <error-info>.
+type RPCError struct {
+	XMLName  xml.Name   `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 rpc-error"`
+	Type     string     `xml:"error-type"`
+	Tag      string     `xml:"error-tag"`
+	Severity string     `xml:"error-severity"`
+	AppTag   string     `xml:"error-app-tag,omitempty"`
+	Path     string     `xml:"error-path,omitempty"`
+	Message  string     `xml:"error-message,omitempty"`
+	Info     *ErrorInfo `xml:"error-info,omitempty"`
+}
+
+// ErrorInfo is the content of <error-info> as XML
+type ErrorInfo struct {
+	Content string `xml:",innerxml"`
+}
+
+func (e *RPCError) Error() string {
+	s := e.Tag
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	if e.Path != "" {
+		s = e.Path + ": " + s
+	}
+	return s
+}
+
+// RPCErrors holds the errors of a reply
+type RPCErrors []*RPCError
+
+func (e RPCErrors) Error() string {
+	s := make([]string, len(e))
+	for i, v := range e {
+		s[i] = v.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Err returns nil unless one of the errors has the severity error
+func (e RPCErrors) Err() error {
+	for _, v := range e {
+		if v.Severity != "warning" {
+			return e
+		}
+	}
+	return nil
+}
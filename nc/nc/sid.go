@@ -0,0 +1,40 @@
+package nc
+
+import "sync"
+
+// The generated code registers the SIDs of RFC 9595 read from the .sid
+// files of the modules. The identifiers are the ones of the .sid files
+// except for identities which are qualified as "module:identity":
+//   - a module is identified by its name
+//   - a data node is identified by its path as "/module:node/node" where
+//     the name of the module qualifies a node when it changes
+//   - an identity is identified by "module:identity"
+var sids = struct {
+	sync.RWMutex
+	byId  map[string]uint64
+	bySid map[uint64]string
+}{byId: map[string]uint64{}, bySid: map[uint64]string{}}
+
+// RegisterSid registers the SID of the item with the identifier passed
+func RegisterSid(identifier string, sid uint64) {
+	sids.Lock()
+	defer sids.Unlock()
+	sids.byId[identifier] = sid
+	sids.bySid[sid] = identifier
+}
+
+// SidOf returns the SID registered for the identifier passed
+func SidOf(identifier string) (uint64, bool) {
+	sids.RLock()
+	defer sids.RUnlock()
+	sid, ok := sids.byId[identifier]
+	return sid, ok
+}
+
+// SidIdentifier returns the identifier of the item with the SID passed
+func SidIdentifier(sid uint64) (string, bool) {
+	sids.RLock()
+	defer sids.RUnlock()
+	id, ok := sids.bySid[sid]
+	return id, ok
+}
@@ -0,0 +1,246 @@
+package nc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"nc/xpath"
+)
+
+// dataNode presents the generated structures as the data tree on which
+// the XPath expressions are evaluated. The nodes of a structure are made
+// of its fields as they are encoded, the fields of the choices, cases and
+// groupings being part of the structure that contains them. Each entry
+// of a list or a leaf-list is a node. The children are built when they
+// are first needed.
+type dataNode struct {
+	tree     *dataTree
+	parent   *dataNode
+	ns       string
+	name     string
+	v        reflect.Value // the structure or the value of a leaf
+	leaf     bool
+	empty    bool // leaf of type empty
+	pos      int  // position of an entry, -1 for the other nodes
+	path     string
+	cons     []*Constraint // constraints of the field of the node
+	inline   []*Constraint // when of the choices, cases and uses within
+	children []xpath.Node
+	built    bool
+}
+
+// A tree made of a structure other than the <data> of the device lacks
+// the nodes around it. The evaluations that move above the structure are
+// noted as their result depends on the missing nodes.
+type dataTree struct {
+	partial bool
+	escaped bool
+}
+
+// The tree for v. The <data> of the device is the root while any other
+// structure is the single node under the root.
+func newTree(v interface{}, path string) *dataNode {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return nil
+	}
+	root := &dataNode{tree: &dataTree{}, pos: -1}
+	ns, name := elementName(rv)
+	if isData(ns, name) {
+		root.v = rv
+		return root
+	}
+	root.tree.partial = true
+	top := &dataNode{tree: root.tree, parent: root, ns: ns, name: name, v: rv, pos: -1, path: path}
+	root.children = []xpath.Node{top}
+	root.built = true
+	return root
+}
+
+func (n *dataNode) Name() (string, string) {
+	return n.ns, n.name
+}
+
+// Parent notes the steps of the evaluation that move above the structure
+// of a partial tree
+func (n *dataNode) Parent() xpath.Node {
+	if n.parent == nil {
+		return nil
+	}
+	if n.parent.parent == nil && n.tree.partial {
+		n.tree.escaped = true
+	}
+	return n.parent
+}
+
+// OrderParent returns the parent for the document order, which doesn't
+// depend on the nodes missing from a partial tree
+func (n *dataNode) OrderParent() xpath.Node {
+	if n.parent == nil {
+		return nil
+	}
+	return n.parent
+}
+
+func (n *dataNode) Children() []xpath.Node {
+	if !n.built {
+		n.built = true
+		if !n.leaf {
+			n.addFields(n.v, n.ns)
+		}
+	}
+	return n.children
+}
+
+func (n *dataNode) Value() string {
+	if !n.leaf || n.empty {
+		return ""
+	}
+	text, err := marshalText(n.v, n.ns)
+	if err != nil {
+		return fmt.Sprint(n.v.Interface())
+	}
+	return string(text)
+}
+
+// The children for the fields of a structure
+func (n *dataNode) addFields(v reflect.Value, ns string) {
+	ti := getTypeInfo(v.Type())
+	cons := constraintsOf(v.Type())
+	for _, fi := range ti.fields {
+		fv := v.Field(fi.idx)
+		if fi.prsnt >= 0 && !v.Field(fi.prsnt).Bool() {
+			continue
+		}
+		if fi.empty {
+			if fv.Bool() {
+				c := n.addChild(fv, nsOr(fi.ns, ns), fi.name, cons[fi.goname], -1)
+				c.empty = true
+			}
+			continue
+		}
+		if fi.inline {
+			if fv = indirect(fv); fv.IsValid() && fv.Kind() == reflect.Struct {
+				for _, c := range cons[fi.goname] {
+					if c.Kind == When {
+						n.inline = append(n.inline, c)
+					}
+				}
+				n.addFields(fv, ns)
+			}
+			continue
+		}
+		if fi.prsnt < 0 && fv.IsZero() {
+			continue
+		}
+		fv = indirect(fv)
+		if !fv.IsValid() {
+			continue
+		}
+		if !isLeafValue(fv) && (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) {
+			for i := 0; i < fv.Len(); i++ {
+				n.addChild(fv.Index(i), nsOr(fi.ns, ns), fi.name, cons[fi.goname], i)
+			}
+			continue
+		}
+		n.addChild(fv, nsOr(fi.ns, ns), fi.name, cons[fi.goname], -1)
+	}
+}
+
+func (n *dataNode) addChild(v reflect.Value, ns, name string, cons []*Constraint, pos int) *dataNode {
+	c := &dataNode{tree: n.tree, parent: n, ns: ns, name: name, v: indirect(v), pos: pos, cons: cons}
+	c.leaf = !c.v.IsValid() || isLeafValue(c.v)
+	if !c.v.IsValid() {
+		c.built = true
+	}
+	c.path = n.childPath(c)
+	n.children = append(n.children, c)
+	return c
+}
+
+// The instance path of a child. The name is qualified by the name of the
+// module when the namespace changes. The entries of the lists are
+// identified by their keys and those of the leaf-lists by their values.
+func (n *dataNode) childPath(c *dataNode) string {
+	name := c.name
+	if n.parent == nil || c.ns != n.ns {
+		if m, ok := ModuleByNs(c.ns); ok {
+			name = m.Name + ":" + name
+		}
+	}
+	path := n.path + "/" + name
+	switch {
+	case c.pos < 0:
+		return path
+	case c.leaf:
+		return path + "[.=" + quoteValue(c.Value()) + "]"
+	case c.v.CanAddr():
+		return EntryPath(path, c.v.Addr().Interface(), c.pos)
+	}
+	return EntryPath(path, c.v.Interface(), c.pos)
+}
+
+// The identity of a leaf of type identityref. The identities report their
+// namespace through RuntimeNs() and are encoded as prefix:name.
+func (n *dataNode) Identity() (xml.Name, bool) {
+	if !n.leaf || n.empty || n.v.Kind() != reflect.String {
+		return xml.Name{}, false
+	}
+	rns := runtimeNs(n.v)
+	if rns == "" {
+		return xml.Name{}, false
+	}
+	if _, ns, ok := strings.Cut(rns, "!"); ok {
+		rns = ns
+	}
+	name := n.Value()
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	return xml.Name{Space: rns, Local: name}, true
+}
+
+// The generated enumerations are of type int with the value of the enum
+func (n *dataNode) EnumValue() (int64, bool) {
+	if !n.leaf || n.v.Kind() != reflect.Int {
+		return 0, false
+	}
+	if _, ok := asTextMarshaler(n.v); !ok {
+		return 0, false
+	}
+	return n.v.Int(), true
+}
+
+// The nodes selected by the path of a leafref whose value is the one of
+// the leaf
+func (n *dataNode) Deref() []xpath.Node {
+	var res []xpath.Node
+	for _, c := range n.cons {
+		if c.Kind == Leafref {
+			nodes, _ := n.deref(c)
+			res = append(res, nodes...)
+		}
+	}
+	return res
+}
+
+func (n *dataNode) deref(c *Constraint) ([]xpath.Node, error) {
+	e, err := xpath.CompileCached(c.Expr)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := e.Nodes(c.env(), n)
+	if err != nil {
+		return nil, err
+	}
+	var res []xpath.Node
+	value := n.Value()
+	for _, m := range nodes {
+		if xpath.StringValue(m) == value {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
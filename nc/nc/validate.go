@@ -0,0 +1,58 @@
+package nc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is a violation of a constraint of the schema found by
+// the generated Validate(). The path is the instance path of the node
+// and the tag is the error-app-tag of RFC 7950 if there is one.
+type ValidationError struct {
+	Path    string
+	AppTag  string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validator is implemented by the generated device, containers and lists
+type Validator interface {
+	Validate() error
+}
+
+// ValidationErrors holds every violation found by Validate()
+type ValidationErrors []*ValidationError
+
+// Add adds a violation found at the path passed
+func (e *ValidationErrors) Add(path, tag, msg string) {
+	*e = append(*e, &ValidationError{Path: path, AppTag: tag, Message: msg})
+}
+
+// Err returns nil when no violation was found and the violations otherwise
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e ValidationErrors) Error() string {
+	s := make([]string, len(e))
+	for i, v := range e {
+		s[i] = v.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// UniqueKey returns a string that identifies the values of the leaves of
+// a unique statement so that the entries of a list can be compared.
+func UniqueKey(values ...interface{}) string {
+	var b strings.Builder
+	for _, v := range values {
+		fmt.Fprintf(&b, "%q ", fmt.Sprint(v))
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package restconf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"nc/nc"
+)
+
+// Client issues the methods of RESTCONF on the data resources of a server.
+// The resources are the paths of the generated path builders and the
+// values the generated structures of their nodes, pointers to them for
+// those decoded. The errors of the server are returned as nc.RPCErrors.
+type Client struct {
+	url   string
+	http  *http.Client
+	media string
+}
+
+// NewClient returns a client of the server at url, the scheme and the
+// authority, whose RESTCONF root is /restconf. The http client is the
+// default one if nil. The bodies are encoded in JSON.
+func NewClient(url string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{url: strings.TrimSuffix(url, "/"), http: hc, media: MediaJSON}
+}
+
+// SetEncoding sets the media type of the bodies, MediaJSON or MediaXML
+func (c *Client) SetEncoding(media string) {
+	c.media = media
+}
+
+// Get decodes the node of the path, with its state data, into v
+func (c *Client) Get(ctx context.Context, p nc.Path, v interface{}) error {
+	return c.get(ctx, p, "", v)
+}
+
+// GetConfig decodes the configuration of the node of the path into v
+func (c *Client) GetConfig(ctx context.Context, p nc.Path, v interface{}) error {
+	return c.get(ctx, p, "?content=config", v)
+}
+
+func (c *Client) get(ctx context.Context, p nc.Path, query string, v interface{}) error {
+	body, err := c.do(ctx, http.MethodGet, p.Restconf()+query, "", nil)
+	if err != nil {
+		return err
+	}
+	return decodeNode(c.media, p, body, v)
+}
+
+// Put creates or replaces the node of the path with v
+func (c *Client) Put(ctx context.Context, p nc.Path, v interface{}) error {
+	return c.send(ctx, http.MethodPut, p, p, v)
+}
+
+// Patch merges v into the node of the path which must exist
+func (c *Client) Patch(ctx context.Context, p nc.Path, v interface{}) error {
+	return c.send(ctx, http.MethodPatch, p, p, v)
+}
+
+// Post creates the node of the path with v. It fails if the node exists.
+func (c *Client) Post(ctx context.Context, p nc.Path, v interface{}) error {
+	if len(p) == 0 {
+		return fmt.Errorf("restconf: can't create the data resource")
+	}
+	return c.send(ctx, http.MethodPost, p[:len(p)-1], p, v)
+}
+
+// Delete deletes the node of the path which must exist
+func (c *Client) Delete(ctx context.Context, p nc.Path) error {
+	_, err := c.do(ctx, http.MethodDelete, p.Restconf(), "", nil)
+	return err
+}
+
+// YangPatch applies the patch to the node of the path. The failure of an
+// edit is returned as a *PatchError.
+func (c *Client) YangPatch(ctx context.Context, p nc.Path, patch *Patch) error {
+	body, err := encodePatch(c.media, p, patch)
+	if err != nil {
+		return err
+	}
+	media := MediaPatchJSON
+	if c.media == MediaXML {
+		media = MediaPatchXML
+	}
+	reply, err := c.do(ctx, http.MethodPatch, p.Restconf(), media, body)
+	if err != nil && reply == nil {
+		return err
+	}
+	if serr := decodeStatus(c.media, reply); serr != nil || err == nil {
+		return serr
+	}
+	return err
+}
+
+// Send the node of the path in the body of a method on the resource
+func (c *Client) send(ctx context.Context, method string, resource, p nc.Path, v interface{}) error {
+	body, err := encodeNode(c.media, p, v)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, method, resource.Restconf(), c.media, body)
+	return err
+}
+
+// Issue a request on a data resource. The body of an error reply is
+// returned along with the error when it isn't made of errors.
+func (c *Client) do(ctx context.Context, method, resource, media string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+"/restconf/data"+resource, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", c.media)
+	if body != nil {
+		req.Header.Set("Content-Type", media)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return reply, nil
+	}
+	if errs := decodeErrors(c.media, reply); len(errs) > 0 {
+		return nil, errs
+	}
+	return reply, fmt.Errorf("restconf: %s", resp.Status)
+}
@@ -0,0 +1,381 @@
+package restconf
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"nc/nc"
+	"nc/server"
+)
+
+// Handler serves the datastore of a netconf server over RESTCONF. The
+// resources are under /restconf/data and the changes are made to running
+// as the netconf sessions see them. It is an http.Handler that may be run
+// by httptest.
+type Handler struct {
+	srv *server.Server
+}
+
+// NewHandler returns the handler of the datastore of the server
+func NewHandler(srv *server.Server) *Handler {
+	return &Handler{srv: srv}
+}
+
+// The root of RESTCONF in the host-meta of RFC 6415
+const hostMeta = `<XRD xmlns="http://docs.oasis-open.org/ns/xri/xrd-1.0"><Link rel="restconf" href="/restconf"/></XRD>`
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/.well-known/host-meta" {
+		w.Header().Set("Content-Type", "application/xrd+xml")
+		io.WriteString(w, hostMeta)
+		return
+	}
+	media, ok := acceptedMedia(r.Header.Get("Accept"))
+	if !ok {
+		h.fail(w, MediaJSON, http.StatusNotAcceptable, nc.ErrProtocol, "invalid-value", "the media types accepted aren't supported")
+		return
+	}
+	resource, ok := strings.CutPrefix(r.URL.EscapedPath(), "/restconf/data")
+	if !ok || (resource != "" && resource[0] != '/') {
+		h.fail(w, media, http.StatusNotFound, nc.ErrProtocol, "invalid-value", "no such resource")
+		return
+	}
+	p, err := ParsePath(resource, "")
+	if err != nil {
+		h.fail(w, media, http.StatusBadRequest, nc.ErrProtocol, "invalid-value", err.Error())
+		return
+	}
+	if p, err = nc.ResolvePath(h.srv.Datastore(nc.Running), p); err != nil {
+		h.error(w, media, err)
+		return
+	}
+	var body []byte
+	if r.Body != nil {
+		if body, err = io.ReadAll(r.Body); err != nil {
+			h.fail(w, media, http.StatusBadRequest, nc.ErrProtocol, "malformed-message", err.Error())
+			return
+		}
+	}
+	var bodyMedia string
+	var yangPatch bool
+	switch r.Method {
+	case http.MethodPut, http.MethodPost, http.MethodPatch:
+		bodyMedia, yangPatch, ok = contentMedia(r.Header.Get("Content-Type"))
+		if !ok || (yangPatch && r.Method != http.MethodPatch) {
+			if r.Method == http.MethodPatch {
+				w.Header().Set("Accept-Patch", acceptPatch)
+			}
+			h.fail(w, media, http.StatusUnsupportedMediaType, nc.ErrProtocol, "invalid-value", "the media type of the body isn't supported")
+			return
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, media, p)
+	case http.MethodPut:
+		h.put(w, bodyMedia, media, p, body)
+	case http.MethodPost:
+		h.post(w, bodyMedia, media, p, body)
+	case http.MethodPatch:
+		if yangPatch {
+			h.yangPatch(w, bodyMedia, media, p, body)
+		} else {
+			h.patch(w, bodyMedia, media, p, body)
+		}
+	case http.MethodDelete:
+		if len(p) == 0 {
+			h.fail(w, media, http.StatusMethodNotAllowed, nc.ErrProtocol, "operation-not-supported", "the data resource can't be deleted")
+			return
+		}
+		h.edit(w, media, http.StatusNoContent, func(v interface{}) error {
+			if _, ok := nc.Find(v, p); !ok {
+				return notFound
+			}
+			return nc.EditNode(v, p, nil, nc.OpDelete)
+		})
+	case http.MethodOptions:
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Accept-Patch", acceptPatch)
+		w.WriteHeader(http.StatusOK)
+	default:
+		h.fail(w, media, http.StatusMethodNotAllowed, nc.ErrProtocol, "operation-not-supported", r.Method+" isn't supported")
+	}
+}
+
+// The media types of the bodies of PATCH
+const acceptPatch = MediaJSON + ", " + MediaXML + ", " + MediaPatchJSON + ", " + MediaPatchXML
+
+// The resource to get, patch or delete doesn't exist
+var notFound = errors.New("no such resource")
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, media string, p nc.Path) {
+	var data interface{}
+	var err error
+	switch content := r.URL.Query().Get("content"); content {
+	case "", "all":
+		data, err = h.srv.Get()
+	case "config":
+		data = h.srv.Datastore(nc.Running)
+	default:
+		h.fail(w, media, http.StatusBadRequest, nc.ErrProtocol, "invalid-value", "content "+content+" isn't supported")
+		return
+	}
+	if err != nil {
+		h.error(w, media, err)
+		return
+	}
+	node, ok := nc.Find(data, p)
+	if !ok {
+		h.error(w, media, notFound)
+		return
+	}
+	b, err := encodeNode(media, p, node)
+	if err != nil {
+		h.error(w, media, err)
+		return
+	}
+	w.Header().Set("Content-Type", media)
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Write(b)
+}
+
+// Decode the body of the node of the path
+func (h *Handler) decode(bodyMedia string, p nc.Path, body []byte) (interface{}, error) {
+	v, err := nc.NewNode(h.srv.Datastore(nc.Running), p)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeNode(bodyMedia, p, body, v); err != nil {
+		return nil, &nc.RPCError{Type: nc.ErrProtocol, Tag: "malformed-message", Severity: "error", Message: err.Error()}
+	}
+	return v, nil
+}
+
+// PUT replies 201 when the node is created and 204 when it is replaced
+func (h *Handler) put(w http.ResponseWriter, bodyMedia, media string, p nc.Path, body []byte) {
+	v, err := h.decode(bodyMedia, p, body)
+	if err != nil {
+		h.error(w, media, err)
+		return
+	}
+	created := false
+	if !h.edit(w, media, 0, func(data interface{}) error {
+		_, exists := nc.Find(data, p)
+		created = !exists
+		return nc.EditNode(data, p, v, nc.OpReplace)
+	}) {
+		return
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// POST creates the child of the resource in the body
+func (h *Handler) post(w http.ResponseWriter, bodyMedia, media string, p nc.Path, body []byte) {
+	ns := ""
+	if len(p) > 0 {
+		ns = p[len(p)-1].Namespace
+	}
+	top, err := topName(bodyMedia, body, ns)
+	if err != nil {
+		h.fail(w, media, http.StatusBadRequest, nc.ErrProtocol, "malformed-message", err.Error())
+		return
+	}
+	child := p.Child(top.Namespace, top.Name)
+	v, err := h.decode(bodyMedia, child, body)
+	if err != nil {
+		h.error(w, media, err)
+		return
+	}
+	child[len(child)-1].Keys = nc.Keys(v)
+	if h.edit(w, media, 0, func(data interface{}) error {
+		return nc.EditNode(data, child, v, nc.OpCreate)
+	}) {
+		w.Header().Set("Location", "/restconf/data"+child.Restconf())
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// PATCH merges the body into the node which must exist
+func (h *Handler) patch(w http.ResponseWriter, bodyMedia, media string, p nc.Path, body []byte) {
+	v, err := h.decode(bodyMedia, p, body)
+	if err != nil {
+		h.error(w, media, err)
+		return
+	}
+	h.edit(w, media, http.StatusNoContent, func(data interface{}) error {
+		if _, ok := nc.Find(data, p); !ok {
+			return notFound
+		}
+		return nc.EditNode(data, p, v, nc.OpMerge)
+	})
+}
+
+// The edits of a yang-patch are applied together. The status of the
+// patch reports the first edit that fails.
+func (h *Handler) yangPatch(w http.ResponseWriter, bodyMedia, media string, p nc.Path, body []byte) {
+	yp, err := decodePatch(bodyMedia, body)
+	if err != nil {
+		h.fail(w, media, http.StatusBadRequest, nc.ErrProtocol, "malformed-message", err.Error())
+		return
+	}
+	st := &patchStatus{ID: yp.ID}
+	failed := ""
+	err = h.srv.Edit(func(data interface{}) error {
+		for _, e := range yp.Edits {
+			failed = e.ID
+			if err := h.applyEdit(data, bodyMedia, p, e); err != nil {
+				return err
+			}
+		}
+		failed = ""
+		return nil
+	})
+	code := http.StatusOK
+	switch {
+	case err == nil:
+		st.OK = &empty{}
+	case failed != "":
+		errs := server.RPCErrors(err)
+		code = status(errs[0].Tag)
+		st.Edits = &editStatuses{Edit: []editStatus{{ID: failed, Errors: toRestErrors(errs)}}}
+	default:
+		errs := server.RPCErrors(err)
+		code = status(errs[0].Tag)
+		st.Global = toRestErrors(errs)
+	}
+	w.Header().Set("Content-Type", strings.Replace(media, "yang-data", "yang-patch", 1))
+	w.WriteHeader(code)
+	w.Write(encodeStatus(media, st))
+}
+
+func (h *Handler) applyEdit(data interface{}, bodyMedia string, p nc.Path, e patchEdit) error {
+	ns := ""
+	if len(p) > 0 {
+		ns = p[len(p)-1].Namespace
+	}
+	rel, err := ParsePath(e.Target, ns)
+	if err != nil {
+		return &nc.RPCError{Type: nc.ErrProtocol, Tag: "invalid-value", Severity: "error", Message: err.Error()}
+	}
+	target := append(append(nc.Path(nil), p...), rel...)
+	op := nc.Operation(e.Operation)
+	switch op {
+	case nc.OpCreate, nc.OpMerge, nc.OpReplace:
+		if e.Value == nil {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "missing-element", Severity: "error", Message: "no value"}
+		}
+		v, err := nc.NewNode(data, target)
+		if err != nil {
+			return err
+		}
+		if err := decodeNode(bodyMedia, target, e.Value, v); err != nil {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "malformed-message", Severity: "error", Message: err.Error()}
+		}
+		return nc.EditNode(data, target, v, op)
+	case nc.OpDelete, nc.OpRemove:
+		return nc.EditNode(data, target, nil, op)
+	case OpInsert, OpMove:
+		return h.insert(data, bodyMedia, p, target, e)
+	}
+	return &nc.RPCError{Type: nc.ErrProtocol, Tag: "operation-not-supported", Severity: "error", Message: "operation " + e.Operation + " isn't supported"}
+}
+
+// Insert or move an entry of a list ordered by the user as edit-config
+// does with the attribute insert. An entry inserted must not exist and
+// one moved must. The point is an entry of the same list.
+func (h *Handler) insert(data interface{}, bodyMedia string, p, target nc.Path, e patchEdit) error {
+	target, err := nc.ResolvePath(data, target)
+	if err != nil {
+		return err
+	}
+	ins := nc.Insert{Where: nc.InsertWhere(e.Where)}
+	switch ins.Where {
+	case nc.InsertNone:
+		ins.Where = nc.InsertLast
+	case nc.InsertFirst, nc.InsertLast:
+	case nc.InsertBefore, nc.InsertAfter:
+		if e.Point == "" {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "missing-element", Severity: "error", Message: "no point to insert " + e.Where}
+		}
+		rel, err := ParsePath(e.Point, target[len(target)-1].Namespace)
+		if err != nil {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "invalid-value", Severity: "error", Message: err.Error()}
+		}
+		point, err := nc.ResolvePath(data, append(append(nc.Path(nil), p...), rel...))
+		if err != nil {
+			return err
+		}
+		last, plast := target[len(target)-1], point[len(point)-1]
+		if len(plast.Keys) == 0 || plast.Name != last.Name || plast.Namespace != last.Namespace ||
+			point[:len(point)-1].String() != target[:len(target)-1].String() {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "invalid-value", Severity: "error", Message: "the point " + e.Point + " isn't an entry of the list of the target"}
+		}
+		ins.Key = plast.Keys
+	default:
+		return &nc.RPCError{Type: nc.ErrProtocol, Tag: "invalid-value", Severity: "error", Message: "invalid where " + e.Where}
+	}
+	v, err := nc.NewNode(data, target)
+	if err != nil {
+		return err
+	}
+	op := nc.OpCreate
+	if nc.Operation(e.Operation) == OpMove {
+		if _, ok := nc.Find(data, target); !ok {
+			return &nc.RPCError{Type: nc.ErrApplication, Tag: "data-missing", Severity: "error", Path: target.String(), Message: "the entry to move doesn't exist"}
+		}
+		op = nc.OpMerge
+	} else {
+		if e.Value == nil {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "missing-element", Severity: "error", Message: "no value"}
+		}
+		if err := decodeNode(bodyMedia, target, e.Value, v); err != nil {
+			return &nc.RPCError{Type: nc.ErrProtocol, Tag: "malformed-message", Severity: "error", Message: err.Error()}
+		}
+	}
+	if err := nc.SetInsert(v, ins); err != nil {
+		return &nc.RPCError{Type: nc.ErrProtocol, Tag: "operation-not-supported", Severity: "error", Path: target.String(), Message: e.Operation + " is only supported for the entries of the lists ordered by the user"}
+	}
+	return nc.EditNode(data, target, v, op)
+}
+
+// Apply a change to running and reply the status on success unless it is
+// 0. It returns whether the change succeeded.
+func (h *Handler) edit(w http.ResponseWriter, media string, code int, f func(data interface{}) error) bool {
+	if err := h.srv.Edit(f); err != nil {
+		h.error(w, media, err)
+		return false
+	}
+	if code != 0 {
+		w.WriteHeader(code)
+	}
+	return true
+}
+
+// Reply an error. The status is the one of the error-tag of the first.
+func (h *Handler) error(w http.ResponseWriter, media string, err error) {
+	if err == notFound {
+		h.fail(w, media, http.StatusNotFound, nc.ErrProtocol, "invalid-value", err.Error())
+		return
+	}
+	errs := server.RPCErrors(err)
+	h.reply(w, media, status(errs[0].Tag), errs)
+}
+
+func (h *Handler) fail(w http.ResponseWriter, media string, code int, typ, tag, msg string) {
+	h.reply(w, media, code, nc.RPCErrors{{Type: typ, Tag: tag, Severity: "error", Message: msg}})
+}
+
+func (h *Handler) reply(w http.ResponseWriter, media string, code int, errs nc.RPCErrors) {
+	w.Header().Set("Content-Type", media)
+	w.WriteHeader(code)
+	w.Write(encodeErrors(media, errs))
+}
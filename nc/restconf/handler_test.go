@@ -0,0 +1,168 @@
+package restconf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+	"nc/server"
+)
+
+// A handler of a server whose running holds the items a, b and c
+func testServer(t *testing.T) (*server.Server, *httptest.Server) {
+	dev := &yang.Device{}
+	for _, name := range []string{"a", "b", "c"} {
+		dev.SetT_item(yang.T_item{Name: name})
+	}
+	srv := server.New(dev)
+	ts := httptest.NewServer(NewHandler(srv))
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func itemPath(name string) nc.Path {
+	return yang.Root().T_item(name).Path
+}
+
+func itemNames(srv *server.Server) string {
+	var names []string
+	for _, it := range srv.Datastore(nc.Running).(*yang.Device).T_item {
+		names = append(names, it.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestInsertMove(t *testing.T) {
+	tests := []struct {
+		name string
+		edit Edit
+		want string
+		tag  string
+	}{
+		{"insert", Edit{Operation: OpInsert, Target: itemPath("d")}, "a b c d", ""},
+		{"insert first", Edit{Operation: OpInsert, Target: itemPath("d"), Where: nc.InsertFirst}, "d a b c", ""},
+		{"insert before", Edit{Operation: OpInsert, Target: itemPath("d"), Where: nc.InsertBefore, Point: itemPath("b")}, "a d b c", ""},
+		{"insert after", Edit{Operation: OpInsert, Target: itemPath("d"), Where: nc.InsertAfter, Point: itemPath("c")}, "a b c d", ""},
+		{"move first", Edit{Operation: OpMove, Target: itemPath("c"), Where: nc.InsertFirst}, "c a b", ""},
+		{"move last", Edit{Operation: OpMove, Target: itemPath("a")}, "b c a", ""},
+		{"move before", Edit{Operation: OpMove, Target: itemPath("c"), Where: nc.InsertBefore, Point: itemPath("b")}, "a c b", ""},
+		{"move after", Edit{Operation: OpMove, Target: itemPath("a"), Where: nc.InsertAfter, Point: itemPath("b")}, "b a c", ""},
+		{"insert existing", Edit{Operation: OpInsert, Target: itemPath("a")}, "a b c", "data-exists"},
+		{"move missing", Edit{Operation: OpMove, Target: itemPath("d")}, "a b c", "data-missing"},
+		{"no point", Edit{Operation: OpMove, Target: itemPath("a"), Where: nc.InsertAfter}, "a b c", "missing-element"},
+		{"missing point", Edit{Operation: OpMove, Target: itemPath("a"), Where: nc.InsertAfter, Point: itemPath("d")}, "a b c", "missing-instance"},
+		{"invalid where", Edit{Operation: OpMove, Target: itemPath("a"), Where: "middle"}, "a b c", "invalid-value"},
+	}
+	for _, media := range []string{MediaJSON, MediaXML} {
+		for _, tt := range tests {
+			srv, ts := testServer(t)
+			c := NewClient(ts.URL, ts.Client())
+			c.SetEncoding(media)
+			e := tt.edit
+			if e.Operation == OpInsert {
+				e.Value = &yang.T_item{Name_Prsnt: true, Name: e.Target[0].Keys[0].Value.(string)}
+			}
+			err := c.YangPatch(context.Background(), nil, &Patch{Edits: []Edit{e}})
+			var perr *PatchError
+			switch {
+			case tt.tag == "" && err != nil:
+				t.Errorf("%s %s: %v", media, tt.name, err)
+			case tt.tag != "" && !errors.As(err, &perr):
+				t.Errorf("%s %s: error %v, want a patch error", media, tt.name, err)
+			case tt.tag != "" && perr.Errors[0].Tag != tt.tag && perr.Errors[0].AppTag != tt.tag:
+				t.Errorf("%s %s: error %v, want %s", media, tt.name, err, tt.tag)
+			}
+			if got := itemNames(srv); got != tt.want {
+				t.Errorf("%s %s: items %s, want %s", media, tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	_, ts := testServer(t)
+	tests := []struct {
+		accept string
+		code   int
+		media  string
+	}{
+		{"", http.StatusOK, MediaJSON},
+		{MediaXML, http.StatusOK, MediaXML},
+		{MediaXML + "; charset=utf-8", http.StatusOK, MediaXML},
+		{"*/*", http.StatusOK, MediaJSON},
+		{MediaPatchXML, http.StatusOK, MediaXML},
+		{"application/*", http.StatusOK, MediaJSON},
+		{MediaXML + ";q=0.5, " + MediaJSON + ";q=0.9", http.StatusOK, MediaJSON},
+		{MediaXML + ";q=0.9, " + MediaJSON + ";q=0.5", http.StatusOK, MediaXML},
+		{"*/*;q=0.1, " + MediaXML, http.StatusOK, MediaXML},
+		{MediaJSON + ";q=0, */*", http.StatusOK, MediaXML},
+		{"text/html, */*;q=0.8", http.StatusOK, MediaJSON},
+		{"application/xml", http.StatusNotAcceptable, MediaJSON},
+		{"text/html", http.StatusNotAcceptable, MediaJSON},
+		{MediaXML + ";q=0", http.StatusNotAcceptable, MediaJSON},
+		{"application/yang-data+xmlx", http.StatusNotAcceptable, MediaJSON},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/restconf/data", nil)
+		req.Header.Set("Accept", tt.accept)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code || resp.Header.Get("Content-Type") != tt.media {
+			t.Errorf("Accept %q: %d %s, want %d %s", tt.accept, resp.StatusCode, resp.Header.Get("Content-Type"), tt.code, tt.media)
+		}
+	}
+}
+
+func TestContentType(t *testing.T) {
+	srv, ts := testServer(t)
+	item := `{"test:item":[{"name":"a"}]}`
+	patch := `{"ietf-yang-patch:yang-patch":{"patch-id":"p","edit":[{"edit-id":"1","operation":"remove","target":"/test:item=a"}]}}`
+	tests := []struct {
+		method string
+		ctype  string
+		body   string
+		code   int
+	}{
+		{http.MethodPut, MediaJSON, item, http.StatusNoContent},
+		{http.MethodPut, MediaJSON + "; charset=utf-8", item, http.StatusNoContent},
+		{http.MethodPut, "", item, http.StatusUnsupportedMediaType},
+		{http.MethodPut, "text/plain", item, http.StatusUnsupportedMediaType},
+		{http.MethodPut, "application/json", item, http.StatusUnsupportedMediaType},
+		{http.MethodPut, "application/yang-data+jsonx", item, http.StatusUnsupportedMediaType},
+		{http.MethodPut, MediaPatchJSON, patch, http.StatusUnsupportedMediaType},
+		{http.MethodPatch, "application/xml", item, http.StatusUnsupportedMediaType},
+		{http.MethodPatch, MediaJSON, item, http.StatusNoContent},
+		{http.MethodPatch, MediaPatchJSON, patch, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL+"/restconf/data/test:item=a", strings.NewReader(tt.body))
+		if strings.Contains(tt.body, "yang-patch") {
+			req.URL.Path = "/restconf/data"
+		}
+		if tt.ctype != "" {
+			req.Header.Set("Content-Type", tt.ctype)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Errorf("%s %q: %d, want %d", tt.method, tt.ctype, resp.StatusCode, tt.code)
+		}
+		if tt.code == http.StatusUnsupportedMediaType && tt.method == http.MethodPatch && resp.Header.Get("Accept-Patch") == "" {
+			t.Errorf("%s %q: no Accept-Patch", tt.method, tt.ctype)
+		}
+	}
+	if got := itemNames(srv); got != "b c" {
+		t.Errorf("items %s after the patch, want b c", got)
+	}
+}
@@ -0,0 +1,212 @@
+package restconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"nc/nc"
+)
+
+// Patch is a yang-patch of RFC 8072. Its edits are applied in order to
+// the resource the patch is sent to and either all succeed or none.
+type Patch struct {
+	ID      string
+	Comment string
+	Edits   []Edit
+}
+
+// The operations of yang-patch beyond those of edit-config
+const (
+	OpInsert nc.Operation = "insert"
+	OpMove   nc.Operation = "move"
+)
+
+// Edit is an edit of a yang-patch. The operation is one of create, delete,
+// insert, merge, move, replace and remove. The target is the path of the
+// node edited from the top, within the resource, and the value its
+// content. Insert and move put an entry of a list ordered by the user
+// where given, last by default, and the point is the path of the entry
+// of the list it is put before or after.
+type Edit struct {
+	ID        string
+	Operation nc.Operation
+	Target    nc.Path
+	Point     nc.Path
+	Where     nc.InsertWhere
+	Value     interface{}
+}
+
+// PatchError is the error of an edit of a yang-patch
+type PatchError struct {
+	EditID string
+	Errors nc.RPCErrors
+}
+
+func (e *PatchError) Error() string {
+	return "edit " + e.EditID + ": " + e.Errors.Error()
+}
+
+// The yang-patch and the yang-patch-status in both encodings. The value
+// and the target are encoded separately as they depend on the resource.
+type patchEdit struct {
+	ID        string          `xml:"edit-id" json:"edit-id"`
+	Operation string          `xml:"operation" json:"operation"`
+	Target    string          `xml:"target" json:"target"`
+	Point     string          `xml:"point,omitempty" json:"point,omitempty"`
+	Where     string          `xml:"where,omitempty" json:"where,omitempty"`
+	Value     json.RawMessage `xml:"-" json:"value,omitempty"`
+	XMLValue  *innerXML       `xml:"value" json:"-"`
+}
+
+type innerXML struct {
+	Content []byte `xml:",innerxml"`
+}
+
+type yangPatch struct {
+	XMLName xml.Name    `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-patch yang-patch" json:"-"`
+	ID      string      `xml:"patch-id" json:"patch-id"`
+	Comment string      `xml:"comment,omitempty" json:"comment,omitempty"`
+	Edits   []patchEdit `xml:"edit" json:"edit"`
+}
+
+type editStatus struct {
+	ID     string      `xml:"edit-id" json:"edit-id"`
+	OK     *empty      `xml:"ok" json:"ok,omitempty"`
+	Errors *restErrors `xml:"errors" json:"errors,omitempty"`
+}
+
+type patchStatus struct {
+	XMLName xml.Name      `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-patch yang-patch-status" json:"-"`
+	ID      string        `xml:"patch-id" json:"patch-id"`
+	OK      *empty        `xml:"ok" json:"ok,omitempty"`
+	Global  *restErrors   `xml:"global-errors>errors" json:"global-errors,omitempty"`
+	Edits   *editStatuses `xml:"edit-status" json:"edit-status,omitempty"`
+}
+
+type editStatuses struct {
+	Edit []editStatus `xml:"edit" json:"edit"`
+}
+
+// The type empty of yang, [null] in JSON
+type empty struct{}
+
+func (empty) MarshalJSON() ([]byte, error) {
+	return []byte("[null]"), nil
+}
+
+func (*empty) UnmarshalJSON([]byte) error {
+	return nil
+}
+
+// Encode the patch for the resource of the path. The targets are made
+// relative to the resource.
+func encodePatch(media string, p nc.Path, patch *Patch) ([]byte, error) {
+	yp := yangPatch{ID: patch.ID, Comment: patch.Comment}
+	if yp.ID == "" {
+		yp.ID = "patch"
+	}
+	for i, e := range patch.Edits {
+		target, err := relativePath(p, e.Target)
+		if err != nil {
+			return nil, err
+		}
+		pe := patchEdit{ID: e.ID, Operation: string(e.Operation), Target: target, Where: string(e.Where)}
+		if pe.ID == "" {
+			pe.ID = fmt.Sprint(i + 1)
+		}
+		if e.Point != nil {
+			if pe.Point, err = relativePath(p, e.Point); err != nil {
+				return nil, err
+			}
+		}
+		if e.Value != nil && e.Operation != nc.OpDelete && e.Operation != nc.OpRemove {
+			value, err := encodeNode(media, e.Target, e.Value)
+			if err != nil {
+				return nil, err
+			}
+			if media == MediaXML {
+				pe.XMLValue = &innerXML{Content: value}
+			} else {
+				pe.Value = value
+			}
+		}
+		yp.Edits = append(yp.Edits, pe)
+	}
+	if media == MediaXML {
+		return xml.Marshal(yp)
+	}
+	return json.Marshal(map[string]yangPatch{"ietf-yang-patch:yang-patch": yp})
+}
+
+// The path of a node relative to the resource of the path p, "/" for the
+// resource
+func relativePath(p, node nc.Path) (string, error) {
+	base, s := p.Restconf(), node.Restconf()
+	if s != base && !strings.HasPrefix(s, base+"/") {
+		return "", fmt.Errorf("restconf: the target %s isn't within %s", s, base)
+	}
+	if s = strings.TrimPrefix(s, base); s == "" {
+		s = "/"
+	}
+	return s, nil
+}
+
+func decodePatch(media string, body []byte) (*yangPatch, error) {
+	var yp yangPatch
+	if media == MediaXML {
+		if err := xml.Unmarshal(body, &yp); err != nil {
+			return nil, fmt.Errorf("restconf: invalid yang-patch: %v", err)
+		}
+		for i, e := range yp.Edits {
+			if e.XMLValue != nil {
+				yp.Edits[i].Value = bytes.TrimSpace(e.XMLValue.Content)
+			}
+		}
+		return &yp, nil
+	}
+	var obj map[string]*yangPatch
+	if err := json.Unmarshal(body, &obj); err != nil || obj["ietf-yang-patch:yang-patch"] == nil {
+		return nil, fmt.Errorf("restconf: invalid yang-patch")
+	}
+	return obj["ietf-yang-patch:yang-patch"], nil
+}
+
+func encodeStatus(media string, st *patchStatus) []byte {
+	if media == MediaXML {
+		b, _ := xml.Marshal(st)
+		return b
+	}
+	b, _ := json.Marshal(map[string]*patchStatus{"ietf-yang-patch:yang-patch-status": st})
+	return b
+}
+
+// The error of a yang-patch-status, nil if the patch succeeded
+func decodeStatus(media string, body []byte) error {
+	var st *patchStatus
+	if media == MediaXML {
+		st = &patchStatus{}
+		if err := xml.Unmarshal(body, st); err != nil {
+			return fmt.Errorf("restconf: invalid yang-patch-status: %v", err)
+		}
+	} else {
+		var obj map[string]*patchStatus
+		if err := json.Unmarshal(body, &obj); err != nil || obj["ietf-yang-patch:yang-patch-status"] == nil {
+			return fmt.Errorf("restconf: invalid yang-patch-status")
+		}
+		st = obj["ietf-yang-patch:yang-patch-status"]
+	}
+	if st.Global != nil {
+		return st.Global.rpcErrors()
+	}
+	if st.Edits != nil {
+		for _, e := range st.Edits.Edit {
+			if e.Errors != nil {
+				return &PatchError{EditID: e.ID, Errors: e.Errors.rpcErrors()}
+			}
+		}
+	}
+	return nil
+}
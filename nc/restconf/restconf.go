@@ -0,0 +1,374 @@
+// Package restconf binds the generated structures to RESTCONF of RFC 8040.
+// The data resources are the paths of the generated path builders whose
+// names are qualified by the modules registered by the generated code.
+// The bodies are encoded in JSON of RFC 7951 or in XML. The client issues
+// the methods on the resources and yang-patch of RFC 8072 while the
+// handler serves the datastore of a netconf server.
+package restconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"nc/nc"
+)
+
+// The media types of the bodies
+const (
+	MediaJSON      = "application/yang-data+json"
+	MediaXML       = "application/yang-data+xml"
+	MediaPatchJSON = "application/yang-patch+json"
+	MediaPatchXML  = "application/yang-patch+xml"
+)
+
+// The namespace of the module ietf-restconf
+const Ns = "urn:ietf:params:xml:ns:yang:ietf-restconf"
+
+// The namespace of the module ietf-yang-patch
+const PatchNs = "urn:ietf:params:xml:ns:yang:ietf-yang-patch"
+
+// ParsePath returns the path of a data resource identifier relative to
+// {+restconf}/data as Path.Restconf() returns it. The namespace of an
+// unqualified name is the one of its parent, ns at the top. The keys are
+// left unnamed.
+func ParsePath(s, ns string) (nc.Path, error) {
+	s = strings.TrimPrefix(s, "/")
+	if s == "" {
+		return nil, nil
+	}
+	var p nc.Path
+	for _, seg := range strings.Split(s, "/") {
+		name, keys, hasKeys := strings.Cut(seg, "=")
+		name, err := url.PathUnescape(name)
+		if err != nil {
+			return nil, fmt.Errorf("restconf: invalid path %s", s)
+		}
+		if mod, local, ok := strings.Cut(name, ":"); ok {
+			m, ok := nc.ModuleByName(mod)
+			if !ok {
+				return nil, fmt.Errorf("restconf: unknown module %s", mod)
+			}
+			ns, name = m.Namespace, local
+		}
+		if ns == "" {
+			return nil, fmt.Errorf("restconf: %s isn't qualified by its module", name)
+		}
+		var kv []nc.KeyValue
+		if hasKeys {
+			for _, k := range strings.Split(keys, ",") {
+				k, err := url.PathUnescape(k)
+				if err != nil {
+					return nil, fmt.Errorf("restconf: invalid key in %s", s)
+				}
+				kv = append(kv, nc.KeyValue{Value: k})
+			}
+		}
+		p = append(p, nc.PathElem{Name: name, Namespace: ns, Keys: kv})
+	}
+	return p, nil
+}
+
+// The name of a node qualified by its module as the top of a body
+func memberName(e nc.PathElem) string {
+	if m, ok := nc.ModuleByNs(e.Namespace); ok {
+		return m.Name + ":" + e.Name
+	}
+	return e.Name
+}
+
+// Encode the node of the path as a body. The device is <data> of
+// ietf-restconf and an entry is within an array of one in JSON with the
+// keys of the path.
+func encodeNode(media string, p nc.Path, v interface{}) ([]byte, error) {
+	var err error
+	if media == MediaXML {
+		if len(p) == 0 {
+			return nc.MarshalElement(v, Ns, "data")
+		}
+		e := p[len(p)-1]
+		if len(e.Keys) > 0 {
+			if v, err = withKeys(v, e); err != nil {
+				return nil, err
+			}
+		}
+		return nc.MarshalElement(v, e.Namespace, e.Name)
+	}
+	var b bytes.Buffer
+	if len(p) == 0 {
+		data, err := nc.MarshalJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(`{"ietf-restconf:data":`)
+		b.Write(data)
+		b.WriteString("}")
+		return b.Bytes(), nil
+	}
+	e := p[len(p)-1]
+	if len(e.Keys) > 0 {
+		if v, err = withKeys(v, e); err != nil {
+			return nil, err
+		}
+	}
+	value, err := nc.MarshalJSONValue(v, e.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := json.Marshal(memberName(e))
+	b.WriteString("{")
+	b.Write(name)
+	b.WriteString(":")
+	if len(e.Keys) > 0 {
+		b.WriteString("[")
+		b.Write(value)
+		b.WriteString("]")
+	} else {
+		b.Write(value)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+// A copy of an entry with the keys of its path as the keys in the body
+// must be those of the resource
+func withKeys(entry interface{}, e nc.PathElem) (interface{}, error) {
+	c := nc.Clone(entry)
+	if err := nc.SetKeys(c, e); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Decode the body of the node of the path into v
+func decodeNode(media string, p nc.Path, body []byte, v interface{}) error {
+	if media == MediaXML {
+		d := nc.NewDecoder(bytes.NewReader(body))
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return fmt.Errorf("restconf: invalid body: %v", err)
+			}
+			start, ok := tok.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			if len(p) == 0 {
+				start.Name = xml.Name{Space: nc.NetconfNs, Local: "data"}
+			} else if e := p[len(p)-1]; start.Name.Local != e.Name || start.Name.Space != e.Namespace {
+				return fmt.Errorf("restconf: the body isn't %s", e.Name)
+			}
+			return d.DecodeElement(v, &start)
+		}
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return fmt.Errorf("restconf: invalid body: %v", err)
+	}
+	if len(p) == 0 {
+		data, ok := obj["ietf-restconf:data"]
+		if !ok {
+			return fmt.Errorf("restconf: the body isn't ietf-restconf:data")
+		}
+		return nc.UnmarshalJSON(data, v)
+	}
+	e := p[len(p)-1]
+	value, ok := obj[memberName(e)]
+	if !ok {
+		return fmt.Errorf("restconf: the body isn't %s", memberName(e))
+	}
+	if len(value) > 0 && value[0] == '[' {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(value, &entries); err != nil || len(entries) != 1 {
+			return fmt.Errorf("restconf: the body of %s isn't one entry", e.Name)
+		}
+		value = entries[0]
+	}
+	return nc.UnmarshalJSONValue(value, v, e.Namespace)
+}
+
+// The name of the node at the top of a body. Its namespace is ns unless
+// qualified.
+func topName(media string, body []byte, ns string) (nc.PathElem, error) {
+	if media == MediaXML {
+		d := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nc.PathElem{}, fmt.Errorf("restconf: invalid body: %v", err)
+			}
+			if start, ok := tok.(xml.StartElement); ok {
+				return nc.PathElem{Name: start.Name.Local, Namespace: start.Name.Space}, nil
+			}
+		}
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nc.PathElem{}, fmt.Errorf("restconf: invalid body: %v", err)
+	}
+	if len(obj) != 1 {
+		return nc.PathElem{}, fmt.Errorf("restconf: the body must hold a single node")
+	}
+	for k := range obj {
+		p, err := ParsePath(k, ns)
+		if err != nil {
+			return nc.PathElem{}, err
+		}
+		return p[0], nil
+	}
+	return nc.PathElem{}, nil
+}
+
+// The error of the errors of RFC 8040 in both encodings
+type restError struct {
+	Type    string `xml:"error-type" json:"error-type"`
+	Tag     string `xml:"error-tag" json:"error-tag"`
+	AppTag  string `xml:"error-app-tag,omitempty" json:"error-app-tag,omitempty"`
+	Path    string `xml:"error-path,omitempty" json:"error-path,omitempty"`
+	Message string `xml:"error-message,omitempty" json:"error-message,omitempty"`
+}
+
+type restErrors struct {
+	XMLName xml.Name    `xml:"urn:ietf:params:xml:ns:yang:ietf-restconf errors" json:"-"`
+	Error   []restError `xml:"error" json:"error"`
+}
+
+func toRestErrors(errs nc.RPCErrors) *restErrors {
+	r := &restErrors{}
+	for _, e := range errs {
+		r.Error = append(r.Error, restError{Type: e.Type, Tag: e.Tag, AppTag: e.AppTag, Path: e.Path, Message: e.Message})
+	}
+	return r
+}
+
+func (r *restErrors) rpcErrors() nc.RPCErrors {
+	var errs nc.RPCErrors
+	for _, e := range r.Error {
+		errs = append(errs, &nc.RPCError{Type: e.Type, Tag: e.Tag, Severity: "error", AppTag: e.AppTag, Path: e.Path, Message: e.Message})
+	}
+	return errs
+}
+
+func encodeErrors(media string, errs nc.RPCErrors) []byte {
+	r := toRestErrors(errs)
+	if media == MediaXML {
+		b, _ := xml.Marshal(r)
+		return b
+	}
+	b, _ := json.Marshal(map[string]*restErrors{"ietf-restconf:errors": r})
+	return b
+}
+
+// Decode the errors of a body, nil if it holds none
+func decodeErrors(media string, body []byte) nc.RPCErrors {
+	var r restErrors
+	if media == MediaXML {
+		if xml.Unmarshal(body, &r) != nil {
+			return nil
+		}
+		return r.rpcErrors()
+	}
+	var obj map[string]*restErrors
+	if json.Unmarshal(body, &obj) != nil || obj["ietf-restconf:errors"] == nil {
+		return nil
+	}
+	return obj["ietf-restconf:errors"].rpcErrors()
+}
+
+// The status of the error-tag as in section 7 of RFC 8040
+func status(tag string) int {
+	switch tag {
+	case "in-use", "lock-denied", "resource-denied", "data-exists", "data-missing":
+		return http.StatusConflict
+	case "too-big":
+		return http.StatusRequestEntityTooLarge
+	case "access-denied":
+		return http.StatusForbidden
+	case "operation-not-supported":
+		return http.StatusMethodNotAllowed
+	case "rollback-failed", "operation-failed", "partial-operation":
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// The media of the replies from the value of Accept, JSON when there is
+// none. Each media type supported takes the q-value of the most specific
+// range that matches it and the highest is chosen, JSON for equal ones.
+// The media types of yang-patch stand for those of its status. It returns
+// false when none is acceptable.
+func acceptedMedia(header string) (string, bool) {
+	if strings.TrimSpace(header) == "" {
+		return MediaJSON, true
+	}
+	type mediaRange struct {
+		typ string
+		q   float64
+	}
+	var ranges []mediaRange
+	for _, s := range strings.Split(header, ",") {
+		typ, params, err := mime.ParseMediaType(s)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if typ == MediaPatchJSON || typ == MediaPatchXML {
+			typ = strings.Replace(typ, "yang-patch", "yang-data", 1)
+		}
+		ranges = append(ranges, mediaRange{typ, q})
+	}
+	media, best := "", 0.0
+	for _, m := range []string{MediaJSON, MediaXML} {
+		q, specific := 0.0, -1
+		for _, r := range ranges {
+			s := -1
+			switch r.typ {
+			case m:
+				s = 2
+			case "application/*":
+				s = 1
+			case "*/*":
+				s = 0
+			}
+			if s > specific {
+				q, specific = r.q, s
+			}
+		}
+		if q > best {
+			media, best = m, q
+		}
+	}
+	return media, media != ""
+}
+
+// The media of a body from the value of Content-Type, MediaJSON or
+// MediaXML, and whether it is a yang-patch. It returns false for the media
+// types not supported.
+func contentMedia(header string) (media string, patch bool, ok bool) {
+	typ, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", false, false
+	}
+	switch typ {
+	case MediaJSON:
+		return MediaJSON, false, true
+	case MediaXML:
+		return MediaXML, false, true
+	case MediaPatchJSON:
+		return MediaJSON, true, true
+	case MediaPatchXML:
+		return MediaXML, true, true
+	}
+	return "", false, false
+}
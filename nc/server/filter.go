@@ -0,0 +1,167 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"nc/nc"
+)
+
+// node is an element of the XML of a datastore or of a filter
+type node struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*node
+}
+
+// Read the element whose start has been read along with its content
+func readNode(d *nc.Decoder, start xml.StartElement) (*node, error) {
+	n := &node{name: start.Name, attrs: start.Attr}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			c, err := readNode(d, t)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, c)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(n.children) == 0 {
+				n.text = text.String()
+			}
+			return n, nil
+		}
+	}
+}
+
+// Parse the XML of a single element
+func parseNode(b []byte) (*node, error) {
+	d := nc.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return readNode(d, start)
+		}
+	}
+}
+
+// Write the element. The namespace is declared when it differs from the
+// one of the parent and the declarations of prefixes are kept for the
+// values and the attributes that use them.
+func (n *node) write(b *bytes.Buffer, parentNs string) {
+	b.WriteString("<" + n.name.Local)
+	if n.name.Space != parentNs {
+		b.WriteString(` xmlns="`)
+		xml.EscapeText(b, []byte(n.name.Space))
+		b.WriteString(`"`)
+	}
+	prefixes := map[string]string{}
+	for _, a := range n.attrs {
+		if a.Name.Space == "xmlns" {
+			prefixes[a.Value] = a.Name.Local
+			b.WriteString(" xmlns:" + a.Name.Local + `="`)
+			xml.EscapeText(b, []byte(a.Value))
+			b.WriteString(`"`)
+		}
+	}
+	for i, a := range n.attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		name := a.Name.Local
+		if a.Name.Space != "" {
+			prefix, ok := prefixes[a.Name.Space]
+			if !ok {
+				prefix = fmt.Sprintf("ns%d", i)
+				prefixes[a.Name.Space] = prefix
+				b.WriteString(" xmlns:" + prefix + `="`)
+				xml.EscapeText(b, []byte(a.Name.Space))
+				b.WriteString(`"`)
+			}
+			name = prefix + ":" + name
+		}
+		b.WriteString(" " + name + `="`)
+		xml.EscapeText(b, []byte(a.Value))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+	xml.EscapeText(b, []byte(n.text))
+	for _, c := range n.children {
+		c.write(b, n.name.Space)
+	}
+	b.WriteString("</" + n.name.Local + ">")
+}
+
+// The name of a node of the filter matches the one of the data when the
+// filter has no namespace or the same one
+func (f *node) matches(n *node) bool {
+	return f.name.Local == n.name.Local && (f.name.Space == "" || f.name.Space == n.name.Space)
+}
+
+func (f *node) isContentMatch() bool {
+	return len(f.children) == 0 && strings.TrimSpace(f.text) != ""
+}
+
+// Select the children of the data node as per the nodes of a subtree
+// filter of RFC 6241 at the same level. It returns false when the content
+// match nodes exclude the data node.
+func selectChildren(data *node, filter []*node) ([]*node, bool) {
+	onlyMatches := true
+	for _, f := range filter {
+		if !f.isContentMatch() {
+			onlyMatches = false
+			continue
+		}
+		found := false
+		for _, c := range data.children {
+			if f.matches(c) && strings.TrimSpace(c.text) == strings.TrimSpace(f.text) && len(c.children) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	if onlyMatches {
+		return data.children, true
+	}
+	var selected []*node
+	for _, c := range data.children {
+		for _, f := range filter {
+			if !f.matches(c) {
+				continue
+			}
+			if f.isContentMatch() {
+				if strings.TrimSpace(c.text) == strings.TrimSpace(f.text) {
+					selected = append(selected, c)
+					break
+				}
+				continue
+			}
+			if len(f.children) == 0 {
+				selected = append(selected, c)
+				break
+			}
+			children, ok := selectChildren(c, f.children)
+			if ok && len(children) > 0 {
+				selected = append(selected, &node{name: c.name, attrs: c.attrs, children: children})
+				break
+			}
+		}
+	}
+	return selected, true
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"nc/nc"
+)
+
+// Notify sends the event to the sessions subscribed to the NETCONF stream
+// whose filter selects it, once for each subscription of the session. The event is either the generated structure of
+// a notification of a module or, for the notifications of the data nodes,
+// the generated device structure holding the path down to it.
+func (s *Server) Notify(event interface{}) error {
+	var events []*node
+	ns, name := nc.ElementName(event)
+	if ns == nc.NetconfNs && name == "data" {
+		b, err := nc.MarshalElement(event, nc.NotificationNs, "notification")
+		if err != nil {
+			return err
+		}
+		root, err := parseNode(b)
+		if err != nil {
+			return err
+		}
+		events = root.children
+	} else {
+		b, err := nc.Marshal(event)
+		if err != nil {
+			return err
+		}
+		n, err := parseNode(b)
+		if err != nil {
+			return err
+		}
+		events = []*node{n}
+	}
+	eventTime := time.Now().Format(time.RFC3339Nano)
+
+	// The filters of the subscriptions of each session, nil for those
+	// without one
+	s.mu.Lock()
+	filters := map[*Session][]*node{}
+	for _, sess := range s.sessions {
+		if sess.subscribed {
+			filters[sess] = append(filters[sess], sess.filter)
+		}
+		for _, filter := range sess.subscriptions {
+			filters[sess] = append(filters[sess], filter)
+		}
+	}
+	s.mu.Unlock()
+	for sess, sessFilters := range filters {
+		for _, filter := range sessFilters {
+			selected := events
+			if filter != nil {
+				selected, _ = selectChildren(&node{children: events}, filter.children)
+			}
+			if len(selected) == 0 {
+				continue
+			}
+			var b bytes.Buffer
+			b.WriteString(`<notification xmlns="` + nc.NotificationNs + `"><eventTime>` + eventTime + "</eventTime>")
+			for _, n := range selected {
+				n.write(&b, nc.NotificationNs)
+			}
+			b.WriteString("</notification>")
+			// A session that has gone away misses the notification
+			sess.f.WriteMsg(b.Bytes())
+		}
+	}
+	return nil
+}
+
+// Subscribe the session to the notifications of the NETCONF stream as in
+// RFC 5277. The replay of the notifications isn't supported.
+func (s *Session) createSubscription(d *nc.Decoder) error {
+	var filter *node
+	for {
+		t, err := nextStart(d)
+		if err != nil {
+			return rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		if t == nil {
+			break
+		}
+		n, err := readNode(d, *t)
+		if err != nil {
+			return rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		switch n.name.Local {
+		case "stream":
+			if stream := strings.TrimSpace(n.text); stream != "NETCONF" {
+				return rpcError(nc.ErrProtocol, "invalid-value", "no stream "+stream)
+			}
+		case "filter":
+			if typ := attrValue(n.attrs, "type"); typ != "" && typ != "subtree" {
+				return rpcError(nc.ErrProtocol, "invalid-value", "filter of type "+typ+" isn't supported")
+			}
+			filter = n
+		case "startTime":
+			return rpcError(nc.ErrProtocol, "operation-failed", "the replay of the notifications isn't supported")
+		}
+	}
+	s.srv.mu.Lock()
+	defer s.srv.mu.Unlock()
+	if s.subscribed {
+		return rpcError(nc.ErrProtocol, "operation-failed", "the session already has a subscription")
+	}
+	s.subscribed = true
+	s.filter = filter
+	return nil
+}
+
+// The error of a subscription of RFC 8639 whose reason is the identity
+// passed of ietf-subscribed-notifications
+func subscriptionError(tag, reason, msg string) *nc.RPCError {
+	e := rpcError(nc.ErrApplication, tag, msg)
+	e.AppTag = "ietf-subscribed-notifications:" + reason
+	return e
+}
+
+// Establish a dynamic subscription of the session to the notifications of
+// the NETCONF stream as in RFC 8639 and reply with its id. The filters are
+// subtree filters and the replay of the notifications isn't supported.
+func (s *Session) establishSubscription(d *nc.Decoder) ([]byte, error) {
+	var filter *node
+	for {
+		t, err := nextStart(d)
+		if err != nil {
+			return nil, rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		if t == nil {
+			break
+		}
+		n, err := readNode(d, *t)
+		if err != nil {
+			return nil, rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		switch n.name.Local {
+		case "stream":
+			if stream := strings.TrimSpace(n.text); stream != "NETCONF" {
+				return nil, subscriptionError("invalid-value", "stream-unavailable", "no stream "+stream)
+			}
+		case "stream-subtree-filter":
+			filter = n
+		case "stream-xpath-filter", "stream-filter-name":
+			return nil, subscriptionError("invalid-value", "filter-unsupported", n.name.Local+" isn't supported")
+		case "replay-start-time":
+			return nil, subscriptionError("operation-failed", "replay-unsupported", "the replay of the notifications isn't supported")
+		case "encoding":
+			if enc := strings.TrimSpace(n.text); !strings.HasSuffix(enc, "encode-xml") {
+				return nil, subscriptionError("invalid-value", "encoding-unsupported", "encoding "+enc+" isn't supported")
+			}
+		}
+	}
+	srv := s.srv
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.nextSub++
+	id := srv.nextSub
+	if s.subscriptions == nil {
+		s.subscriptions = map[uint32]*node{}
+	}
+	s.subscriptions[id] = filter
+	return []byte(`<id xmlns="` + nc.SubscribedNotificationsNs + `">` + strconv.FormatUint(uint64(id), 10) + "</id>"), nil
+}
+
+// Delete a dynamic subscription of the session
+func (s *Session) deleteSubscription(d *nc.Decoder) error {
+	var id string
+	for {
+		t, err := nextStart(d)
+		if err != nil {
+			return rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		if t == nil {
+			break
+		}
+		n, err := readNode(d, *t)
+		if err != nil {
+			return rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		if n.name.Local == "id" {
+			id = strings.TrimSpace(n.text)
+		}
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	s.srv.mu.Lock()
+	defer s.srv.mu.Unlock()
+	if _, ok := s.subscriptions[uint32(n)]; err != nil || !ok {
+		return subscriptionError("invalid-value", "no-such-subscription", "no subscription "+id)
+	}
+	delete(s.subscriptions, uint32(n))
+	return nil
+}
@@ -0,0 +1,301 @@
+// Package server is a netconf server that hosts the datastores as the
+// generated structures. It stands in for a device in the tests of the
+// clients and runs over any stream such as stdio or a net.Conn. The rpcs
+// of the modules are dispatched to the handlers registered for their
+// generated input.
+package server
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"nc/nc"
+	"nc/transport"
+)
+
+// Request is an rpc or an action received. The input is a pointer to the
+// generated input structure registered for it. The path is the one of the
+// data node of an action.
+type Request struct {
+	Session *Session
+	Input   interface{}
+	Path    nc.Path
+}
+
+// Handler handles an rpc. The output returned, if not nil, is the
+// generated output structure of the rpc encoded in the reply and <ok/>
+// is replied otherwise. An *nc.RPCError or nc.RPCErrors returned is
+// replied as is and any other error as operation-failed.
+type Handler func(r *Request) (interface{}, error)
+
+type handler struct {
+	typ reflect.Type
+	h   Handler
+}
+
+// Server holds the datastores running, candidate and startup along with
+// the state data and serves the sessions of the clients.
+type Server struct {
+	mu       sync.Mutex
+	typ      reflect.Type
+	stores   map[nc.Datastore]interface{}
+	state    interface{}
+	locks    map[nc.Datastore]*Session
+	dirty    bool // candidate differs from running
+	handlers map[xml.Name]handler
+	sessions map[uint64]*Session
+	nextID   uint64
+	watchers map[chan struct{}]bool
+	nextSub  uint32 // the id of the last dynamic subscription
+}
+
+// The capabilities of the protocol the server supports
+var serverCapabilities = []string{
+	transport.Base10,
+	transport.Base11,
+	transport.CandidateCapability,
+	transport.StartupCapability,
+	transport.ValidateCapability,
+	transport.WritableRunningCapability,
+	transport.NotificationCapability,
+	transport.InterleaveCapability,
+	transport.WithDefaultsCapability + "?basic-mode=explicit&also-supported=report-all,trim,report-all-tagged",
+	nc.SubscribedNotificationsNs + "?module=ietf-subscribed-notifications&revision=2019-09-09",
+}
+
+// New returns a server whose datastores start with the configuration of
+// device, a pointer to the generated device structure.
+func New(device interface{}) *Server {
+	v := reflect.ValueOf(device)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("server: %T isn't a pointer to the device", device))
+	}
+	return &Server{
+		typ: v.Elem().Type(),
+		stores: map[nc.Datastore]interface{}{
+			nc.Running:   nc.Clone(device),
+			nc.Candidate: nc.Clone(device),
+			nc.Startup:   nc.Clone(device),
+		},
+		locks:    map[nc.Datastore]*Session{},
+		handlers: map[xml.Name]handler{},
+		sessions: map[uint64]*Session{},
+		watchers: map[chan struct{}]bool{},
+	}
+}
+
+// Capabilities returns the capabilities of the hello of the server which
+// include those of every module compiled in
+func (s *Server) Capabilities() []string {
+	return append(append([]string(nil), serverCapabilities...), nc.Capabilities()...)
+}
+
+// Handle registers the handler of the rpc or the action whose generated
+// input is passed
+func (s *Server) Handle(input nc.Rpc, h Handler) {
+	ns, name := nc.ElementName(input)
+	if name == "" {
+		panic(fmt.Sprintf("server: %T has no XMLName", input))
+	}
+	t := reflect.TypeOf(input)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[xml.Name{Space: ns, Local: name}] = handler{typ: t, h: h}
+}
+
+// Datastore returns a copy of the content of the datastore
+func (s *Server) Datastore(ds nc.Datastore) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return nc.Clone(s.stores[ds])
+}
+
+// Update changes the datastore through f which is passed a pointer to
+// its content
+func (s *Server) Update(ds nc.Datastore, f func(v interface{})) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.stores[ds])
+	if ds == nc.Running {
+		s.changed()
+	}
+}
+
+// SetState sets the state data which get returns along with running. The
+// state is a pointer to the generated device structure.
+func (s *Server) SetState(state interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = nc.Clone(state)
+	s.changed()
+}
+
+// State returns a copy of the state data, a pointer to the generated
+// device structure
+func (s *Server) State() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return reflect.New(s.typ).Interface()
+	}
+	return nc.Clone(s.state)
+}
+
+// Get returns a copy of running with the state data merged as the
+// operation get does
+func (s *Server) Get() (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get()
+}
+
+func (s *Server) get() (interface{}, error) {
+	data := nc.Clone(s.stores[nc.Running])
+	if s.state != nil {
+		if err := nc.Edit(data, s.state, nc.OpMerge); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Edit changes running through f which is passed a copy of it. It is
+// how the protocols other than netconf change the configuration. The
+// change is refused while a session has running locked and the result
+// must be valid. The candidate follows unless it has changes of its own.
+func (s *Server) Edit(f func(running interface{}) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if holder := s.locks[nc.Running]; holder != nil {
+		return rpcError(nc.ErrProtocol, "in-use", "running is locked by session "+strconv.FormatUint(holder.id, 10))
+	}
+	v := nc.Clone(s.stores[nc.Running])
+	if err := f(v); err != nil {
+		return err
+	}
+	if err := validate(v); err != nil {
+		return err
+	}
+	s.stores[nc.Running] = v
+	if !s.dirty {
+		s.stores[nc.Candidate] = nc.Clone(v)
+	}
+	s.changed()
+	return nil
+}
+
+// Watch returns a channel that receives a value when running or the state
+// data change until stop is called. The changes made while a value is
+// pending are reported by it.
+func (s *Server) Watch() (changes <-chan struct{}, stop func()) {
+	c := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers[c] = true
+	s.mu.Unlock()
+	return c, func() {
+		s.mu.Lock()
+		delete(s.watchers, c)
+		s.mu.Unlock()
+	}
+}
+
+// Signal the watchers of a change. The server is locked.
+func (s *Server) changed() {
+	for c := range s.watchers {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Serve accepts the connections of the listener and serves each of them
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+// ServeStdio serves a session on the standard input and output as when
+// run as the netconf subsystem of an SSH server
+func (s *Server) ServeStdio() error {
+	return s.ServeConn(stdio{os.Stdin, os.Stdout})
+}
+
+// ServeConn serves a session over rw until the client closes it. The
+// stream is closed at the end if it is an io.Closer.
+func (s *Server) ServeConn(rw io.ReadWriter) error {
+	sess := s.newSession(rw)
+	defer s.endSession(sess)
+	f := sess.f
+	hello := &transport.Hello{Capabilities: s.Capabilities(), SessionID: sess.id}
+	peer, err := transport.Exchange(f, hello)
+	if err != nil {
+		return err
+	}
+	sess.caps = peer.Capabilities
+	for {
+		msg, err := f.ReadMsg()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		reply, closing := sess.handle(msg)
+		if err := f.WriteMsg(reply); err != nil {
+			return err
+		}
+		if closing {
+			return nil
+		}
+	}
+}
+
+func (s *Server) newSession(rw io.ReadWriter) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	sess := &Session{srv: s, id: s.nextID, rw: rw, f: transport.NewFramer(rw, rw)}
+	s.sessions[sess.id] = sess
+	return sess
+}
+
+// The locks of a session are released when it ends and the changes to
+// the candidate it locked are discarded
+func (s *Server) endSession(sess *Session) {
+	s.mu.Lock()
+	delete(s.sessions, sess.id)
+	for ds, holder := range s.locks {
+		if holder == sess {
+			delete(s.locks, ds)
+			if ds == nc.Candidate && s.dirty {
+				s.stores[nc.Candidate] = nc.Clone(s.stores[nc.Running])
+				s.dirty = false
+			}
+		}
+	}
+	s.mu.Unlock()
+	if c, ok := sess.rw.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"nc/internal/yang"
+	"nc/nc"
+	"nc/transport"
+)
+
+// The configuration the datastores start with
+func testDevice() *yang.Device {
+	dev := &yang.Device{T_system_Prsnt: true, T_system: yang.T_system_cont{
+		Hostname_Prsnt: true, Hostname: "r1", Mtu_Prsnt: true, Mtu: 1500}}
+	dev.T_top_Prsnt = true
+	dev.T_top.Intf = []yang.T_top_intf{{Name_Prsnt: true, Name: "eth0", Mtu_Prsnt: true, Mtu: 1500}}
+	return dev
+}
+
+// client is the end of a session of a client with the server
+type client struct {
+	t     *testing.T
+	f     *transport.Framer
+	hello *transport.Hello
+	done  chan error
+	msgID int
+}
+
+// Open a session with srv over an in-memory pipe
+func connect(t *testing.T, srv *Server) *client {
+	t.Helper()
+	a, b := net.Pipe()
+	t.Cleanup(func() { a.Close() })
+	c := &client{t: t, f: transport.NewFramer(a, a), done: make(chan error, 1)}
+	go func() { c.done <- srv.ServeConn(b) }()
+	hello, err := transport.Exchange(c.f, &transport.Hello{Capabilities: []string{transport.Base10, transport.Base11}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.hello = hello
+	return c
+}
+
+// Send the operation in an rpc and return the content of the reply
+func (c *client) rpc(op string) string {
+	c.t.Helper()
+	c.msgID++
+	id := fmt.Sprintf(`message-id="%d"`, c.msgID)
+	if err := c.f.WriteMsg([]byte(`<rpc ` + id + ` xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">` + op + `</rpc>`)); err != nil {
+		c.t.Fatal(err)
+	}
+	b, err := c.f.ReadMsg()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	prefix := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" ` + id + `>`
+	reply := string(b)
+	if !strings.HasPrefix(reply, prefix) || !strings.HasSuffix(reply, "</rpc-reply>") {
+		c.t.Fatalf("reply %s to %s", reply, op)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(reply, prefix), "</rpc-reply>")
+}
+
+// The operation must be replied with <ok/>
+func (c *client) ok(op string) {
+	c.t.Helper()
+	if r := c.rpc(op); r != "<ok/>" {
+		c.t.Errorf("reply %s to %s", r, op)
+	}
+}
+
+// The operation must fail with the error-tag passed
+func (c *client) fails(op, tag string) string {
+	c.t.Helper()
+	r := c.rpc(op)
+	if !strings.Contains(r, "<error-tag>"+tag+"</error-tag>") {
+		c.t.Errorf("reply %s to %s, want %s", r, op, tag)
+	}
+	return r
+}
+
+func getConfig(ds nc.Datastore, filter string) string {
+	return `<get-config><source><` + string(ds) + `/></source>` + filter + `</get-config>`
+}
+
+func editConfig(ds nc.Datastore, config string) string {
+	return `<edit-config><target><` + string(ds) + `/></target><config>` + config + `</config></edit-config>`
+}
+
+func TestHello(t *testing.T) {
+	srv := New(testDevice())
+	c1 := connect(t, srv)
+	c2 := connect(t, srv)
+	if c1.hello.SessionID == 0 || c1.hello.SessionID == c2.hello.SessionID {
+		t.Errorf("session-ids %d and %d", c1.hello.SessionID, c2.hello.SessionID)
+	}
+	for _, want := range []string{transport.Base11, transport.CandidateCapability, transport.NotificationCapability, yang.Test_capability,
+		nc.SubscribedNotificationsNs + "?module=ietf-subscribed-notifications"} {
+		if !transport.HasCapability(c1.hello.Capabilities, want) {
+			t.Errorf("no capability %s in %v", want, c1.hello.Capabilities)
+		}
+	}
+	// Both ends support netconf 1.1 and the messages are chunked
+	if !c1.f.Chunked() {
+		t.Errorf("the framing isn't chunked")
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	c := connect(t, New(testDevice()))
+	if err := c.f.WriteMsg([]byte(`<rpc xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><get/></rpc>`)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.f.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<error-tag>missing-attribute</error-tag>") {
+		t.Errorf("reply %s to an rpc without message-id", b)
+	}
+	c.fails(`<frobnicate/>`, "operation-not-supported")
+}
+
+func TestGetConfigFilter(t *testing.T) {
+	c := connect(t, New(testDevice()))
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"", `<data xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><system xmlns="urn:test"><hostname>r1</hostname><mtu>1500</mtu></system>` +
+			`<top xmlns="urn:test"><intf><name>eth0</name><mtu>1500</mtu></intf></top></data>`},
+		{`<filter type="subtree"/>`, `<data></data>`},
+		{`<filter type="subtree"><system xmlns="urn:test"/></filter>`,
+			`<data><system xmlns="urn:test"><hostname>r1</hostname><mtu>1500</mtu></system></data>`},
+		{`<filter type="subtree"><system xmlns="urn:test"><hostname/></system></filter>`,
+			`<data><system xmlns="urn:test"><hostname>r1</hostname></system></data>`},
+		{`<filter type="subtree"><top xmlns="urn:test"><intf><name>eth1</name></intf></top></filter>`, `<data></data>`},
+		{`<filter type="subtree"><system xmlns="urn:other"/></filter>`, `<data></data>`},
+	}
+	for _, tt := range tests {
+		if got := c.rpc(getConfig(nc.Running, tt.filter)); got != tt.want {
+			t.Errorf("get-config with %s = %s, want %s", tt.filter, got, tt.want)
+		}
+	}
+	c.fails(getConfig(nc.Running, `<filter type="xpath" select="/t:system"/>`), "invalid-value")
+	c.fails(getConfig("nowhere", ""), "invalid-value")
+}
+
+func TestEditConfig(t *testing.T) {
+	srv := New(testDevice())
+	c := connect(t, srv)
+	running := func() *yang.Device { return srv.Datastore(nc.Running).(*yang.Device) }
+
+	c.ok(editConfig(nc.Running, `<system xmlns="urn:test"><hostname>r2</hostname></system>`))
+	if dev := running(); dev.T_system.Hostname != "r2" || dev.T_system.Mtu != 1500 {
+		t.Errorf("merge: %+v", dev.T_system)
+	}
+	c.ok(editConfig(nc.Running, `<top xmlns="urn:test"><intf xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="create"><name>eth1</name><mtu>9000</mtu></intf></top>`))
+	if dev := running(); len(dev.T_top.Intf) != 2 || dev.T_top.Intf[1].Name != "eth1" {
+		t.Errorf("create: %+v", dev.T_top)
+	}
+	r := c.fails(editConfig(nc.Running, `<top xmlns="urn:test"><intf xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="create"><name>eth1</name></intf></top>`), "data-exists")
+	if !strings.Contains(r, "<error-path>/test:top/intf[name=&#39;eth1&#39;]</error-path>") {
+		t.Errorf("data-exists: %s", r)
+	}
+	c.ok(editConfig(nc.Running, `<top xmlns="urn:test"><intf xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="delete"><name>eth1</name></intf></top>`))
+	if dev := running(); len(dev.T_top.Intf) != 1 {
+		t.Errorf("delete: %+v", dev.T_top)
+	}
+	r = c.fails(editConfig(nc.Running, `<top xmlns="urn:test"><intf xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="delete"><name>eth1</name></intf></top>`), "data-missing")
+	if !strings.Contains(r, "<error-path>/test:top/intf[name=&#39;eth1&#39;]</error-path>") {
+		t.Errorf("data-missing: %s", r)
+	}
+	// The edit that breaks a constraint leaves running as it was
+	c.fails(editConfig(nc.Running, `<top xmlns="urn:test"><intf><name>eth1</name><mtu>1500</mtu></intf></top>`), "operation-failed")
+	if dev := running(); len(dev.T_top.Intf) != 1 {
+		t.Errorf("invalid edit applied: %+v", dev.T_top)
+	}
+	c.fails(`<edit-config><target><running/></target></edit-config>`, "missing-element")
+	c.fails(editConfig(nc.Startup, `<system xmlns="urn:test"/>`), "invalid-value")
+	// The candidate follows running while it has no changes of its own
+	if dev := srv.Datastore(nc.Candidate).(*yang.Device); dev.T_system.Hostname != "r2" {
+		t.Errorf("candidate: %+v", dev.T_system)
+	}
+}
+
+func TestCandidate(t *testing.T) {
+	srv := New(testDevice())
+	c1 := connect(t, srv)
+	c2 := connect(t, srv)
+	hostname := func(ds nc.Datastore) string { return srv.Datastore(ds).(*yang.Device).T_system.Hostname }
+
+	c1.ok(`<lock><target><candidate/></target></lock>`)
+	r := c2.fails(`<lock><target><candidate/></target></lock>`, "lock-denied")
+	if !strings.Contains(r, fmt.Sprintf("<session-id>%d</session-id>", c1.hello.SessionID)) {
+		t.Errorf("lock-denied: %s", r)
+	}
+	c2.fails(editConfig(nc.Candidate, `<system xmlns="urn:test"><hostname>r3</hostname></system>`), "in-use")
+
+	c1.ok(editConfig(nc.Candidate, `<system xmlns="urn:test"><hostname>r2</hostname></system>`))
+	if hostname(nc.Candidate) != "r2" || hostname(nc.Running) != "r1" {
+		t.Errorf("edit of the candidate: %s, running %s", hostname(nc.Candidate), hostname(nc.Running))
+	}
+	c1.ok(`<discard-changes/>`)
+	if hostname(nc.Candidate) != "r1" {
+		t.Errorf("discard-changes: %s", hostname(nc.Candidate))
+	}
+	c1.ok(editConfig(nc.Candidate, `<system xmlns="urn:test"><hostname>r2</hostname></system>`))
+	c1.ok(`<commit/>`)
+	if hostname(nc.Running) != "r2" {
+		t.Errorf("commit: running %s", hostname(nc.Running))
+	}
+	if got := c2.rpc(getConfig(nc.Running, `<filter><system xmlns="urn:test"><hostname/></system></filter>`)); got != `<data><system xmlns="urn:test"><hostname>r2</hostname></system></data>` {
+		t.Errorf("get-config after the commit: %s", got)
+	}
+	c1.ok(`<unlock><target><candidate/></target></unlock>`)
+	c2.fails(`<unlock><target><candidate/></target></unlock>`, "operation-failed")
+	c2.ok(`<lock><target><candidate/></target></lock>`)
+}
+
+// The changes to the candidate of a session are discarded along with its
+// lock when it closes
+func TestCloseSession(t *testing.T) {
+	srv := New(testDevice())
+	c1 := connect(t, srv)
+	c1.ok(`<lock><target><candidate/></target></lock>`)
+	c1.ok(editConfig(nc.Candidate, `<system xmlns="urn:test"><hostname>r2</hostname></system>`))
+	c1.ok(`<close-session/>`)
+	if err := <-c1.done; err != nil {
+		t.Errorf("ServeConn() = %v", err)
+	}
+	if _, err := c1.f.ReadMsg(); err == nil {
+		t.Errorf("the session is still open")
+	}
+	if dev := srv.Datastore(nc.Candidate).(*yang.Device); dev.T_system.Hostname != "r1" {
+		t.Errorf("candidate: %+v", dev.T_system)
+	}
+	c2 := connect(t, srv)
+	c2.ok(`<lock><target><candidate/></target></lock>`)
+}
+
+func TestKillSession(t *testing.T) {
+	srv := New(testDevice())
+	c1 := connect(t, srv)
+	c2 := connect(t, srv)
+	c1.ok(`<lock><target><running/></target></lock>`)
+	c2.fails(`<kill-session><session-id>`+fmt.Sprint(c2.hello.SessionID)+`</session-id></kill-session>`, "invalid-value")
+	c2.ok(`<kill-session><session-id>` + fmt.Sprint(c1.hello.SessionID) + `</session-id></kill-session>`)
+	<-c1.done
+	c2.ok(`<lock><target><running/></target></lock>`)
+}
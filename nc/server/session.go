@@ -0,0 +1,541 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"nc/nc"
+	"nc/transport"
+)
+
+// Session is a session of a client with the server
+type Session struct {
+	srv  *Server
+	id   uint64
+	rw   io.ReadWriter
+	f    *transport.Framer
+	caps []string
+
+	subscribed    bool
+	filter        *node            // of the notifications
+	subscriptions map[uint32]*node // dynamic ones with their filter
+}
+
+// ID returns the session-id
+func (s *Session) ID() uint64 {
+	return s.id
+}
+
+// Capabilities returns the capabilities of the client
+func (s *Session) Capabilities() []string {
+	return s.caps
+}
+
+// The parameters of the operations of the base protocol
+type params struct {
+	source       nc.Datastore
+	target       nc.Datastore
+	filter       *node
+	config       interface{}
+	defaultOp    nc.Operation
+	testOption   string
+	withDefaults *nc.WithDefaults
+	sessionID    uint64
+}
+
+func rpcError(typ, tag, msg string) *nc.RPCError {
+	return &nc.RPCError{Type: typ, Tag: tag, Severity: "error", Message: msg}
+}
+
+// Handle an rpc and return the reply. It returns true when the session is
+// to be closed.
+func (s *Session) handle(msg []byte) ([]byte, bool) {
+	d := nc.NewDecoder(bytes.NewReader(msg))
+	rpc, err := nextStart(d)
+	if err != nil || rpc == nil || rpc.Name.Space != nc.NetconfNs || rpc.Name.Local != "rpc" {
+		return reply(nil, nil, rpcError(nc.ErrRpc, "malformed-message", "not an rpc")), false
+	}
+	if attrValue(rpc.Attr, "message-id") == "" {
+		e := rpcError(nc.ErrRpc, "missing-attribute", "no message-id")
+		e.Info = &nc.ErrorInfo{Content: "<bad-attribute>message-id</bad-attribute><bad-element>rpc</bad-element>"}
+		return reply(rpc.Attr, nil, e), false
+	}
+	op, err := nextStart(d)
+	if err != nil || op == nil {
+		return reply(rpc.Attr, nil, rpcError(nc.ErrRpc, "missing-element", "no operation")), false
+	}
+	body, err := s.dispatch(d, op)
+	if err != nil {
+		return reply(rpc.Attr, nil, err), false
+	}
+	closing := op.Name.Space == nc.NetconfNs && op.Name.Local == "close-session"
+	return reply(rpc.Attr, body, nil), closing
+}
+
+// The next element within the current one, nil at its end
+func nextStart(d *nc.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+func attrValue(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// The reply carries the attributes of the rpc. The body is <ok/> when it
+// is empty.
+func reply(attrs []xml.Attr, body []byte, err error) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<rpc-reply xmlns="` + nc.NetconfNs + `"`)
+	for _, a := range attrs {
+		if a.Name.Space == "" && a.Name.Local != "xmlns" {
+			b.WriteString(" " + a.Name.Local + `="`)
+			xml.EscapeText(&b, []byte(a.Value))
+			b.WriteString(`"`)
+		}
+	}
+	b.WriteString(">")
+	switch {
+	case err != nil:
+		for _, e := range RPCErrors(err) {
+			x, _ := xml.Marshal(e)
+			b.Write(x)
+		}
+	case len(body) == 0:
+		b.WriteString("<ok/>")
+	default:
+		b.Write(body)
+	}
+	b.WriteString("</rpc-reply>")
+	return b.Bytes()
+}
+
+// RPCErrors returns the rpc-errors replied for an error. The violations
+// of the constraints of the schema are reported as operation-failed.
+func RPCErrors(err error) nc.RPCErrors {
+	switch e := err.(type) {
+	case *nc.RPCError:
+		return nc.RPCErrors{e}
+	case nc.RPCErrors:
+		return e
+	case nc.ValidationErrors:
+		var errs nc.RPCErrors
+		for _, v := range e {
+			errs = append(errs, RPCErrors(v)...)
+		}
+		return errs
+	case *nc.ValidationError:
+		r := rpcError(nc.ErrApplication, "operation-failed", e.Message)
+		r.AppTag, r.Path = e.AppTag, e.Path
+		return nc.RPCErrors{r}
+	}
+	return nc.RPCErrors{rpcError(nc.ErrApplication, "operation-failed", err.Error())}
+}
+
+func (s *Session) dispatch(d *nc.Decoder, op *xml.StartElement) ([]byte, error) {
+	if op.Name.Space == nc.YangNs && op.Name.Local == "action" {
+		return s.action(d, op)
+	}
+	if op.Name.Space == nc.NotificationNs && op.Name.Local == "create-subscription" {
+		return nil, s.createSubscription(d)
+	}
+	if op.Name.Space == nc.SubscribedNotificationsNs {
+		switch op.Name.Local {
+		case "establish-subscription":
+			return s.establishSubscription(d)
+		case "delete-subscription":
+			return nil, s.deleteSubscription(d)
+		}
+	}
+	if op.Name.Space != nc.NetconfNs {
+		return s.call(d, op)
+	}
+	p, err := s.readParams(d)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Name.Local {
+	case "get":
+		return s.get(p, true)
+	case "get-config":
+		return s.get(p, false)
+	case "edit-config":
+		return nil, s.editConfig(p)
+	case "lock":
+		return nil, s.lock(p.target)
+	case "unlock":
+		return nil, s.unlock(p.target)
+	case "commit":
+		return nil, s.commit()
+	case "discard-changes":
+		return nil, s.discardChanges()
+	case "validate":
+		return nil, s.validate(p.source)
+	case "close-session":
+		return nil, nil
+	case "kill-session":
+		return nil, s.killSession(p.sessionID)
+	}
+	return nil, rpcError(nc.ErrProtocol, "operation-not-supported", op.Name.Local+" isn't supported")
+}
+
+// Read the parameters of an operation of the base protocol
+func (s *Session) readParams(d *nc.Decoder) (*params, error) {
+	p := &params{}
+	for {
+		t, err := nextStart(d)
+		if err != nil {
+			return nil, rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		if t == nil {
+			return p, nil
+		}
+		if t.Name.Local == "config" {
+			v := reflect.New(s.srv.typ).Interface()
+			if err := d.DecodeElement(v, t); err != nil {
+				return nil, rpcError(nc.ErrApplication, "invalid-value", err.Error())
+			}
+			p.config = v
+			continue
+		}
+		n, err := readNode(d, *t)
+		if err != nil {
+			return nil, rpcError(nc.ErrRpc, "malformed-message", err.Error())
+		}
+		text := strings.TrimSpace(n.text)
+		switch n.name.Local {
+		case "source", "target":
+			if len(n.children) != 1 {
+				return nil, rpcError(nc.ErrProtocol, "invalid-value", "no datastore in "+n.name.Local)
+			}
+			ds := nc.Datastore(n.children[0].name.Local)
+			if n.name.Local == "source" {
+				p.source = ds
+			} else {
+				p.target = ds
+			}
+		case "filter":
+			if typ := attrValue(n.attrs, "type"); typ != "" && typ != "subtree" {
+				return nil, rpcError(nc.ErrProtocol, "invalid-value", "filter of type "+typ+" isn't supported")
+			}
+			p.filter = n
+		case "default-operation":
+			switch op := nc.Operation(text); op {
+			case nc.OpMerge, nc.OpReplace, nc.OpNoop:
+				p.defaultOp = op
+			default:
+				return nil, rpcError(nc.ErrProtocol, "invalid-value", "default-operation "+text)
+			}
+		case "test-option":
+			p.testOption = text
+		case "with-defaults":
+			mode, err := nc.ParseWithDefaults(text)
+			if err != nil {
+				return nil, rpcError(nc.ErrProtocol, "invalid-value", err.Error())
+			}
+			p.withDefaults = &mode
+		case "session-id":
+			id, err := strconv.ParseUint(text, 10, 64)
+			if err != nil {
+				return nil, rpcError(nc.ErrProtocol, "invalid-value", "session-id "+text)
+			}
+			p.sessionID = id
+		}
+	}
+}
+
+// The datastore must be one of the server
+func (s *Session) checkDatastore(ds nc.Datastore) error {
+	if _, ok := s.srv.stores[ds]; !ok {
+		return rpcError(nc.ErrProtocol, "invalid-value", "unknown datastore "+string(ds))
+	}
+	return nil
+}
+
+// The datastore can't be changed while another session has it locked
+func (s *Session) checkLock(ds nc.Datastore) error {
+	if holder := s.srv.locks[ds]; holder != nil && holder != s {
+		return rpcError(nc.ErrProtocol, "in-use", string(ds)+" is locked by session "+strconv.FormatUint(holder.id, 10))
+	}
+	return nil
+}
+
+func (s *Session) get(p *params, state bool) ([]byte, error) {
+	srv := s.srv
+	srv.mu.Lock()
+	source := p.source
+	if state {
+		source = nc.Running
+	}
+	if err := s.checkDatastore(source); err != nil {
+		srv.mu.Unlock()
+		return nil, err
+	}
+	var data interface{}
+	var err error
+	if state {
+		data, err = srv.get()
+	} else {
+		data = nc.Clone(srv.stores[source])
+	}
+	srv.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	e := nc.NewEncoder(&b)
+	if p.withDefaults != nil {
+		e.SetWithDefaults(*p.withDefaults)
+	}
+	if err := e.EncodeElement(data, nc.NetconfNs, "data"); err != nil {
+		return nil, err
+	}
+	if p.filter == nil {
+		return b.Bytes(), nil
+	}
+	root, err := parseNode(b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.WriteString("<data>")
+	if len(p.filter.children) > 0 {
+		selected, _ := selectChildren(root, p.filter.children)
+		for _, n := range selected {
+			n.write(&out, nc.NetconfNs)
+		}
+	}
+	out.WriteString("</data>")
+	return out.Bytes(), nil
+}
+
+func (s *Session) editConfig(p *params) error {
+	if p.config == nil {
+		e := rpcError(nc.ErrProtocol, "missing-element", "no config")
+		e.Info = &nc.ErrorInfo{Content: "<bad-element>config</bad-element>"}
+		return e
+	}
+	if p.target != nc.Running && p.target != nc.Candidate {
+		return rpcError(nc.ErrProtocol, "invalid-value", "can't edit "+string(p.target))
+	}
+	srv := s.srv
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := s.checkLock(p.target); err != nil {
+		return err
+	}
+	v := nc.Clone(srv.stores[p.target])
+	if err := nc.Edit(v, p.config, p.defaultOp); err != nil {
+		return err
+	}
+	// The candidate is validated when it is committed
+	if p.target == nc.Running && p.testOption != "set" {
+		if err := validate(v); err != nil {
+			return err
+		}
+	}
+	if p.testOption == "test-only" {
+		return nil
+	}
+	srv.stores[p.target] = v
+	switch {
+	case p.target == nc.Candidate:
+		srv.dirty = true
+	case !srv.dirty:
+		srv.stores[nc.Candidate] = nc.Clone(v)
+	}
+	if p.target == nc.Running {
+		srv.changed()
+	}
+	return nil
+}
+
+func validate(v interface{}) error {
+	if val, ok := v.(nc.Validator); ok {
+		return val.Validate()
+	}
+	return nil
+}
+
+func (s *Session) lock(ds nc.Datastore) error {
+	srv := s.srv
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := s.checkDatastore(ds); err != nil {
+		return err
+	}
+	if holder := srv.locks[ds]; holder != nil {
+		e := rpcError(nc.ErrProtocol, "lock-denied", string(ds)+" is already locked")
+		e.Info = &nc.ErrorInfo{Content: fmt.Sprintf("<session-id>%d</session-id>", holder.id)}
+		return e
+	}
+	if ds == nc.Candidate && srv.dirty {
+		e := rpcError(nc.ErrProtocol, "lock-denied", "the candidate has changes not committed")
+		e.Info = &nc.ErrorInfo{Content: "<session-id>0</session-id>"}
+		return e
+	}
+	srv.locks[ds] = s
+	return nil
+}
+
+func (s *Session) unlock(ds nc.Datastore) error {
+	srv := s.srv
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := s.checkDatastore(ds); err != nil {
+		return err
+	}
+	if srv.locks[ds] != s {
+		return rpcError(nc.ErrProtocol, "operation-failed", string(ds)+" isn't locked by the session")
+	}
+	delete(srv.locks, ds)
+	return nil
+}
+
+func (s *Session) commit() error {
+	srv := s.srv
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := s.checkLock(nc.Running); err != nil {
+		return err
+	}
+	candidate := srv.stores[nc.Candidate]
+	if err := validate(candidate); err != nil {
+		return err
+	}
+	srv.stores[nc.Running] = nc.Clone(candidate)
+	srv.dirty = false
+	srv.changed()
+	return nil
+}
+
+func (s *Session) discardChanges() error {
+	srv := s.srv
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := s.checkLock(nc.Candidate); err != nil {
+		return err
+	}
+	srv.stores[nc.Candidate] = nc.Clone(srv.stores[nc.Running])
+	srv.dirty = false
+	return nil
+}
+
+func (s *Session) validate(ds nc.Datastore) error {
+	srv := s.srv
+	srv.mu.Lock()
+	if err := s.checkDatastore(ds); err != nil {
+		srv.mu.Unlock()
+		return err
+	}
+	v := nc.Clone(srv.stores[ds])
+	srv.mu.Unlock()
+	return validate(v)
+}
+
+// Closing the stream of the session ends it and releases its locks
+func (s *Session) killSession(id uint64) error {
+	if id == s.id {
+		return rpcError(nc.ErrProtocol, "invalid-value", "can't kill the own session")
+	}
+	s.srv.mu.Lock()
+	other := s.srv.sessions[id]
+	s.srv.mu.Unlock()
+	if other == nil {
+		return rpcError(nc.ErrProtocol, "invalid-value", "no session "+strconv.FormatUint(id, 10))
+	}
+	c, ok := other.rw.(io.Closer)
+	if !ok {
+		return rpcError(nc.ErrApplication, "operation-failed", "the session can't be closed")
+	}
+	return c.Close()
+}
+
+// Call the handler of an rpc of a module
+func (s *Session) call(d *nc.Decoder, op *xml.StartElement) ([]byte, error) {
+	s.srv.mu.Lock()
+	h, ok := s.srv.handlers[op.Name]
+	s.srv.mu.Unlock()
+	if !ok {
+		return nil, rpcError(nc.ErrProtocol, "operation-not-supported", op.Name.Local+" isn't supported")
+	}
+	in := reflect.New(h.typ).Interface()
+	if err := d.DecodeElement(in, op); err != nil {
+		return nil, rpcError(nc.ErrProtocol, "invalid-value", err.Error())
+	}
+	return s.invoke(h, &Request{Session: s, Input: in})
+}
+
+func (s *Session) invoke(h handler, r *Request) ([]byte, error) {
+	out, err := h.h(r)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	return nc.MarshalOutput(out)
+}
+
+// Call the handler of an action. The elements down to the action are the
+// path of the data node and their leaves are the keys of the entries.
+func (s *Session) action(d *nc.Decoder, op *xml.StartElement) ([]byte, error) {
+	n, err := readNode(d, *op)
+	if err != nil {
+		return nil, rpcError(nc.ErrRpc, "malformed-message", err.Error())
+	}
+	var path nc.Path
+	for {
+		var next, input *node
+		var h handler
+		var keys []nc.KeyValue
+		for _, c := range n.children {
+			s.srv.mu.Lock()
+			ch, ok := s.srv.handlers[c.name]
+			s.srv.mu.Unlock()
+			switch {
+			case ok && path != nil:
+				input, h = c, ch
+			case len(c.children) > 0:
+				next = c
+			default:
+				keys = append(keys, nc.KeyValue{Name: c.name.Local, Value: strings.TrimSpace(c.text)})
+			}
+		}
+		if len(path) > 0 {
+			path[len(path)-1].Keys = keys
+		}
+		if input != nil {
+			var b bytes.Buffer
+			input.write(&b, "")
+			in := reflect.New(h.typ).Interface()
+			if err := nc.Unmarshal(b.Bytes(), in); err != nil {
+				return nil, rpcError(nc.ErrProtocol, "invalid-value", err.Error())
+			}
+			return s.invoke(h, &Request{Session: s, Input: in, Path: path})
+		}
+		if next == nil {
+			return nil, rpcError(nc.ErrProtocol, "operation-not-supported", "no action found")
+		}
+		path = append(path, nc.PathElem{Name: next.name.Local, Namespace: next.name.Space})
+		n = next
+	}
+}
@@ -0,0 +1,193 @@
+// Package transport carries the messages of netconf over a stream. It
+// implements the end-of-message framing of netconf 1.0 and the chunked
+// framing of RFC 6242 along with the exchange of hello messages.
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// The delimiter of the messages of netconf 1.0
+const endOfMessage = "]]>]]>"
+
+// The largest chunk of RFC 6242
+const maxChunkSize = 4294967295
+
+// ErrFraming is returned when the framing of a message is invalid
+var ErrFraming = errors.New("transport: invalid framing")
+
+// ErrMsgTooLarge is returned when a message exceeds the maximum size set
+// by SetMaxMsgSize. The session can't be read further.
+var ErrMsgTooLarge = errors.New("transport: message too large")
+
+// Framer reads and writes the messages of a session. The framing starts
+// with the end-of-message delimiter used for the hellos and switches to
+// the chunked framing when both ends support netconf 1.1. A message may
+// be written while another is read.
+type Framer struct {
+	r       *bufio.Reader
+	w       io.Writer
+	wmu     sync.Mutex
+	chunked bool
+	maxSize int64
+}
+
+// NewFramer returns a framer that reads from r and writes to w
+func NewFramer(r io.Reader, w io.Writer) *Framer {
+	return &Framer{r: bufio.NewReader(r), w: w}
+}
+
+// SetChunked switches to the chunked framing
+func (f *Framer) SetChunked() {
+	f.wmu.Lock()
+	defer f.wmu.Unlock()
+	f.chunked = true
+}
+
+// SetMaxMsgSize sets the maximum size of the messages read, 0 for none
+// which is the default. A larger message fails with ErrMsgTooLarge.
+func (f *Framer) SetMaxMsgSize(n int64) {
+	f.wmu.Lock()
+	defer f.wmu.Unlock()
+	f.maxSize = n
+}
+
+// Chunked tells whether the chunked framing is in use
+func (f *Framer) Chunked() bool {
+	f.wmu.Lock()
+	defer f.wmu.Unlock()
+	return f.chunked
+}
+
+// WriteMsg writes a message
+func (f *Framer) WriteMsg(msg []byte) error {
+	f.wmu.Lock()
+	defer f.wmu.Unlock()
+	var b bytes.Buffer
+	if f.chunked {
+		if len(msg) > 0 {
+			fmt.Fprintf(&b, "\n#%d\n", len(msg))
+			b.Write(msg)
+		}
+		b.WriteString("\n##\n")
+	} else {
+		b.Write(msg)
+		b.WriteString(endOfMessage)
+	}
+	_, err := f.w.Write(b.Bytes())
+	return err
+}
+
+// ReadMsg reads the next message. The reads are not safe for concurrent
+// use.
+func (f *Framer) ReadMsg() ([]byte, error) {
+	f.wmu.Lock()
+	chunked, maxSize := f.chunked, f.maxSize
+	f.wmu.Unlock()
+	if chunked {
+		return f.readChunked(maxSize)
+	}
+	return f.readEOM(maxSize)
+}
+
+func (f *Framer) readEOM(maxSize int64) ([]byte, error) {
+	var msg []byte
+	for {
+		if maxSize > 0 && int64(len(msg)) > maxSize+int64(len(endOfMessage)) {
+			return nil, ErrMsgTooLarge
+		}
+		b, err := f.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(bytes.TrimSpace(msg)) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		msg = append(msg, b)
+		if b == '>' && bytes.HasSuffix(msg, []byte(endOfMessage)) {
+			msg = msg[:len(msg)-len(endOfMessage)]
+			if maxSize > 0 && int64(len(msg)) > maxSize {
+				return nil, ErrMsgTooLarge
+			}
+			return msg, nil
+		}
+	}
+}
+
+// The chunks are "\n#size\n" followed by the data of that size and the
+// message ends with "\n##\n". The data is read as it arrives rather than
+// allocated from the size announced.
+func (f *Framer) readChunked(maxSize int64) ([]byte, error) {
+	var msg bytes.Buffer
+	for {
+		if err := f.expect('\n'); err != nil {
+			return nil, err
+		}
+		if err := f.expect('#'); err != nil {
+			return nil, err
+		}
+		b, err := f.r.ReadByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if b == '#' {
+			if err := f.expect('\n'); err != nil {
+				return nil, err
+			}
+			return msg.Bytes(), nil
+		}
+		size, err := f.readSize(b)
+		if err != nil {
+			return nil, err
+		}
+		if maxSize > 0 && int64(msg.Len())+size > maxSize {
+			return nil, ErrMsgTooLarge
+		}
+		if _, err := io.CopyN(&msg, f.r, size); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+}
+
+// Read the size of a chunk from its first digit b. The size has no
+// leading zero, is at most maxChunkSize and ends with "\n".
+func (f *Framer) readSize(b byte) (int64, error) {
+	var size int64
+	for n := 0; b != '\n'; n++ {
+		if b < '0' || b > '9' || (n == 0 && b == '0') || size > maxChunkSize {
+			return 0, ErrFraming
+		}
+		size = size*10 + int64(b-'0')
+		var err error
+		if b, err = f.r.ReadByte(); err != nil {
+			return 0, unexpectedEOF(err)
+		}
+	}
+	if size == 0 || size > maxChunkSize {
+		return 0, ErrFraming
+	}
+	return size, nil
+}
+
+func (f *Framer) expect(c byte) error {
+	b, err := f.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != c {
+		return ErrFraming
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// A framer that reads the input passed
+func readerOf(s string) *Framer {
+	return NewFramer(strings.NewReader(s), io.Discard)
+}
+
+// Two framers connected by an in-memory pipe
+func pipe(t *testing.T) (*Framer, *Framer) {
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return NewFramer(a, a), NewFramer(b, b)
+}
+
+func TestPipe(t *testing.T) {
+	msgs := []string{"<hello/>", "", "a]]>b", strings.Repeat("<x/>", 10000), "\n##\n"}
+	for _, chunked := range []bool{false, true} {
+		w, r := pipe(t)
+		if chunked {
+			w.SetChunked()
+			r.SetChunked()
+		}
+		go func() {
+			for _, m := range msgs {
+				if !chunked && strings.Contains(m, endOfMessage[:3]) {
+					continue
+				}
+				if err := w.WriteMsg([]byte(m)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		for _, m := range msgs {
+			if !chunked && strings.Contains(m, endOfMessage[:3]) {
+				continue
+			}
+			got, err := r.ReadMsg()
+			if err != nil {
+				t.Fatalf("chunked %v: %v", chunked, err)
+			}
+			if string(got) != m {
+				t.Errorf("chunked %v: read %.40q, want %.40q", chunked, got, m)
+			}
+		}
+	}
+}
+
+func TestReadEOM(t *testing.T) {
+	f := readerOf("<a/>]]>]]>\n<b>]]</b>]]>]]>")
+	for _, want := range []string{"<a/>", "\n<b>]]</b>"} {
+		got, err := f.ReadMsg()
+		if err != nil || string(got) != want {
+			t.Fatalf("ReadMsg() = %q, %v, want %q", got, err, want)
+		}
+	}
+	if _, err := f.ReadMsg(); err != io.EOF {
+		t.Errorf("ReadMsg() at the end = %v, want EOF", err)
+	}
+	if _, err := readerOf("<a/>]]>]]").ReadMsg(); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadMsg() of a truncated message = %v, want unexpected EOF", err)
+	}
+	if _, err := readerOf("\n  \n").ReadMsg(); err != io.EOF {
+		t.Errorf("ReadMsg() of spaces = %v, want EOF", err)
+	}
+}
+
+func TestReadChunked(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{"\n#4\nabcd\n##\n", "abcd", nil},
+		{"\n#4\nabcd\n#3\nefg\n#1\n\n\n##\n", "abcdefg\n", nil},
+		{"\n#10\n0123456789\n##\n", "0123456789", nil},
+		{"", "", io.EOF},
+		{"\n#0\n", "", ErrFraming},
+		{"\n#01\na\n##\n", "", ErrFraming},
+		{"\n#\nabcd\n##\n", "", ErrFraming},
+		{"\n#4abcd\n##\n", "", ErrFraming},
+		{"\n#4 \nabcd\n##\n", "", ErrFraming},
+		{"\n# 4\nabcd\n##\n", "", ErrFraming},
+		{"\n#-1\na\n##\n", "", ErrFraming},
+		{"\n#+1\na\n##\n", "", ErrFraming},
+		{"\n#4294967296\n", "", ErrFraming},
+		{"\n#99999999999999999999999\n", "", ErrFraming},
+		{"#4\nabcd\n##\n", "", ErrFraming},
+		{"\n\n#4\nabcd\n##\n", "", ErrFraming},
+		{"\n#4\nabcd\n#", "", io.ErrUnexpectedEOF},
+		{"\n#4\nabcd\n##x", "", ErrFraming},
+		{"\n#4\nabcd#\n##\n", "", ErrFraming},
+		{"\n#4\nab", "", io.ErrUnexpectedEOF},
+		{"\n#4", "", io.ErrUnexpectedEOF},
+		{"\n#4294967295\nabcd", "", io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		f := readerOf(tt.in)
+		f.SetChunked()
+		got, err := f.ReadMsg()
+		if err != tt.err || string(got) != tt.want {
+			t.Errorf("ReadMsg(%q) = %q, %v, want %q, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// The size announced by a chunk isn't allocated before the data arrives
+func TestChunkAllocation(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	f := readerOf("\n#4294967295\n" + strings.Repeat("a", 1000))
+	f.SetChunked()
+	if _, err := f.ReadMsg(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadMsg() = %v, want unexpected EOF", err)
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("%d bytes allocated for 1000 bytes of data", n)
+	}
+}
+
+func TestMaxMsgSize(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		chunked bool
+		err     error
+	}{
+		{"12345678]]>]]>", false, nil},
+		{"123456789]]>]]>", false, ErrMsgTooLarge},
+		{strings.Repeat("a", 100), false, ErrMsgTooLarge},
+		{"\n#8\n12345678\n##\n", true, nil},
+		{"\n#4\n1234\n#4\n5678\n##\n", true, nil},
+		{"\n#9\n123456789\n##\n", true, ErrMsgTooLarge},
+		{"\n#4\n1234\n#5\n56789\n##\n", true, ErrMsgTooLarge},
+		{"\n#4294967295\n", true, ErrMsgTooLarge},
+	} {
+		f := readerOf(tt.in)
+		f.SetMaxMsgSize(8)
+		if tt.chunked {
+			f.SetChunked()
+		}
+		if _, err := f.ReadMsg(); err != tt.err {
+			t.Errorf("ReadMsg(%q) = %v, want %v", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestWriteMsg(t *testing.T) {
+	var b bytes.Buffer
+	f := NewFramer(strings.NewReader(""), &b)
+	f.WriteMsg([]byte("<a/>"))
+	f.SetChunked()
+	f.WriteMsg([]byte("<b/>"))
+	f.WriteMsg(nil)
+	if want := "<a/>]]>]]>\n#4\n<b/>\n##\n\n##\n"; b.String() != want {
+		t.Errorf("written %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// The capabilities of the base protocol
+const (
+	Base10 = "urn:ietf:params:netconf:base:1.0"
+	Base11 = "urn:ietf:params:netconf:base:1.1"
+)
+
+// The capabilities of RFC 6241 and of the extensions of the protocol
+const (
+	WritableRunningCapability = "urn:ietf:params:netconf:capability:writable-running:1.0"
+	CandidateCapability       = "urn:ietf:params:netconf:capability:candidate:1.0"
+	ConfirmedCommitCapability = "urn:ietf:params:netconf:capability:confirmed-commit:1.1"
+	ValidateCapability        = "urn:ietf:params:netconf:capability:validate:1.1"
+	StartupCapability         = "urn:ietf:params:netconf:capability:startup:1.0"
+	XPathCapability           = "urn:ietf:params:netconf:capability:xpath:1.0"
+	WithDefaultsCapability    = "urn:ietf:params:netconf:capability:with-defaults:1.0"
+	NotificationCapability    = "urn:ietf:params:netconf:capability:notification:1.0"
+	InterleaveCapability      = "urn:ietf:params:netconf:capability:interleave:1.0"
+)
+
+// Hello is the message each end sends when the session starts. The server
+// assigns the session-id.
+type Hello struct {
+	XMLName      xml.Name `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 hello"`
+	Capabilities []string `xml:"capabilities>capability"`
+	SessionID    uint64   `xml:"session-id,omitempty"`
+}
+
+// Exchange sends the hello and receives the one of the other end. The
+// framing switches to chunked when both ends support netconf 1.1.
+func Exchange(f *Framer, hello *Hello) (*Hello, error) {
+	b, err := xml.Marshal(hello)
+	if err != nil {
+		return nil, err
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- f.WriteMsg(append([]byte(xml.Header), b...))
+	}()
+	msg, err := f.ReadMsg()
+	if werr := <-errc; err == nil {
+		err = werr
+	}
+	if err != nil {
+		return nil, err
+	}
+	peer := &Hello{}
+	if err := xml.Unmarshal(msg, peer); err != nil {
+		return nil, fmt.Errorf("transport: invalid hello: %v", err)
+	}
+	if !HasCapability(peer.Capabilities, Base10) && !HasCapability(peer.Capabilities, Base11) {
+		return nil, fmt.Errorf("transport: no common base capability")
+	}
+	if HasCapability(hello.Capabilities, Base11) && HasCapability(peer.Capabilities, Base11) {
+		f.SetChunked()
+	}
+	return peer, nil
+}
+
+// HasCapability tells whether the capability is among caps. A capability
+// without parameters matches the one with the same URI whatever its
+// parameters. The capabilities of modules such as the variables
+// <module>_capability of the generated code match when they have the same
+// namespace and module and the revision, if given, is the same. The other
+// parameters are ignored.
+func HasCapability(caps []string, capability string) bool {
+	uri, query, _ := strings.Cut(capability, "?")
+	want, _ := url.ParseQuery(query)
+	for _, c := range caps {
+		c = strings.TrimSpace(c)
+		if c == capability {
+			return true
+		}
+		curi, cquery, _ := strings.Cut(c, "?")
+		if curi != uri {
+			continue
+		}
+		if query == "" {
+			return true
+		}
+		if want.Get("module") == "" {
+			continue
+		}
+		have, _ := url.ParseQuery(cquery)
+		if have.Get("module") != want.Get("module") {
+			continue
+		}
+		if rev := want.Get("revision"); rev == "" || rev == have.Get("revision") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,616 @@
+package xpath
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The values of XPath 1.0 are a node-set, a string, a number and a
+// boolean. The node-sets are kept in document order without duplicates.
+
+type nodeSet []Node
+
+type expr interface {
+	eval(c *context) (interface{}, error)
+}
+
+type context struct {
+	node    Node
+	pos     int
+	size    int
+	env     *Env
+	current Node
+	order   *docOrder
+}
+
+// The context for a node within a node-set of size n
+func (c *context) with(node Node, pos int, size int) *context {
+	c2 := *c
+	c2.node, c2.pos, c2.size = node, pos, size
+	return &c2
+}
+
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case nodeSet:
+		if len(x) == 0 {
+			return ""
+		}
+		return StringValue(x[0])
+	case string:
+		return x
+	case float64:
+		return numberString(x)
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
+
+func numberString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func stringNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	// Only the decimal notation with an optional minus sign is a number
+	digits := strings.TrimPrefix(s, "-")
+	if strings.Trim(digits, "0123456789.") != "" || strings.Count(digits, ".") > 1 ||
+		strings.Trim(digits, ".") == "" {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+func toNumber(v interface{}) float64 {
+	switch x := v.(type) {
+	case float64:
+		return x
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	}
+	return stringNumber(toString(v))
+}
+
+func toBool(v interface{}) bool {
+	switch x := v.(type) {
+	case nodeSet:
+		return len(x) > 0
+	case []Node:
+		return len(x) > 0
+	case string:
+		return x != ""
+	case float64:
+		return x != 0 && !math.IsNaN(x)
+	case bool:
+		return x
+	}
+	return false
+}
+
+// The expressions
+
+type literalExpr struct {
+	s string
+}
+
+func (e *literalExpr) eval(c *context) (interface{}, error) {
+	return e.s, nil
+}
+
+type numberExpr struct {
+	f float64
+}
+
+func (e *numberExpr) eval(c *context) (interface{}, error) {
+	return e.f, nil
+}
+
+type negExpr struct {
+	e expr
+}
+
+func (e *negExpr) eval(c *context) (interface{}, error) {
+	v, err := e.e.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	return -toNumber(v), nil
+}
+
+type binaryExpr struct {
+	op   string
+	l, r expr
+}
+
+func (e *binaryExpr) eval(c *context) (interface{}, error) {
+	l, err := e.l.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	// and and or don't evaluate the right operand when the left one
+	// decides the result
+	switch e.op {
+	case "and":
+		if !toBool(l) {
+			return false, nil
+		}
+	case "or":
+		if toBool(l) {
+			return true, nil
+		}
+	}
+	r, err := e.r.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "and", "or":
+		return toBool(r), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compare(e.op, l, r), nil
+	}
+	a, b := toNumber(l), toNumber(r)
+	switch e.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "div":
+		return a / b, nil
+	}
+	return math.Mod(a, b), nil
+}
+
+// The comparisons of section 3.4 of XPath 1.0. A comparison with a
+// node-set is true when it is true for one of its nodes.
+func compare(op string, l interface{}, r interface{}) bool {
+	ln, lok := l.(nodeSet)
+	rn, rok := r.(nodeSet)
+	switch {
+	case lok && rok:
+		for _, a := range ln {
+			sa := StringValue(a)
+			for _, b := range rn {
+				if compareAtoms(op, sa, StringValue(b)) {
+					return true
+				}
+			}
+		}
+		return false
+	case lok || rok:
+		nodes, other, swapped := ln, r, false
+		if rok {
+			nodes, other, swapped = rn, l, true
+		}
+		if b, ok := other.(bool); ok {
+			if swapped {
+				return compareAtoms(op, b, len(nodes) > 0)
+			}
+			return compareAtoms(op, len(nodes) > 0, b)
+		}
+		for _, n := range nodes {
+			var a interface{} = StringValue(n)
+			if f, ok := other.(float64); ok {
+				a = stringNumber(a.(string))
+				if swapped {
+					if compareAtoms(op, f, a) {
+						return true
+					}
+				} else if compareAtoms(op, a, f) {
+					return true
+				}
+				continue
+			}
+			if swapped {
+				if compareAtoms(op, other, a) {
+					return true
+				}
+			} else if compareAtoms(op, a, other) {
+				return true
+			}
+		}
+		return false
+	}
+	return compareAtoms(op, l, r)
+}
+
+func compareAtoms(op string, l interface{}, r interface{}) bool {
+	switch op {
+	case "=", "!=":
+		var eq bool
+		_, lb := l.(bool)
+		_, rb := r.(bool)
+		_, lf := l.(float64)
+		_, rf := r.(float64)
+		switch {
+		case lb || rb:
+			eq = toBool(l) == toBool(r)
+		case lf || rf:
+			eq = toNumber(l) == toNumber(r)
+		default:
+			eq = toString(l) == toString(r)
+		}
+		return eq == (op == "=")
+	}
+	a, b := toNumber(l), toNumber(r)
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	}
+	return a >= b
+}
+
+type unionExpr struct {
+	l, r expr
+}
+
+func (e *unionExpr) eval(c *context) (interface{}, error) {
+	l, err := evalNodes(c, e.l)
+	if err != nil {
+		return nil, err
+	}
+	r, err := evalNodes(c, e.r)
+	if err != nil {
+		return nil, err
+	}
+	return c.order.sort(append(append(nodeSet{}, l...), r...)), nil
+}
+
+func evalNodes(c *context, e expr) (nodeSet, error) {
+	v, err := e.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.(nodeSet)
+	if !ok {
+		return nil, errorf("a node-set is expected instead of %s", toString(v))
+	}
+	return ns, nil
+}
+
+type filterExpr struct {
+	primary expr
+	preds   []expr
+}
+
+func (e *filterExpr) eval(c *context) (interface{}, error) {
+	ns, err := evalNodes(c, e.primary)
+	if err != nil {
+		return nil, err
+	}
+	for _, pred := range e.preds {
+		if ns, err = filter(c, ns, pred); err != nil {
+			return nil, err
+		}
+	}
+	return ns, nil
+}
+
+// Keep the nodes for which the predicate is true. A number is true when
+// it is the position of the node.
+func filter(c *context, ns nodeSet, pred expr) (nodeSet, error) {
+	var res nodeSet
+	for i, n := range ns {
+		v, err := pred.eval(c.with(n, i+1, len(ns)))
+		if err != nil {
+			return nil, err
+		}
+		keep := false
+		if f, ok := v.(float64); ok {
+			keep = f == float64(i+1)
+		} else {
+			keep = toBool(v)
+		}
+		if keep {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+type funcExpr struct {
+	name string
+	fn   function
+	args []expr
+}
+
+func (e *funcExpr) eval(c *context) (interface{}, error) {
+	return e.fn.fn(c, e.args)
+}
+
+// The location paths
+
+type testKind int
+
+const (
+	testName   testKind = iota // prefix:name or name
+	testPrefix                 // prefix:*
+	testAny                    // *
+	testNode                   // node()
+	testText                   // text()
+	testNone                   // comment() and processing-instruction()
+)
+
+type nodeTest struct {
+	kind   testKind
+	prefix string
+	local  string
+}
+
+type step struct {
+	axis  string
+	test  nodeTest
+	preds []expr
+}
+
+type pathExpr struct {
+	filter   expr
+	absolute bool
+	steps    []*step
+}
+
+var axes = map[string]bool{
+	"ancestor": true, "ancestor-or-self": true, "attribute": true, "child": true,
+	"descendant": true, "descendant-or-self": true, "following": true,
+	"following-sibling": true, "namespace": true, "parent": true, "preceding": true,
+	"preceding-sibling": true, "self": true,
+}
+
+func (e *pathExpr) eval(c *context) (interface{}, error) {
+	var ns nodeSet
+	switch {
+	case e.filter != nil:
+		var err error
+		if ns, err = evalNodes(c, e.filter); err != nil {
+			return nil, err
+		}
+	case e.absolute:
+		ns = nodeSet{Root(c.node)}
+	default:
+		ns = nodeSet{c.node}
+	}
+	for _, s := range e.steps {
+		var res nodeSet
+		for _, n := range ns {
+			sel, err := s.eval(c, n)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, sel...)
+		}
+		if len(ns) > 1 || isReverse(s.axis) {
+			res = c.order.sort(res)
+		}
+		ns = res
+	}
+	return ns, nil
+}
+
+func isReverse(axis string) bool {
+	switch axis {
+	case "ancestor", "ancestor-or-self", "preceding", "preceding-sibling":
+		return true
+	}
+	return false
+}
+
+// The nodes selected by a step from a node. The nodes are in the order of
+// the axis for the positions in the predicates.
+func (s *step) eval(c *context, n Node) (nodeSet, error) {
+	var res nodeSet
+	var err error
+	visit := func(m Node) {
+		if err == nil {
+			var ok bool
+			if ok, err = s.test.match(c, m); ok {
+				res = append(res, m)
+			}
+		}
+	}
+	switch s.axis {
+	case "self":
+		visit(n)
+	case "child":
+		for _, m := range n.Children() {
+			visit(m)
+		}
+	case "parent":
+		if p := n.Parent(); p != nil {
+			visit(p)
+		}
+	case "descendant", "descendant-or-self":
+		if s.axis == "descendant-or-self" {
+			visit(n)
+		}
+		descendants(n, visit)
+	case "ancestor", "ancestor-or-self":
+		if s.axis == "ancestor-or-self" {
+			visit(n)
+		}
+		for p := n.Parent(); p != nil; p = p.Parent() {
+			visit(p)
+		}
+	case "following-sibling", "preceding-sibling":
+		siblings := siblingsOf(n)
+		i := indexOf(siblings, n)
+		if s.axis == "following-sibling" {
+			for _, m := range siblings[i+1:] {
+				visit(m)
+			}
+		} else {
+			for j := i - 1; j >= 0; j-- {
+				visit(siblings[j])
+			}
+		}
+	case "following":
+		for m := n; m.Parent() != nil; m = m.Parent() {
+			siblings := siblingsOf(m)
+			for _, f := range siblings[indexOf(siblings, m)+1:] {
+				visit(f)
+				descendants(f, visit)
+			}
+		}
+	case "preceding":
+		// The ancestors are not preceding nodes
+		for m := n; m.Parent() != nil; m = m.Parent() {
+			siblings := siblingsOf(m)
+			for j := indexOf(siblings, m) - 1; j >= 0; j-- {
+				var sub nodeSet
+				sub = append(sub, siblings[j])
+				descendants(siblings[j], func(d Node) { sub = append(sub, d) })
+				for k := len(sub) - 1; k >= 0; k-- {
+					visit(sub[k])
+				}
+			}
+		}
+	}
+	// The attribute and namespace axes are always empty
+	if err != nil {
+		return nil, err
+	}
+	for _, pred := range s.preds {
+		if res, err = filter(c, res, pred); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func descendants(n Node, visit func(Node)) {
+	for _, m := range n.Children() {
+		visit(m)
+		descendants(m, visit)
+	}
+}
+
+func siblingsOf(n Node) []Node {
+	if p := n.Parent(); p != nil {
+		return p.Children()
+	}
+	return []Node{n}
+}
+
+func indexOf(nodes []Node, n Node) int {
+	for i, m := range nodes {
+		if m == n {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t nodeTest) match(c *context, n Node) (bool, error) {
+	switch t.kind {
+	case testNode:
+		return true, nil
+	case testText, testNone:
+		return false, nil
+	}
+	ns, local := n.Name()
+	if local == "" {
+		return false, nil
+	}
+	if t.kind == testAny {
+		return true, nil
+	}
+	want, err := c.namespace(t.prefix)
+	if err != nil {
+		return false, err
+	}
+	if ns != want {
+		return false, nil
+	}
+	return t.kind == testPrefix || local == t.local, nil
+}
+
+// The namespace of a prefix. No prefix stands for the namespace of the
+// current node.
+func (c *context) namespace(prefix string) (string, error) {
+	if prefix == "" {
+		ns, _ := c.current.Name()
+		return ns, nil
+	}
+	ns, ok := c.env.Prefixes[prefix]
+	if !ok {
+		return "", errorf("unknown prefix %s", prefix)
+	}
+	return ns, nil
+}
+
+// The document order is given by the positions of the nodes from the
+// root. They are computed once per evaluation.
+
+type docOrder struct {
+	keys map[Node][]int
+}
+
+func (o *docOrder) key(n Node) []int {
+	if k, ok := o.keys[n]; ok {
+		return k
+	}
+	var k []int
+	if p := orderParent(n); p != nil {
+		pk := o.key(p)
+		k = append(append(make([]int, 0, len(pk)+1), pk...), indexOf(p.Children(), n))
+	}
+	o.keys[n] = k
+	return k
+}
+
+func orderParent(n Node) Node {
+	if o, ok := n.(OrderedNode); ok {
+		return o.OrderParent()
+	}
+	return n.Parent()
+}
+
+// Sort the nodes in document order and remove the duplicates
+func (o *docOrder) sort(ns nodeSet) nodeSet {
+	seen := map[Node]bool{}
+	res := ns[:0:0]
+	for _, n := range ns {
+		if !seen[n] {
+			seen[n] = true
+			res = append(res, n)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := o.key(res[i]), o.key(res[j])
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return res
+}
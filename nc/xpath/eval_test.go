@@ -0,0 +1,164 @@
+package xpath
+
+import "testing"
+
+func TestOperators(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{"1 + 2 * 3", "7"},
+		{"(1 + 2) * 3", "9"},
+		{"10 - 4 - 3", "3"},
+		{"8 div 2 div 2", "2"},
+		{"10 div 4", "2.5"},
+		{"7 mod 3", "1"},
+		{"-7 mod 3", "-1"},
+		{"1 div 0", "Infinity"},
+		{"-1 div 0", "-Infinity"},
+		{"0 div 0", "NaN"},
+		{"- - 2", "2"},
+		{"-2 - -3", "1"},
+		{"2 * -3 + 1", "-5"},
+		{"1 + 2 = 3", "true"},
+		{"1 < 2 = 2 > 1", "true"},
+		{"3 > 2 > 1", "false"},
+		{"1 = 1 or 1 div 0", "true"},
+		{"true() or false() and false()", "true"},
+		{"(true() or false()) and false()", "false"},
+		{"1 < 2 and 2 < 3", "true"},
+		{"1 = 2 or 2 = 2", "true"},
+		{"'1' + '2'", "3"},
+		{"'a' + 1", "NaN"},
+		{"true() + 1", "2"},
+		{"1 != 1", "false"},
+		{"2 >= 2 and 2 <= 2", "true"},
+		{"'abc' = 'abc'", "true"},
+		{"'2' = 2", "true"},
+		{"'2.0' = 2", "true"},
+		{"true() = 'x'", "true"},
+		{"false() = ''", "true"},
+		{"'10' < '9'", "false"},
+		{"mtu - 500", "1000"},
+		{"mtu*2", "3000"},
+		{"mtu div 3", "500"},
+		{"mtu mod 7", "2"},
+		{"hostname", "[hostname=r1]"},
+		{"mtu | hostname", "[hostname=r1 mtu=1500]"},
+		{"iface/name | primary | iface[1]/name", "[name=eth0 name=eth1 name=eth2 primary=eth1]"},
+	})
+}
+
+func TestNodeSetComparisons(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		// A node-set compares true when one of its nodes does
+		{"iface/ifindex = 2", "true"},
+		{"iface/ifindex != 2", "true"},
+		{"iface/ifindex = 4", "false"},
+		{"iface/ifindex > 2", "true"},
+		{"iface/ifindex > 3", "false"},
+		{"iface/ifindex < 1", "false"},
+		{"2 < iface/ifindex", "true"},
+		{"iface/name = 'eth1'", "true"},
+		{"iface/name != 'eth1'", "true"},
+		{"'eth1' = iface/name", "true"},
+		{"iface/state = 'down'", "true"},
+		{"not(iface/state = 'unknown')", "true"},
+		// Two node-sets compare their string-values pairwise
+		{"iface/ifindex = iface/speed", "false"},
+		{"iface/ifindex < iface/speed", "true"},
+		{"iface[1]/name = iface[2]/name", "false"},
+		{"primary = iface/name", "true"},
+		{"iface/speed = b:x", "false"},
+		{"iface/ifindex = b:x", "true"},
+		// An empty node-set compares false whatever the operator
+		{"missing = 'x'", "false"},
+		{"missing != 'x'", "false"},
+		{"missing = missing", "false"},
+		{"missing != missing", "false"},
+		{"missing < 1", "false"},
+		{"missing = iface/name", "false"},
+		// With a boolean the node-set is converted to a boolean
+		{"iface = true()", "true"},
+		{"missing = false()", "true"},
+		{"missing != true()", "true"},
+		// The string-value of the nodes that aren't leaves
+		{"iface[1] = 'eth0up110'", "true"},
+		{"count(iface[. = 'eth23'])", "1"},
+	})
+}
+
+func TestPredicates(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{"iface[2]/name", "[name=eth1]"},
+		{"iface[last()]/name", "[name=eth2]"},
+		{"iface[last() - 1]/name", "[name=eth1]"},
+		{"iface[position() > 1]/name", "[name=eth1 name=eth2]"},
+		{"iface[position() > 1][1]/name", "[name=eth1]"},
+		{"iface[1][2]", "[]"},
+		{"iface[4]", "[]"},
+		{"iface[1.5]", "[]"},
+		{"iface[state]/name", "[name=eth0 name=eth1]"},
+		{"iface[not(state)]/name", "[name=eth2]"},
+		{"iface[state = 'up']/ifindex", "[ifindex=1]"},
+		{"iface[name = 'eth1' and state = 'down']/ifindex", "[ifindex=2]"},
+		{"iface[ifindex > 1][speed]/name", "[name=eth1]"},
+		{"iface[speed = 10 or ifindex = 3]/name", "[name=eth0 name=eth2]"},
+		{"iface[name = ../primary]/ifindex", "[ifindex=2]"},
+		{"count(iface[ifindex = count(../iface)])", "1"},
+		{"iface[count(*) = 2]/name", "[name=eth2]"},
+		{"(iface/name)[2]", "[name=eth1]"},
+		{"(iface/name)[last()]", "[name=eth2]"},
+		{"(iface | hostname)[1]", "[hostname=r1]"},
+		{"iface/name[1]", "[name=eth0 name=eth1 name=eth2]"},
+		{"iface[true()]/ifindex[. > 1]", "[ifindex=2 ifindex=3]"},
+		{"iface['']", "[]"},
+		{"iface['x']/ifindex", "[ifindex=1 ifindex=2 ifindex=3]"},
+	})
+}
+
+func TestAxes(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{"child::hostname", "[hostname=r1]"},
+		{"./hostname", "[hostname=r1]"},
+		{"self::sys/mtu", "[mtu=1500]"},
+		{"self::iface", "[]"},
+		{"count(*)", "10"},
+		{"count(a:*)", "9"},
+		{"b:*", "[x=1]"},
+		{"count(node())", "10"},
+		{"text()", "[]"},
+		{"attribute::name", "[]"},
+		{"@name", "[]"},
+		{"count(..)", "1"},
+		{"../*", "[sys other]"},
+		{"../b:other/b:y", "[y=5]"},
+		{"/a:sys/hostname", "[hostname=r1]"},
+		{"/b:other/y", "[]"},
+		{"/*", "[sys other]"},
+		{"/", "[]"},
+		{"count(/)", "1"},
+		{"parent::node()", "[]"},
+		{"iface[1]/parent::sys/mtu", "[mtu=1500]"},
+		{"iface[1]/name/ancestor::*", "[sys iface]"},
+		{"iface[1]/name/ancestor::iface/ifindex", "[ifindex=1]"},
+		{"iface[1]/name/ancestor-or-self::*", "[sys iface name=eth0]"},
+		{"iface[1]/name/ancestor::*[1]", "[iface]"},
+		{"iface[1]/name/ancestor::*[last()]", "[sys]"},
+		{"//ifindex", "[ifindex=1 ifindex=2 ifindex=3]"},
+		{"count(//*)", "23"},
+		{"count(descendant::*)", "20"},
+		{"count(descendant-or-self::*)", "21"},
+		{"descendant::state", "[state=up state=down]"},
+		{".//iface//speed", "[speed=10 speed=20]"},
+		{"iface[2]/following-sibling::iface/name", "[name=eth2]"},
+		{"iface[2]/preceding-sibling::iface/name", "[name=eth0]"},
+		{"iface[3]/preceding-sibling::iface[1]/name", "[name=eth1]"},
+		{"iface[1]/following-sibling::*[1]", "[iface]"},
+		{"mtu/preceding-sibling::*", "[hostname=r1]"},
+		{"iface[3]/following::*", "[primary=eth1 kind=a:ethernet color=blue flags=up down x=1 other y=5]"},
+		{"count(iface[3]/following::*)", "7"},
+		{"iface[1]/preceding::*", "[hostname=r1 mtu=1500]"},
+		{"iface[2]/name/preceding::name", "[name=eth0]"},
+		{"iface[2]/name/preceding::*[1]", "[speed=10]"},
+		{"count(b:x/following::*)", "2"},
+		{"namespace::*", "[]"},
+	})
+}
@@ -0,0 +1,502 @@
+package xpath
+
+import (
+	"encoding/xml"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A function with the minimum and the maximum number of its arguments, -1
+// for no maximum. The arguments are evaluated by the function.
+type function struct {
+	min int
+	max int
+	fn  func(c *context, args []expr) (interface{}, error)
+}
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		// The node-set functions
+		"last":          {0, 0, fnLast},
+		"position":      {0, 0, fnPosition},
+		"count":         {1, 1, fnCount},
+		"id":            {1, 1, fnId},
+		"local-name":    {0, 1, fnLocalName},
+		"namespace-uri": {0, 1, fnNamespaceUri},
+		"name":          {0, 1, fnLocalName},
+		// The string functions
+		"string":           {0, 1, fnString},
+		"concat":           {2, -1, fnConcat},
+		"starts-with":      {2, 2, fnStartsWith},
+		"contains":         {2, 2, fnContains},
+		"substring-before": {2, 2, fnSubstringBefore},
+		"substring-after":  {2, 2, fnSubstringAfter},
+		"substring":        {2, 3, fnSubstring},
+		"string-length":    {0, 1, fnStringLength},
+		"normalize-space":  {0, 1, fnNormalizeSpace},
+		"translate":        {3, 3, fnTranslate},
+		// The boolean functions
+		"boolean": {1, 1, fnBoolean},
+		"not":     {1, 1, fnNot},
+		"true":    {0, 0, fnTrue},
+		"false":   {0, 0, fnFalse},
+		"lang":    {1, 1, fnFalse},
+		// The number functions
+		"number":  {0, 1, fnNumber},
+		"sum":     {1, 1, fnSum},
+		"floor":   {1, 1, fnFloor},
+		"ceiling": {1, 1, fnCeiling},
+		"round":   {1, 1, fnRound},
+		// The functions of yang 1.1
+		"current":              {0, 0, fnCurrent},
+		"deref":                {1, 1, fnDeref},
+		"derived-from":         {2, 2, fnDerivedFrom},
+		"derived-from-or-self": {2, 2, fnDerivedFromOrSelf},
+		"re-match":             {2, 2, fnReMatch},
+		"enum-value":           {1, 1, fnEnumValue},
+		"bit-is-set":           {2, 2, fnBitIsSet},
+	}
+}
+
+func evalString(c *context, e expr) (string, error) {
+	v, err := e.eval(c)
+	if err != nil {
+		return "", err
+	}
+	return toString(v), nil
+}
+
+func evalNumber(c *context, e expr) (float64, error) {
+	v, err := e.eval(c)
+	if err != nil {
+		return 0, err
+	}
+	return toNumber(v), nil
+}
+
+// The strings of the arguments
+func evalStrings(c *context, args []expr) ([]string, error) {
+	var res []string
+	for _, a := range args {
+		s, err := evalString(c, a)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// The string of the optional argument which defaults to the context node
+func evalOptString(c *context, args []expr) (string, error) {
+	if len(args) == 0 {
+		return StringValue(c.node), nil
+	}
+	return evalString(c, args[0])
+}
+
+// The first node of the optional node-set argument which defaults to the
+// context node
+func evalOptNode(c *context, args []expr) (Node, error) {
+	if len(args) == 0 {
+		return c.node, nil
+	}
+	ns, err := evalNodes(c, args[0])
+	if err != nil || len(ns) == 0 {
+		return nil, err
+	}
+	return ns[0], nil
+}
+
+// The functions of XPath 1.0
+
+func fnLast(c *context, args []expr) (interface{}, error) {
+	return float64(c.size), nil
+}
+
+func fnPosition(c *context, args []expr) (interface{}, error) {
+	return float64(c.pos), nil
+}
+
+func fnCount(c *context, args []expr) (interface{}, error) {
+	ns, err := evalNodes(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return float64(len(ns)), nil
+}
+
+// There are no ID attributes in the data of yang
+func fnId(c *context, args []expr) (interface{}, error) {
+	if _, err := args[0].eval(c); err != nil {
+		return nil, err
+	}
+	return nodeSet{}, nil
+}
+
+// The names of the data are not prefixed and name() is local-name()
+func fnLocalName(c *context, args []expr) (interface{}, error) {
+	n, err := evalOptNode(c, args)
+	if err != nil || n == nil {
+		return "", err
+	}
+	_, name := n.Name()
+	return name, nil
+}
+
+func fnNamespaceUri(c *context, args []expr) (interface{}, error) {
+	n, err := evalOptNode(c, args)
+	if err != nil || n == nil {
+		return "", err
+	}
+	ns, _ := n.Name()
+	return ns, nil
+}
+
+func fnString(c *context, args []expr) (interface{}, error) {
+	return evalOptString(c, args)
+}
+
+func fnConcat(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Join(s, ""), nil
+}
+
+func fnStartsWith(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(s[0], s[1]), nil
+}
+
+func fnContains(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(s[0], s[1]), nil
+}
+
+func fnSubstringBefore(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	if i := strings.Index(s[0], s[1]); i >= 0 {
+		return s[0][:i], nil
+	}
+	return "", nil
+}
+
+func fnSubstringAfter(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	if i := strings.Index(s[0], s[1]); i >= 0 {
+		return s[0][i+len(s[1]):], nil
+	}
+	return "", nil
+}
+
+// The characters whose position p, counted from 1, satisfies
+// round(start) <= p < round(start) + round(length)
+func fnSubstring(c *context, args []expr) (interface{}, error) {
+	s, err := evalString(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, err := evalNumber(c, args[1])
+	if err != nil {
+		return nil, err
+	}
+	first := round(start)
+	last := math.Inf(1)
+	if len(args) == 3 {
+		length, err := evalNumber(c, args[2])
+		if err != nil {
+			return nil, err
+		}
+		last = first + round(length)
+	}
+	var b strings.Builder
+	p := 1.0
+	for _, r := range s {
+		if p >= first && p < last {
+			b.WriteRune(r)
+		}
+		p++
+	}
+	return b.String(), nil
+}
+
+func fnStringLength(c *context, args []expr) (interface{}, error) {
+	s, err := evalOptString(c, args)
+	if err != nil {
+		return nil, err
+	}
+	return float64(utf8.RuneCountInString(s)), nil
+}
+
+func fnNormalizeSpace(c *context, args []expr) (interface{}, error) {
+	s, err := evalOptString(c, args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r < utf8.RuneSelf && isSpace(byte(r))
+	}), " "), nil
+}
+
+// The characters of the second string are replaced by those at the same
+// position in the third one or removed when it is shorter.
+func fnTranslate(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	from, to := []rune(s[1]), []rune(s[2])
+	var b strings.Builder
+	for _, r := range s[0] {
+		i := 0
+		for i < len(from) && from[i] != r {
+			i++
+		}
+		switch {
+		case i == len(from):
+			b.WriteRune(r)
+		case i < len(to):
+			b.WriteRune(to[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func fnBoolean(c *context, args []expr) (interface{}, error) {
+	v, err := args[0].eval(c)
+	if err != nil {
+		return nil, err
+	}
+	return toBool(v), nil
+}
+
+func fnNot(c *context, args []expr) (interface{}, error) {
+	v, err := args[0].eval(c)
+	if err != nil {
+		return nil, err
+	}
+	return !toBool(v), nil
+}
+
+func fnTrue(c *context, args []expr) (interface{}, error) {
+	return true, nil
+}
+
+func fnFalse(c *context, args []expr) (interface{}, error) {
+	for _, a := range args {
+		if _, err := a.eval(c); err != nil {
+			return nil, err
+		}
+	}
+	return false, nil
+}
+
+func fnNumber(c *context, args []expr) (interface{}, error) {
+	if len(args) == 0 {
+		return stringNumber(StringValue(c.node)), nil
+	}
+	return evalNumber(c, args[0])
+}
+
+func fnSum(c *context, args []expr) (interface{}, error) {
+	ns, err := evalNodes(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	var sum float64
+	for _, n := range ns {
+		sum += stringNumber(StringValue(n))
+	}
+	return sum, nil
+}
+
+func fnFloor(c *context, args []expr) (interface{}, error) {
+	f, err := evalNumber(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return math.Floor(f), nil
+}
+
+func fnCeiling(c *context, args []expr) (interface{}, error) {
+	f, err := evalNumber(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return math.Ceil(f), nil
+}
+
+func fnRound(c *context, args []expr) (interface{}, error) {
+	f, err := evalNumber(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return round(f), nil
+}
+
+// round() of XPath rounds the halves up towards the positive infinity
+func round(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
+
+// The functions of yang 1.1 defined in the section 10 of RFC 7950
+
+func fnCurrent(c *context, args []expr) (interface{}, error) {
+	return nodeSet{c.current}, nil
+}
+
+// The nodes referred to by the first node of the argument which is a
+// leafref or an instance-identifier
+func fnDeref(c *context, args []expr) (interface{}, error) {
+	ns, err := evalNodes(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) == 0 {
+		return nodeSet{}, nil
+	}
+	d, ok := ns[0].(Derefer)
+	if !ok {
+		return nodeSet{}, nil
+	}
+	return c.order.sort(nodeSet(d.Deref())), nil
+}
+
+func fnDerivedFrom(c *context, args []expr) (interface{}, error) {
+	return derivedFrom(c, args, false)
+}
+
+func fnDerivedFromOrSelf(c *context, args []expr) (interface{}, error) {
+	return derivedFrom(c, args, true)
+}
+
+// True when the identity of one of the nodes is derived from the identity
+// of the second argument. The identity is named with a prefix of the
+// module of the expression.
+func derivedFrom(c *context, args []expr, self bool) (interface{}, error) {
+	ns, err := evalNodes(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	s, err := evalString(c, args[1])
+	if err != nil {
+		return nil, err
+	}
+	prefix, name := "", s
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		prefix, name = s[:i], s[i+1:]
+	}
+	space, err := c.namespace(prefix)
+	if err != nil {
+		return nil, err
+	}
+	base := xml.Name{Space: space, Local: name}
+	for _, n := range ns {
+		in, ok := n.(IdentityNode)
+		if !ok {
+			continue
+		}
+		id, ok := in.Identity()
+		if !ok {
+			continue
+		}
+		if self && id == base {
+			return true, nil
+		}
+		if c.derived(id, base, map[xml.Name]bool{}) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Whether an identity is derived from a base through one or more steps
+func (c *context) derived(id xml.Name, base xml.Name, seen map[xml.Name]bool) bool {
+	if c.env.Bases == nil || seen[id] {
+		return false
+	}
+	seen[id] = true
+	for _, b := range c.env.Bases(id) {
+		if b == base || c.derived(b, base, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+var patterns sync.Map
+
+func fnReMatch(c *context, args []expr) (interface{}, error) {
+	s, err := evalStrings(c, args)
+	if err != nil {
+		return nil, err
+	}
+	re, ok := patterns.Load(s[1])
+	if !ok {
+		expr, err := xsdToRE2(s[1])
+		if err != nil {
+			return nil, errorf("re-match(): %s: %s", s[1], err.Error())
+		}
+		re = regexp.MustCompile(expr)
+		patterns.Store(s[1], re)
+	}
+	return re.(*regexp.Regexp).MatchString(s[0]), nil
+}
+
+func fnEnumValue(c *context, args []expr) (interface{}, error) {
+	ns, err := evalNodes(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) > 0 {
+		if e, ok := ns[0].(EnumNode); ok {
+			if v, ok := e.EnumValue(); ok {
+				return float64(v), nil
+			}
+		}
+	}
+	return math.NaN(), nil
+}
+
+// The value of bits is the list of the names of the bits set
+func fnBitIsSet(c *context, args []expr) (interface{}, error) {
+	ns, err := evalNodes(c, args[0])
+	if err != nil {
+		return nil, err
+	}
+	name, err := evalString(c, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) == 0 {
+		return false, nil
+	}
+	for _, b := range strings.Fields(ns[0].Value()) {
+		if b == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,138 @@
+package xpath
+
+import "testing"
+
+func TestCoreFunctions(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{"last()", "1"},
+		{"position()", "1"},
+		{"count(iface)", "3"},
+		{"count(missing)", "0"},
+		{"id('x')", "[]"},
+		{"local-name()", `"sys"`},
+		{"local-name(b:x)", `"x"`},
+		{"local-name(missing)", `""`},
+		{"name(iface)", `"iface"`},
+		{"namespace-uri()", `"urn:a"`},
+		{"namespace-uri(../b:other)", `"urn:b"`},
+		{"string()", `"r11500eth0up110eth1down220eth23eth1a:ethernetblueup down1"`},
+		{"string(iface/name)", `"eth0"`},
+		{"string(1 div 0)", `"Infinity"`},
+		{"string(0.5)", `"0.5"`},
+		{"string(-0)", `"0"`},
+		{"string(true())", `"true"`},
+		{"concat('a', hostname, 1, true())", `"ar11true"`},
+		{"starts-with(hostname, 'r')", "true"},
+		{"starts-with(hostname, '1')", "false"},
+		{"contains(flags, 'own')", "true"},
+		{"substring-before('1999/04/01', '/')", `"1999"`},
+		{"substring-after('1999/04/01', '/')", `"04/01"`},
+		{"substring-after('abc', 'x')", `""`},
+		{"substring('12345', 2, 3)", `"234"`},
+		{"substring('12345', 2)", `"2345"`},
+		{"substring('12345', 1.5, 2.6)", `"234"`},
+		{"substring('12345', 0, 3)", `"12"`},
+		{"substring('12345', 0 div 0, 3)", `""`},
+		{"substring('12345', 1, 0 div 0)", `""`},
+		{"substring('12345', -42, 1 div 0)", `"12345"`},
+		{"substring('12345', -1 div 0, 1 div 0)", `""`},
+		{"string-length(hostname)", "2"},
+		{"string-length('héllo')", "5"},
+		{"normalize-space('  a   b  ')", `"a b"`},
+		{"translate('bar', 'abc', 'ABC')", `"BAr"`},
+		{"translate('--aaa--', 'abc-', 'ABC')", `"AAA"`},
+		{"boolean(iface)", "true"},
+		{"boolean(missing)", "false"},
+		{"boolean('')", "false"},
+		{"boolean(0 div 0)", "false"},
+		{"not(0)", "true"},
+		{"true()", "true"},
+		{"false()", "false"},
+		{"lang('en')", "false"},
+		{"number(mtu)", "1500"},
+		{"number('  12 ')", "12"},
+		{"number('1e3')", "NaN"},
+		{"number('+1')", "NaN"},
+		{"number('.5')", "0.5"},
+		{"number(true())", "1"},
+		{"sum(iface/ifindex)", "6"},
+		{"sum(iface/state)", "NaN"},
+		{"sum(missing)", "0"},
+		{"floor(2.5)", "2"},
+		{"floor(-2.5)", "-3"},
+		{"ceiling(2.1)", "3"},
+		{"ceiling(-2.5)", "-2"},
+		{"round(2.5)", "3"},
+		{"round(-2.5)", "-2"},
+		{"round(0 div 0)", "NaN"},
+		{"iface[last()]/ifindex", "[ifindex=3]"},
+	})
+}
+
+// The functions of RFC 7950 section 10
+func TestYangFunctions(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		// current() is the node of the expression within the predicates
+		{"current()", "[sys]"},
+		{"iface[name = current()/primary]/ifindex", "[ifindex=2]"},
+		{"iface[ifindex = count(current()/iface)]/name", "[name=eth2]"},
+		{"iface/name[. = current()/primary]/../state", "[state=down]"},
+		// deref() follows the leafref to the node it refers to
+		{"deref(primary)", "[name=eth1]"},
+		{"deref(primary)/../ifindex", "[ifindex=2]"},
+		{"deref(primary)/../state = 'down'", "true"},
+		{"deref(hostname)", "[]"},
+		{"deref(missing)", "[]"},
+		// derived-from() needs a base and follows the chain of the bases
+		{"derived-from(kind, 'a:wired')", "true"},
+		{"derived-from(kind, 'a:base')", "true"},
+		{"derived-from(kind, 'a:ethernet')", "false"},
+		{"derived-from(kind, 'b:base')", "false"},
+		{"derived-from(hostname, 'a:base')", "false"},
+		{"derived-from(missing, 'a:base')", "false"},
+		{"derived-from-or-self(kind, 'a:ethernet')", "true"},
+		{"derived-from-or-self(kind, 'ethernet')", "true"},
+		{"derived-from-or-self(kind, 'a:wireless')", "false"},
+		// re-match() matches the whole string with a pattern of XSD
+		{"re-match('abc', '[a-c]+')", "true"},
+		{"re-match('abcd', '[a-c]+')", "false"},
+		{"re-match('xabc', '[a-c]+')", "false"},
+		{"re-match(hostname, '[a-z][a-z0-9-]*')", "true"},
+		{"re-match('1.22.333', '\\d{1,3}\\.\\d{1,3}\\.\\d{1,3}')", "true"},
+		{"re-match('^a$', '\\^a\\$')", "true"},
+		{"re-match('^a', '^a')", "true"},
+		{"re-match('a\nb', 'a.b')", "false"},
+		{"re-match('éa', '\\p{L}a')", "true"},
+		{"re-match('', 'a*')", "true"},
+		{"re-match('ab', 'a|b')", "false"},
+		// enum-value() is the value assigned to the enum
+		{"enum-value(color)", "2"},
+		{"enum-value(color) = 2", "true"},
+		{"enum-value(hostname)", "NaN"},
+		{"enum-value(missing)", "NaN"},
+		// bit-is-set() checks the names of the bits that are set
+		{"bit-is-set(flags, 'down')", "true"},
+		{"bit-is-set(flags, 'up')", "true"},
+		{"bit-is-set(flags, 'do')", "false"},
+		{"bit-is-set(missing, 'up')", "false"},
+	})
+}
+
+func TestFunctionErrors(t *testing.T) {
+	_, sys := testTree()
+	for _, s := range []string{
+		"re-match('a', '[a-z-[aeiou]]')",
+		"derived-from(kind, 'c:base')",
+		"count('a')",
+		"deref('a')",
+	} {
+		e, err := Compile(s)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", s, err)
+			continue
+		}
+		if v, err := e.Eval(testEnv, sys); err == nil {
+			t.Errorf("%s = %s, want an error", s, show(v))
+		}
+	}
+}
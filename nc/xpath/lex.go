@@ -0,0 +1,192 @@
+package xpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("xpath: "+format, args...)
+}
+
+// The lexer follows the section 3.7 of XPath 1.0. The operator names and
+// the multiply operator are recognized from the preceding token.
+
+type tokKind int
+
+const (
+	tokEOF      tokKind = iota
+	tokOp               // the operators and the punctuation
+	tokName             // a name test: name, prefix:name, prefix:* or *
+	tokFunc             // a function name followed by (
+	tokAxis             // an axis name followed by ::
+	tokNodeType         // node, text, comment or processing-instruction followed by (
+	tokLiteral
+	tokNumber
+	tokVar
+)
+
+type token struct {
+	kind tokKind
+	text string
+	num  float64
+}
+
+type lexer struct {
+	s    string
+	pos  int
+	prev *token
+}
+
+func newLexer(s string) *lexer {
+	return &lexer{s: s}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c == '-' || c == '.' || (c >= '0' && c <= '9')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.s) && isSpace(l.s[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *lexer) ncname() string {
+	start := l.pos
+	for l.pos < len(l.s) && isNameChar(l.s[l.pos]) {
+		l.pos++
+	}
+	return l.s[start:l.pos]
+}
+
+// The next character that is not a space after the current position
+func (l *lexer) peekAfterSpace() (byte, int) {
+	i := l.pos
+	for i < len(l.s) && isSpace(l.s[i]) {
+		i++
+	}
+	if i < len(l.s) {
+		return l.s[i], i
+	}
+	return 0, i
+}
+
+// An operator is expected when there is a preceding token which is not
+// @, ::, (, [, , or an operator.
+func (l *lexer) operatorExpected() bool {
+	if l.prev == nil {
+		return false
+	}
+	switch l.prev.kind {
+	case tokOp:
+		switch l.prev.text {
+		case ")", "]", ".", "..":
+			return true
+		}
+		return false
+	case tokAxis, tokFunc, tokNodeType:
+		return false
+	}
+	return true
+}
+
+func (l *lexer) next() (token, error) {
+	t, err := l.scan()
+	if err == nil {
+		l.prev = &t
+	}
+	return t, err
+}
+
+func (l *lexer) scan() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.s) {
+		return token{kind: tokEOF, text: "end of expression"}, nil
+	}
+	c := l.s[l.pos]
+	switch {
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(l.s[l.pos+1:], c)
+		if end < 0 {
+			return token{}, errorf("unterminated literal in %s", l.s)
+		}
+		t := token{kind: tokLiteral, text: l.s[l.pos+1 : l.pos+1+end]}
+		l.pos += end + 2
+		return t, nil
+	case (c >= '0' && c <= '9') || (c == '.' && l.pos+1 < len(l.s) && l.s[l.pos+1] >= '0' && l.s[l.pos+1] <= '9'):
+		start := l.pos
+		for l.pos < len(l.s) && ((l.s[l.pos] >= '0' && l.s[l.pos] <= '9') || l.s[l.pos] == '.') {
+			l.pos++
+		}
+		f, err := strconv.ParseFloat(l.s[start:l.pos], 64)
+		if err != nil {
+			return token{}, errorf("invalid number %s", l.s[start:l.pos])
+		}
+		return token{kind: tokNumber, text: l.s[start:l.pos], num: f}, nil
+	case c == '$':
+		l.pos++
+		return token{kind: tokVar, text: l.ncname()}, nil
+	case c == '*':
+		l.pos++
+		if l.operatorExpected() {
+			return token{kind: tokOp, text: "*"}, nil
+		}
+		return token{kind: tokName, text: "*"}, nil
+	case isNameStart(c):
+		return l.scanName()
+	}
+	for _, op := range []string{"//", "::", "..", "!=", "<=", ">=", "/", "|", "+", "-", "=", "<", ">", "(", ")", "[", "]", ".", "@", ","} {
+		if strings.HasPrefix(l.s[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op}, nil
+		}
+	}
+	return token{}, errorf("unexpected character %q in %s", c, l.s)
+}
+
+func (l *lexer) scanName() (token, error) {
+	name := l.ncname()
+	// The names of the operators
+	if l.operatorExpected() {
+		switch name {
+		case "and", "or", "mod", "div":
+			return token{kind: tokOp, text: name}, nil
+		}
+		return token{}, errorf("unexpected %s in %s", name, l.s)
+	}
+	if l.pos+1 < len(l.s) && l.s[l.pos] == ':' && l.s[l.pos+1] != ':' {
+		l.pos++
+		if l.pos < len(l.s) && l.s[l.pos] == '*' {
+			l.pos++
+			return token{kind: tokName, text: name + ":*"}, nil
+		}
+		local := l.ncname()
+		if local == "" {
+			return token{}, errorf("invalid name %s: in %s", name, l.s)
+		}
+		name = name + ":" + local
+	}
+	c, i := l.peekAfterSpace()
+	switch {
+	case c == '(':
+		switch name {
+		case "node", "text", "comment", "processing-instruction":
+			return token{kind: tokNodeType, text: name}, nil
+		}
+		return token{kind: tokFunc, text: name}, nil
+	case c == ':' && i+1 < len(l.s) && l.s[i+1] == ':':
+		l.pos = i + 2
+		return token{kind: tokAxis, text: name}, nil
+	}
+	return token{kind: tokName, text: name}, nil
+}
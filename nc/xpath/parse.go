@@ -0,0 +1,370 @@
+package xpath
+
+import (
+	"strings"
+)
+
+// The parser is a recursive descent on the grammar of XPath 1.0. Each
+// production that doesn't reduce to the one below it becomes a node of
+// the tree of the expression.
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func (p *parser) next() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return errorf("%s: "+format, append([]interface{}{p.lex.s}, args...)...)
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	if p.tok.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if p.tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		return p.errorf("%s expected instead of %s", op, p.tok.text)
+	}
+	return p.next()
+}
+
+// The binary operators by precedence from the lowest
+var binaryOps = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "div", "mod"},
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	return p.parseBinary(0)
+}
+
+func (p *parser) parseBinary(level int) (expr, error) {
+	if level == len(binaryOps) {
+		return p.parseUnary()
+	}
+	l, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(binaryOps[level]...) {
+		op := p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryExpr{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.isOp("-") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negExpr{e: e}, nil
+	}
+	return p.parseUnion()
+}
+
+func (p *parser) parseUnion() (expr, error) {
+	l, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("|") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		l = &unionExpr{l: l, r: r}
+	}
+	return l, nil
+}
+
+// A path is a location path or a filter expression which may be followed
+// by a relative location path.
+func (p *parser) parsePath() (expr, error) {
+	switch p.tok.kind {
+	case tokName, tokAxis, tokNodeType:
+		return p.parseLocationPath()
+	case tokOp:
+		switch p.tok.text {
+		case "/", "//", ".", "..", "@":
+			return p.parseLocationPath()
+		}
+	}
+	filter, err := p.parseFilter()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("/", "//") {
+		return filter, nil
+	}
+	path := &pathExpr{filter: filter}
+	if err := p.parseRelative(path); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+func (p *parser) parseFilter() (expr, error) {
+	var primary expr
+	switch p.tok.kind {
+	case tokLiteral:
+		primary = &literalExpr{s: p.tok.text}
+	case tokNumber:
+		primary = &numberExpr{f: p.tok.num}
+	case tokVar:
+		return nil, p.errorf("variable $%s is not supported", p.tok.text)
+	case tokFunc:
+		return p.parseFunc()
+	case tokOp:
+		if p.tok.text == "(" {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, p.errorf(") expected instead of %s", p.tok.text)
+			}
+			primary = e
+			break
+		}
+		fallthrough
+	default:
+		return nil, p.errorf("unexpected %s", p.tok.text)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return p.parsePredicates(primary)
+}
+
+func (p *parser) parsePredicates(primary expr) (expr, error) {
+	var preds []expr
+	for p.isOp("[") {
+		pred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+	if preds == nil {
+		return primary, nil
+	}
+	return &filterExpr{primary: primary, preds: preds}, nil
+}
+
+func (p *parser) parsePredicate() (expr, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (p *parser) parseFunc() (expr, error) {
+	name := p.tok.text
+	f, ok := functions[name]
+	if !ok {
+		return nil, p.errorf("unknown function %s()", name)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	call := &funcExpr{name: name, fn: f}
+	for !p.isOp(")") {
+		if len(call.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+	if len(call.args) < f.min || (f.max >= 0 && len(call.args) > f.max) {
+		return nil, p.errorf("invalid number of arguments for %s()", name)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return p.parsePredicates(call)
+}
+
+func (p *parser) parseLocationPath() (expr, error) {
+	path := &pathExpr{}
+	if p.isOp("/") {
+		path.absolute = true
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		// The root alone
+		if !p.startsStep() {
+			return path, nil
+		}
+	} else if p.isOp("//") {
+		path.absolute = true
+	}
+	if !p.isOp("//") {
+		s, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		path.steps = append(path.steps, s)
+	}
+	if err := p.parseRelative(path); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+func (p *parser) startsStep() bool {
+	switch p.tok.kind {
+	case tokName, tokAxis, tokNodeType:
+		return true
+	}
+	return p.isOp(".", "..", "@")
+}
+
+// The steps separated by / and //, // standing for
+// /descendant-or-self::node()/
+func (p *parser) parseRelative(path *pathExpr) error {
+	for p.isOp("/", "//") {
+		if p.tok.text == "//" {
+			path.steps = append(path.steps, &step{axis: "descendant-or-self", test: nodeTest{kind: testNode}})
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		s, err := p.parseStep()
+		if err != nil {
+			return err
+		}
+		path.steps = append(path.steps, s)
+	}
+	return nil
+}
+
+func (p *parser) parseStep() (*step, error) {
+	if p.isOp(".") {
+		return &step{axis: "self", test: nodeTest{kind: testNode}}, p.next()
+	}
+	if p.isOp("..") {
+		return &step{axis: "parent", test: nodeTest{kind: testNode}}, p.next()
+	}
+	s := &step{axis: "child"}
+	if p.isOp("@") {
+		s.axis = "attribute"
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	} else if p.tok.kind == tokAxis {
+		if !axes[p.tok.text] {
+			return nil, p.errorf("unknown axis %s", p.tok.text)
+		}
+		s.axis = p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	switch p.tok.kind {
+	case tokName:
+		s.test = nameTest(p.tok.text)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	case tokNodeType:
+		s.test = nodeTest{kind: testNode}
+		switch p.tok.text {
+		case "text":
+			s.test.kind = testText
+		case "comment", "processing-instruction":
+			s.test.kind = testNone
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokLiteral {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf("node test expected instead of %s", p.tok.text)
+	}
+	for p.isOp("[") {
+		pred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		s.preds = append(s.preds, pred)
+	}
+	return s, nil
+}
+
+func nameTest(name string) nodeTest {
+	if name == "*" {
+		return nodeTest{kind: testAny}
+	}
+	prefix, local := "", name
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		prefix, local = name[:i], name[i+1:]
+	}
+	if local == "*" {
+		return nodeTest{kind: testPrefix, prefix: prefix}
+	}
+	return nodeTest{kind: testName, prefix: prefix, local: local}
+}
@@ -0,0 +1,198 @@
+package xpath
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The patterns of re-match() are XML schema regular expressions as those
+// of the statement pattern. They are translated to RE2 as the generator
+// does for pattern
+//   - An XSD expression always matches the complete value. It is anchored
+//     by enclosing it within ^(?: and )$
+//   - ^ and $ are ordinary characters in XSD and are escaped
+//   - . doesn't match \n and \r in XSD
+//   - \d, \s, \w, \i and \c along with their complements carry the XSD
+//     meaning which is based on unicode and not on ASCII
+//   - \p{IsBlock} refers to a unicode block which RE2 doesn't support and
+//     is replaced by the range of the block
+//
+// Character class subtraction such as [a-z-[aeiou]] has no equivalent and
+// such patterns are reported as errors.
+func xsdToRE2(pattern string) (string, error) {
+	var b strings.Builder
+	rs := []rune(pattern)
+	inClass := false
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(rs) {
+				return "", fmt.Errorf("pattern ends with \\")
+			}
+			i++
+			e := rs[i]
+			switch e {
+			case 'p', 'P':
+				if i+1 >= len(rs) || rs[i+1] != '{' {
+					return "", fmt.Errorf("missing { after \\%c", e)
+				}
+				end := i + 1
+				for end < len(rs) && rs[end] != '}' {
+					end++
+				}
+				if end >= len(rs) {
+					return "", fmt.Errorf("missing } after \\%c", e)
+				}
+				s, err := translateProperty(string(rs[i+2:end]), e == 'P', inClass)
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(s)
+				i = end
+			case 'd', 'D', 's', 'S', 'w', 'W', 'i', 'I', 'c', 'C':
+				s, err := translateClassEscape(e, inClass)
+				if err != nil {
+					return "", err
+				}
+				b.WriteString(s)
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(e)
+			}
+		case inClass:
+			switch c {
+			case '[':
+				return "", fmt.Errorf("character class subtraction is not supported")
+			case ']':
+				inClass = false
+			}
+			b.WriteRune(c)
+		case c == '[':
+			inClass = true
+			b.WriteRune(c)
+			if i+1 < len(rs) && rs[i+1] == '^' {
+				b.WriteRune('^')
+				i++
+			}
+		case c == '.':
+			b.WriteString(`[^\n\r]`)
+		case c == '^' || c == '$':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	if inClass {
+		return "", fmt.Errorf("character class is not terminated")
+	}
+	re := "^(?:" + b.String() + ")$"
+	if _, err := regexp.Compile(re); err != nil {
+		return "", err
+	}
+	return re, nil
+}
+
+// The unicode blocks that may be referred to as \p{IsBlock}. The list
+// covers the blocks used by the standard and the vendor modules.
+var xsdBlocks = map[string]string{
+	"BasicLatin":                  `\x{0000}-\x{007F}`,
+	"Latin-1Supplement":           `\x{0080}-\x{00FF}`,
+	"LatinExtended-A":             `\x{0100}-\x{017F}`,
+	"LatinExtended-B":             `\x{0180}-\x{024F}`,
+	"IPAExtensions":               `\x{0250}-\x{02AF}`,
+	"SpacingModifierLetters":      `\x{02B0}-\x{02FF}`,
+	"CombiningDiacriticalMarks":   `\x{0300}-\x{036F}`,
+	"Greek":                       `\x{0370}-\x{03FF}`,
+	"GreekandCoptic":              `\x{0370}-\x{03FF}`,
+	"Cyrillic":                    `\x{0400}-\x{04FF}`,
+	"Armenian":                    `\x{0530}-\x{058F}`,
+	"Hebrew":                      `\x{0590}-\x{05FF}`,
+	"Arabic":                      `\x{0600}-\x{06FF}`,
+	"Devanagari":                  `\x{0900}-\x{097F}`,
+	"Thai":                        `\x{0E00}-\x{0E7F}`,
+	"LatinExtendedAdditional":     `\x{1E00}-\x{1EFF}`,
+	"GreekExtended":               `\x{1F00}-\x{1FFF}`,
+	"GeneralPunctuation":          `\x{2000}-\x{206F}`,
+	"SuperscriptsandSubscripts":   `\x{2070}-\x{209F}`,
+	"CurrencySymbols":             `\x{20A0}-\x{20CF}`,
+	"LetterlikeSymbols":           `\x{2100}-\x{214F}`,
+	"NumberForms":                 `\x{2150}-\x{218F}`,
+	"Arrows":                      `\x{2190}-\x{21FF}`,
+	"MathematicalOperators":       `\x{2200}-\x{22FF}`,
+	"BoxDrawing":                  `\x{2500}-\x{257F}`,
+	"CJKSymbolsandPunctuation":    `\x{3000}-\x{303F}`,
+	"Hiragana":                    `\x{3040}-\x{309F}`,
+	"Katakana":                    `\x{30A0}-\x{30FF}`,
+	"CJKUnifiedIdeographs":        `\x{4E00}-\x{9FFF}`,
+	"HangulSyllables":             `\x{AC00}-\x{D7AF}`,
+	"PrivateUseArea":              `\x{E000}-\x{F8FF}`,
+	"AlphabeticPresentationForms": `\x{FB00}-\x{FB4F}`,
+	"HalfwidthandFullwidthForms":  `\x{FF00}-\x{FFEF}`,
+	"Specials":                    `\x{FFF0}-\x{FFFF}`,
+}
+
+// Translate \p{name} and \P{name}. The categories such as L and Nd are
+// the same in RE2 whereas the blocks are translated to their ranges.
+func translateProperty(name string, negate bool, inClass bool) (string, error) {
+	if !strings.HasPrefix(name, "Is") {
+		if negate {
+			return `\P{` + name + `}`, nil
+		}
+		return `\p{` + name + `}`, nil
+	}
+	r, ok := xsdBlocks[strings.TrimPrefix(name, "Is")]
+	if !ok {
+		return "", fmt.Errorf("unicode block %s is not supported", name)
+	}
+	switch {
+	case !inClass && negate:
+		return "[^" + r + "]", nil
+	case !inClass:
+		return "[" + r + "]", nil
+	case negate:
+		return "", fmt.Errorf("\\P{%s} is not supported within a character class", name)
+	default:
+		return r, nil
+	}
+}
+
+// Translate the multi character escapes of XSD. Each escape has a form
+// for use outside a character class and another for use within one. The
+// complements that can't be expressed within a character class are
+// reported as errors.
+func translateClassEscape(e rune, inClass bool) (string, error) {
+	var set string
+	negate := false
+	switch e {
+	case 'd':
+		return `\p{Nd}`, nil
+	case 'D':
+		return `\P{Nd}`, nil
+	case 's', 'S':
+		set = `\t\n\r `
+		negate = e == 'S'
+	case 'w':
+		set = `\p{L}\p{M}\p{N}\p{S}`
+	case 'W':
+		set = `\p{P}\p{Z}\p{C}`
+	case 'i', 'I':
+		set = `\p{L}_:`
+		negate = e == 'I'
+	case 'c', 'C':
+		set = `\p{L}\p{N}\p{Mn}\p{Mc}._:\-`
+		negate = e == 'C'
+	}
+	switch {
+	case inClass && negate:
+		return "", fmt.Errorf("\\%c is not supported within a character class", e)
+	case inClass:
+		return set, nil
+	case negate:
+		return "[^" + set + "]", nil
+	default:
+		return "[" + set + "]", nil
+	}
+}
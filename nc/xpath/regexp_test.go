@@ -0,0 +1,72 @@
+package xpath
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestXsdToRE2(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		nomatch []string
+	}{
+		{`[a-z]+`, []string{"abc"}, []string{"", "aBc", "abc\n"}},
+		{`a|b`, []string{"a", "b"}, []string{"ab"}},
+		{`^a$`, []string{"^a$"}, []string{"a"}},
+		{`a.c`, []string{"abc", "a c"}, []string{"a\nc", "a\rc"}},
+		{`\d+`, []string{"123", "١٢٣"}, []string{"12a"}},
+		{`\D`, []string{"a"}, []string{"1"}},
+		{`\s\S`, []string{" a", "\ta"}, []string{"  ", "a "}},
+		{`\w+`, []string{"héllo", "a1+"}, []string{"a b", "a-b"}},
+		{`\W`, []string{" ", "-"}, []string{"a"}},
+		{`\i\c*`, []string{"_a-1.b:c", "é"}, []string{"1a", "-a"}},
+		{`\I\C`, []string{"1 "}, []string{"a1", "1a"}},
+		{`[\d\s]+`, []string{"1 2"}, []string{"a"}},
+		{`[^\d]+`, []string{"abc"}, []string{"a1"}},
+		{`[\i-]+`, []string{"a-b"}, []string{"1"}},
+		{`\p{Lu}\p{Ll}*`, []string{"Abc"}, []string{"abc"}},
+		{`\P{L}`, []string{"1"}, []string{"a"}},
+		{`\p{IsBasicLatin}+`, []string{"abc"}, []string{"é"}},
+		{`\P{IsBasicLatin}`, []string{"é"}, []string{"a"}},
+		{`[\p{IsGreek}a]+`, []string{"αa"}, []string{"b"}},
+		{`\.\*\\`, []string{`.*\`}, []string{"a*\\"}},
+		{`(\d{1,3}\.){3}\d{1,3}`, []string{"10.0.0.1"}, []string{"10.0.0", "1000.0.0.1"}},
+	}
+	for _, tt := range tests {
+		expr, err := xsdToRE2(tt.pattern)
+		if err != nil {
+			t.Errorf("xsdToRE2(%q): %v", tt.pattern, err)
+			continue
+		}
+		re := regexp.MustCompile(expr)
+		for _, s := range tt.match {
+			if !re.MatchString(s) {
+				t.Errorf("%q (%s) doesn't match %q", tt.pattern, expr, s)
+			}
+		}
+		for _, s := range tt.nomatch {
+			if re.MatchString(s) {
+				t.Errorf("%q (%s) matches %q", tt.pattern, expr, s)
+			}
+		}
+	}
+}
+
+func TestXsdToRE2Errors(t *testing.T) {
+	for _, p := range []string{
+		`[a-z-[aeiou]]`,
+		`[abc`,
+		`abc\`,
+		`\p{IsKlingon}`,
+		`\p{L`,
+		`\pL`,
+		`[\P{IsGreek}]`,
+		`[\S]`,
+		`(a`,
+	} {
+		if expr, err := xsdToRE2(p); err == nil {
+			t.Errorf("xsdToRE2(%q) = %s, want an error", p, expr)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package xpath evaluates the XPath 1.0 expressions of yang, those of the
+// statements must and when and of the paths of leafref, over a data tree.
+// The tree is provided through the interface Node which the runtime
+// implements over the generated structures.
+//
+// Besides the core functions of XPath 1.0, the functions of yang 1.1 are
+// supported: current(), deref(), derived-from(), derived-from-or-self(),
+// re-match(), enum-value() and bit-is-set(). Variables are not supported
+// as yang doesn't define any.
+//
+// The names in the expressions are qualified by the prefixes of the
+// module in which the expression is defined. A name without a prefix is
+// in the namespace of the current node.
+package xpath
+
+import (
+	"encoding/xml"
+	"sync"
+)
+
+// Node is a node of the data tree. The root of the tree has no name and
+// its children are the top level data nodes.
+type Node interface {
+	// Name returns the namespace and the name of the node
+	Name() (string, string)
+	// Parent returns nil for the root
+	Parent() Node
+	// Children returns the children in document order. The entries of a
+	// list or a leaf-list are distinct nodes with the same name.
+	Children() []Node
+	// Value returns the value of a leaf as text. The value of the other
+	// nodes is made of the values of their descendants.
+	Value() string
+}
+
+// OrderedNode is implemented by the nodes whose Parent() notes that the
+// evaluation moved up the tree. The document order of the nodes isn't
+// part of the evaluation and is computed through OrderParent() instead,
+// which returns the parent alone.
+type OrderedNode interface {
+	OrderParent() Node
+}
+
+// IdentityNode is implemented by the leaves whose value is an identity
+type IdentityNode interface {
+	Identity() (xml.Name, bool)
+}
+
+// EnumNode is implemented by the leaves whose value is an enumeration
+type EnumNode interface {
+	EnumValue() (int64, bool)
+}
+
+// Derefer is implemented by the leaves of type leafref to return the
+// nodes they refer to.
+type Derefer interface {
+	Deref() []Node
+}
+
+// Env is the environment of the evaluation of an expression
+type Env struct {
+	// Prefixes maps the prefixes used in the expression to namespaces
+	Prefixes map[string]string
+	// Bases returns the identities from which an identity is derived
+	// directly. It is needed by derived-from() and derived-from-or-self().
+	Bases func(id xml.Name) []xml.Name
+}
+
+// Expr is a compiled expression
+type Expr struct {
+	src  string
+	root expr
+}
+
+// Compile parses an expression
+func Compile(s string) (*Expr, error) {
+	p := &parser{lex: newLexer(s)}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok.text)
+	}
+	return &Expr{src: s, root: e}, nil
+}
+
+var compiled sync.Map
+
+// CompileCached returns the compiled expression from a cache so that the
+// expressions evaluated repeatedly are parsed once.
+func CompileCached(s string) (*Expr, error) {
+	if e, ok := compiled.Load(s); ok {
+		return e.(*Expr), nil
+	}
+	e, err := Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	compiled.Store(s, e)
+	return e, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression with the node passed as the context node
+// and as the current node. The result is a []Node, a string, a float64
+// or a bool.
+func (e *Expr) Eval(env *Env, node Node) (interface{}, error) {
+	if env == nil {
+		env = &Env{}
+	}
+	c := &context{node: node, pos: 1, size: 1, env: env, current: node,
+		order: &docOrder{keys: map[Node][]int{}}}
+	v, err := e.root.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	if ns, ok := v.(nodeSet); ok {
+		return []Node(ns), nil
+	}
+	return v, nil
+}
+
+// Bool evaluates the expression and converts the result to a boolean
+func (e *Expr) Bool(env *Env, node Node) (bool, error) {
+	v, err := e.Eval(env, node)
+	if err != nil {
+		return false, err
+	}
+	if ns, ok := v.([]Node); ok {
+		return len(ns) > 0, nil
+	}
+	return toBool(v), nil
+}
+
+// Nodes evaluates the expression whose result must be a node-set
+func (e *Expr) Nodes(env *Env, node Node) ([]Node, error) {
+	v, err := e.Eval(env, node)
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.([]Node)
+	if !ok {
+		return nil, errorf("%s is not a node-set", e.src)
+	}
+	return ns, nil
+}
+
+// StringValue returns the string-value of a node
+func StringValue(n Node) string {
+	children := n.Children()
+	if len(children) == 0 {
+		return n.Value()
+	}
+	var s string
+	for _, c := range children {
+		s += StringValue(c)
+	}
+	return s
+}
+
+// Root returns the root of the tree that contains the node
+func Root(n Node) Node {
+	for p := n.Parent(); p != nil; p = n.Parent() {
+		n = p
+	}
+	return n
+}
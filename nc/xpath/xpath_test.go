@@ -0,0 +1,238 @@
+package xpath
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// A node of the trees of the tests
+type tnode struct {
+	ns, name string
+	value    string
+	parent   *tnode
+	children []*tnode
+	id       *xml.Name
+	enum     *int64
+	ref      []*tnode
+}
+
+func (n *tnode) Name() (string, string) {
+	return n.ns, n.name
+}
+
+func (n *tnode) Parent() Node {
+	if n.parent == nil {
+		return nil
+	}
+	return n.parent
+}
+
+func (n *tnode) Children() []Node {
+	var c []Node
+	for _, m := range n.children {
+		c = append(c, m)
+	}
+	return c
+}
+
+func (n *tnode) Value() string {
+	return n.value
+}
+
+func (n *tnode) Identity() (xml.Name, bool) {
+	if n.id == nil {
+		return xml.Name{}, false
+	}
+	return *n.id, true
+}
+
+func (n *tnode) EnumValue() (int64, bool) {
+	if n.enum == nil {
+		return 0, false
+	}
+	return *n.enum, true
+}
+
+func (n *tnode) Deref() []Node {
+	var r []Node
+	for _, m := range n.ref {
+		r = append(r, m)
+	}
+	return r
+}
+
+func (n *tnode) add(ns, name, value string) *tnode {
+	c := &tnode{ns: ns, name: name, value: value, parent: n}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *tnode) child(name string) *tnode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+const (
+	nsA = "urn:a"
+	nsB = "urn:b"
+)
+
+// The tree of the tests and its node sys which is the context node:
+//
+//	sys
+//	  hostname r1
+//	  mtu 1500
+//	  iface name=eth0 state=up ifindex=1 speed=10
+//	  iface name=eth1 state=down ifindex=2 speed=20
+//	  iface name=eth2 ifindex=3
+//	  primary eth1, a leafref to the name of eth1
+//	  kind a:ethernet, an identity derived from a:base through a:wired
+//	  color blue, the enum of value 2
+//	  flags up down, bits
+//	  b:x 1, a leaf of the module b
+//	b:other
+//	  b:y 5
+func testTree() (*tnode, *tnode) {
+	root := &tnode{}
+	sys := root.add(nsA, "sys", "")
+	sys.add(nsA, "hostname", "r1")
+	sys.add(nsA, "mtu", "1500")
+	for i, state := range []string{"up", "down", ""} {
+		iface := sys.add(nsA, "iface", "")
+		iface.add(nsA, "name", fmt.Sprintf("eth%d", i))
+		if state != "" {
+			iface.add(nsA, "state", state)
+		}
+		iface.add(nsA, "ifindex", fmt.Sprint(i+1))
+		if i < 2 {
+			iface.add(nsA, "speed", fmt.Sprint((i+1)*10))
+		}
+	}
+	primary := sys.add(nsA, "primary", "eth1")
+	primary.ref = []*tnode{sys.children[3].child("name")}
+	kind := sys.add(nsA, "kind", "a:ethernet")
+	kind.id = &xml.Name{Space: nsA, Local: "ethernet"}
+	color := sys.add(nsA, "color", "blue")
+	two := int64(2)
+	color.enum = &two
+	sys.add(nsA, "flags", "up down")
+	sys.add(nsB, "x", "1")
+	other := root.add(nsB, "other", "")
+	other.add(nsB, "y", "5")
+	return root, sys
+}
+
+var testEnv = &Env{
+	Prefixes: map[string]string{"a": nsA, "b": nsB},
+	Bases: func(id xml.Name) []xml.Name {
+		switch id.Local {
+		case "ethernet":
+			return []xml.Name{{Space: nsA, Local: "wired"}}
+		case "wired":
+			return []xml.Name{{Space: nsA, Local: "base"}}
+		}
+		return nil
+	},
+}
+
+// The text of a result: the numbers and the booleans as XPath prints
+// them, the strings quoted and the node-sets as the names of the nodes
+// with the values of the leaves
+func show(v interface{}) string {
+	switch x := v.(type) {
+	case []Node:
+		var s []string
+		for _, n := range x {
+			_, name := n.Name()
+			if len(n.Children()) == 0 {
+				name += "=" + n.Value()
+			}
+			s = append(s, name)
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	case string:
+		return fmt.Sprintf("%q", x)
+	}
+	return toString(v)
+}
+
+type evalTest struct {
+	expr string
+	want string
+}
+
+// Evaluate the expressions with sys as the context node
+func runEvalTests(t *testing.T, tests []evalTest) {
+	t.Helper()
+	_, sys := testTree()
+	for _, tt := range tests {
+		e, err := Compile(tt.expr)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.expr, err)
+			continue
+		}
+		v, err := e.Eval(testEnv, sys)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := show(v); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"1 +",
+		"(1",
+		"foo(",
+		"a[1",
+		"a/",
+		"1 2",
+		"'abc",
+		"@",
+		"unknown-function()",
+		"count()",
+		"count(a, b)",
+		"concat('a')",
+		"$var",
+		"a::b",
+	} {
+		if _, err := Compile(s); err == nil {
+			t.Errorf("Compile(%q) succeeded", s)
+		}
+	}
+}
+
+func TestResults(t *testing.T) {
+	_, sys := testTree()
+	e, _ := Compile("iface/ifindex > 2")
+	if ok, err := e.Bool(testEnv, sys); err != nil || !ok {
+		t.Errorf("Bool() = %v, %v", ok, err)
+	}
+	e, _ = Compile("iface")
+	if ns, err := e.Nodes(testEnv, sys); err != nil || len(ns) != 3 {
+		t.Errorf("Nodes() = %v, %v", ns, err)
+	}
+	e, _ = Compile("count(iface)")
+	if _, err := e.Nodes(testEnv, sys); err == nil {
+		t.Errorf("Nodes() of a number succeeded")
+	}
+	e, _ = Compile("c:x")
+	if _, err := e.Eval(testEnv, sys); err == nil {
+		t.Errorf("the unknown prefix c was accepted")
+	}
+	if a, _ := CompileCached("1 + 1"); a == nil {
+		t.Errorf("CompileCached() failed")
+	} else if b, _ := CompileCached("1 + 1"); a != b {
+		t.Errorf("CompileCached() compiled twice")
+	}
+}